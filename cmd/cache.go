@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/ares"
+	"github.com/fstaffa/czsnoop/internal/cache"
+	"github.com/fstaffa/czsnoop/internal/justice"
+	"github.com/fstaffa/czsnoop/internal/rzp"
+	"github.com/spf13/cobra"
+)
+
+var noCacheFlag bool
+var refreshFlag bool
+var cacheDirFlag string
+
+// openCache returns on-disk cache in --cache-dir, by default in user cache directory
+func openCache() (*cache.Store, error) {
+	dir := cacheDirFlag
+	if dir == "" {
+		var err error
+		dir, err = cache.DefaultDir()
+		if err != nil {
+			return nil, err
+		}
+	}
+	store := cache.New(dir, cacheTTLs())
+	store.Refresh = refreshFlag
+	return store, nil
+}
+
+// cacheTTLs merges default time to live of cached responses of all registry clients
+func cacheTTLs() map[string]time.Duration {
+	ttls := map[string]time.Duration{}
+	maps.Copy(ttls, rzp.CacheTTLs)
+	maps.Copy(ttls, justice.CacheTTLs)
+	maps.Copy(ttls, ares.CacheTTLs)
+	return ttls
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manages cache of registry responses",
+	Long: `Manages cache of registry responses. Responses are cached in $XDG_CACHE_HOME/czsnoop
+unless --cache-dir is given. Searches are kept for a day, subject details for a week.`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Prints number and size of cached responses",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openCache()
+		if err != nil {
+			return err
+		}
+		stats, err := store.Stats()
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "Directory:\t%s\n", stats.Dir)
+		fmt.Fprintf(tw, "Entries:\t%d (%d expired)\n", stats.Entries, stats.Expired)
+		fmt.Fprintf(tw, "Size:\t%d B\n", stats.Bytes)
+		for _, endpoint := range stats.Endpoints {
+			fmt.Fprintf(tw, "%s:\t%d (%d expired), %d B\n", endpoint.Endpoint, endpoint.Entries, endpoint.Expired, endpoint.Bytes)
+		}
+		return tw.Flush()
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Removes expired responses from cache",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openCache()
+		if err != nil {
+			return err
+		}
+		removed, err := store.Prune()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed %d expired entries\n", removed)
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Removes all cached responses",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openCache()
+		if err != nil {
+			return err
+		}
+		if err := store.Clear(); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed all entries from %s\n", store.Dir)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheStatsCmd, cachePruneCmd, cacheClearCmd)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fstaffa/czsnoop/internal/rzp/rzptest"
+)
+
+// normalizeSpace collapses whitespace so that assertions do not depend on table alignment
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func Test_cacheCmd(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := executeCommand(t, "company", "73452301", "--providers", "rzp", "--cache-dir", dir); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	offline := rzptest.Without("subjekty_ing_phd_novak.json", "isvs_F4410.xml", "listiny_F4410.xml")
+	stdout, err := executeCommandWithFixtures(t, offline, "company", "73452301", "--providers", "rzp", "--cache-dir", dir)
+	if err != nil {
+		t.Fatalf("Expected company to be answered from cache, received error %v", err)
+	}
+	if !strings.Contains(stdout, "73452301") {
+		t.Errorf("Expected cached company in output, got %s", stdout)
+	}
+	if _, err := executeCommandWithFixtures(t, offline, "company", "73452301", "--providers", "rzp", "--cache-dir", dir, "--refresh"); err == nil {
+		t.Errorf("Expected --refresh to ignore cached responses")
+	}
+
+	stdout, err = executeCommand(t, "cache", "stats", "--cache-dir", dir)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if !strings.Contains(normalizeSpace(stdout), "Entries: 2 (0 expired)") || !strings.Contains(stdout, "rzp/subjekty/isvs:") {
+		t.Errorf("Expected stats of cached search and details, got %s", stdout)
+	}
+
+	stdout, err = executeCommand(t, "cache", "prune", "--cache-dir", dir)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if stdout != "Removed 0 expired entries\n" {
+		t.Errorf("Expected nothing to be pruned, got %s", stdout)
+	}
+
+	if _, err := executeCommand(t, "cache", "clear", "--cache-dir", dir); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if _, err := executeCommandWithFixtures(t, offline, "company", "73452301", "--providers", "rzp", "--cache-dir", dir); err == nil {
+		t.Errorf("Expected cleared cache to miss")
+	}
+}
+
+func Test_noCacheFlag(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := executeCommand(t, "company", "73452301", "--providers", "rzp", "--cache-dir", dir, "--no-cache"); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	stdout, err := executeCommand(t, "cache", "stats", "--cache-dir", dir)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if !strings.Contains(normalizeSpace(stdout), "Entries: 0 (0 expired)") {
+		t.Errorf("Expected nothing to be cached with --no-cache, got %s", stdout)
+	}
+}
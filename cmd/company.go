@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fstaffa/czsnoop/internal/output"
+	"github.com/fstaffa/czsnoop/internal/search"
+	"github.com/fstaffa/czsnoop/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var companyOutputFlag string
+
+var companyCmd = &cobra.Command{
+	Use:   "company <ico>",
+	Short: "Looks up economic subject by its ICO",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := output.ParseFormat(companyOutputFlag)
+		if err != nil {
+			return err
+		}
+		ico, err := types.CreateIco(args[0])
+		if err != nil {
+			return err
+		}
+
+		providers, err := selectedProviders()
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		input := search.CompanySearchInput{Ico: ico, IncludeHistorical: includeHistoricalFlag}
+		company, statuses, searchErr := search.LookupSubject(cmd.Context(), providers, input, logger)
+		reportProviders(statuses)
+		// profile without ICO means that no provider found the subject
+		if searchErr != nil && company.Ico == "" {
+			return searchErr
+		}
+		err = output.WriteCompany(cmd.OutOrStdout(), format, company)
+		if err != nil {
+			return err
+		}
+		if searchErr != nil {
+			return fmt.Errorf("%w:\n%w", errIncompleteResults, searchErr)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(companyCmd)
+
+	companyCmd.Flags().StringVarP(&companyOutputFlag, "output", "o", string(output.Table), "Output format, one of json, ndjson, csv, table, yaml")
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fstaffa/czsnoop/internal/output"
+)
+
+func Test_companyCmd_JSON(t *testing.T) {
+	stdout, err := executeCommand(t, "company", "73452301", "--output", "json")
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	var company output.Company
+	if err := json.Unmarshal([]byte(stdout), &company); err != nil {
+		t.Fatalf("Unable to decode output %v: %s", err, stdout)
+	}
+	if company.Name != "Ing. Petr Novák, Ph.D." {
+		t.Errorf("Expected name Ing. Petr Novák, Ph.D., got %s", company.Name)
+	}
+	if company.Address != "Sokolovská 352/215, 190 00, Praha 9 - Vysočany" {
+		t.Errorf("Expected registered address, got %s", company.Address)
+	}
+	if company.RegisteringOffice != "Úřad městské části Praha 9" {
+		t.Errorf("Expected registering office, got '%s'", company.RegisteringOffice)
+	}
+	if len(company.Trades) != 2 {
+		t.Fatalf("Expected 2 valid trades, got %d", len(company.Trades))
+	}
+	if trade := company.Trades[1]; trade.Kind != "regulated" || len(trade.Establishments) != 1 || len(trade.ResponsibleRepresentatives) != 1 {
+		t.Errorf("Expected regulated trade with establishment and responsible representative, got %+v", trade)
+	}
+	if suspensions := company.Trades[1].Suspensions; len(suspensions) != 1 || suspensions[0].To != "" {
+		t.Errorf("Expected only ongoing suspension, got %v", suspensions)
+	}
+	if len(company.Persons) != 2 || company.Persons[0].BirthDate != "1983-09-27" {
+		t.Errorf("Expected entrepreneur born 1983-09-27, got %v", company.Persons)
+	}
+	if company.Persons[1].Role != "responsible representative" || company.Persons[1].From != "2015-07-01" {
+		t.Errorf("Expected responsible representative since 2015-07-01, got %+v", company.Persons[1])
+	}
+}
+
+func Test_companyCmd_InvalidIco(t *testing.T) {
+	_, err := executeCommand(t, "company", "1234")
+	if err == nil {
+		t.Fatalf("Expected error for invalid ICO, got nil")
+	}
+}
+
+func Test_companyCmd_LegalEntity(t *testing.T) {
+	stdout, err := executeCommand(t, "company", "01895541", "--output", "json")
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	var company output.Company
+	if err := json.Unmarshal([]byte(stdout), &company); err != nil {
+		t.Fatalf("Unable to decode output %v: %s", err, stdout)
+	}
+	if company.Name != "THOMAS SILVERTONNI s.r.o." {
+		t.Errorf("Expected name THOMAS SILVERTONNI s.r.o., got %s", company.Name)
+	}
+	if len(company.Trades) != 1 {
+		t.Errorf("Expected 1 trade, got %d", len(company.Trades))
+	}
+	if company.LegalForm != "Společnost s ručením omezeným" {
+		t.Errorf("Expected legal form Společnost s ručením omezeným, got %s", company.LegalForm)
+	}
+	if company.FileNumber != "C 226710 vedená u Městského soudu v Praze" || company.RegisteredCapital != "200 000 Kč" {
+		t.Errorf("Expected file number and registered capital from the commercial register, got %+v", company)
+	}
+	if len(company.Persons) != 2 {
+		t.Fatalf("Expected current statutory body member and shareholder, got %v", company.Persons)
+	}
+	if person := company.Persons[0]; person.Role != "statutory body member" || person.Function != "jednatel" || person.LastName != "Silvertonni" {
+		t.Errorf("Expected Silvertonni as jednatel, got %+v", person)
+	}
+	if person := company.Persons[1]; person.Role != "shareholder" || person.Share == "" || person.LastName != "Silvertonni" {
+		t.Errorf("Expected Silvertonni as shareholder, got %+v", person)
+	}
+	if len(company.History) != 0 {
+		t.Errorf("Expected no history without --include-historical, got %v", company.History)
+	}
+}
+
+func Test_companyCmd_OnlyInCommercialRegister(t *testing.T) {
+	stdout, err := executeCommand(t, "company", "24681351", "--output", "json")
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	var company output.Company
+	if err := json.Unmarshal([]byte(stdout), &company); err != nil {
+		t.Fatalf("Unable to decode output %v: %s", err, stdout)
+	}
+	if company.Name != "NOVÁK STAVBY s.r.o." || len(company.Trades) != 0 {
+		t.Errorf("Expected NOVÁK STAVBY s.r.o. without trades, got %+v", company)
+	}
+	if len(company.Persons) != 2 || company.Persons[0].LastName != "Dvořák" || company.Persons[1].Role != "shareholder" {
+		t.Errorf("Expected Dvořák as jednatel and shareholder, got %v", company.Persons)
+	}
+}
+
+func Test_companyCmd_NotFound(t *testing.T) {
+	_, err := executeCommand(t, "company", "27074358")
+	if exitCode(err) != exitNotFound {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func Test_companyCmd_IncludeHistorical(t *testing.T) {
+	tests := map[string]struct {
+		ico     string
+		trades  int
+		persons int
+		history int
+	}{
+		"terminated trade":                  {ico: "73452301", trades: 3, persons: 2},
+		"former responsible representative": {ico: "01895541", trades: 1, persons: 3, history: 1},
+		"former statutory body member":      {ico: "24681351", trades: 0, persons: 4},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout, err := executeCommand(t, "company", test.ico, "--include-historical", "--output", "json")
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			var company output.Company
+			if err := json.Unmarshal([]byte(stdout), &company); err != nil {
+				t.Fatalf("Unable to decode output %v: %s", err, stdout)
+			}
+			if len(company.Trades) != test.trades {
+				t.Errorf("Expected %d trades, got %d", test.trades, len(company.Trades))
+			}
+			if len(company.Persons) != test.persons {
+				t.Errorf("Expected %d persons, got %v", test.persons, company.Persons)
+			}
+			if len(company.History) != test.history {
+				t.Errorf("Expected %d history records, got %v", test.history, company.History)
+			}
+		})
+	}
+}
+
+func Test_companyCmd_Providers(t *testing.T) {
+	tests := map[string]struct {
+		providers      string
+		expectedTrades int
+		expectedVatId  string
+	}{
+		"all":       {providers: "rzp,justice,ares", expectedTrades: 1, expectedVatId: "CZ01895541"},
+		"only ares": {providers: "ares", expectedTrades: 0, expectedVatId: "CZ01895541"},
+		"only rzp":  {providers: "rzp", expectedTrades: 1},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout, err := executeCommand(t, "company", "01895541", "--providers", test.providers, "--output", "json")
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			var company output.Company
+			if err := json.Unmarshal([]byte(stdout), &company); err != nil {
+				t.Fatalf("Unable to decode output %v: %s", err, stdout)
+			}
+			if company.Name != "THOMAS SILVERTONNI s.r.o." || len(company.Trades) != test.expectedTrades || company.VatId != test.expectedVatId {
+				t.Errorf("Expected %d trades and VAT id %q, got %+v", test.expectedTrades, test.expectedVatId, company)
+			}
+		})
+	}
+}
+
+func Test_companyCmd_UnknownProvider(t *testing.T) {
+	_, err := executeCommand(t, "company", "01895541", "--providers", "rzp,isir")
+	if err == nil || !strings.Contains(err.Error(), `unknown provider "isir"`) {
+		t.Errorf("Expected unknown provider error, got %v", err)
+	}
+}
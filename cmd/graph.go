@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fstaffa/czsnoop/internal/names"
+	"github.com/fstaffa/czsnoop/internal/output"
+	"github.com/fstaffa/czsnoop/internal/search"
+	"github.com/fstaffa/czsnoop/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphOutputFlag      string
+	graphDepthFlag       int
+	graphMaxRequestsFlag int
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph <seed>",
+	Short: "Builds graph of relations between persons and companies",
+	Long: `Builds graph of relations between persons and companies starting from the seed and following
+roles of persons in subjects up to the given depth. Seed is either ICO of a subject or a name
+of persons, e.g. "Jan Novák".
+
+Each subject lookup and person search counts against the request budget, subjects and persons
+left unexpanded when the budget runs out are reported in the output and the command exits with
+code 4. Persons without birth date are not expanded, their namesakes could not be told apart.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := output.ParseFormat(graphOutputFlag)
+		if err != nil {
+			return err
+		}
+		if graphDepthFlag < 0 {
+			return fmt.Errorf("depth must not be negative, got %d", graphDepthFlag)
+		}
+		if graphMaxRequestsFlag < 1 {
+			return fmt.Errorf("max requests must be positive, got %d", graphMaxRequestsFlag)
+		}
+		input := search.GraphInput{
+			Depth:             graphDepthFlag,
+			MaxRequests:       graphMaxRequestsFlag,
+			IncludeHistorical: includeHistoricalFlag,
+		}
+		if isDigits(args[0]) {
+			input.Ico, err = types.CreateIco(args[0])
+			if err != nil {
+				return err
+			}
+		} else {
+			if names.Parse(args[0]).Surname == "" {
+				return fmt.Errorf("seed must be ICO or name of persons, got %q", args[0])
+			}
+			input.Query = args[0]
+		}
+
+		providers, err := selectedProviders()
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		graph, searchErr := search.Traverse(cmd.Context(), providers, input, logger)
+		if len(graph.Nodes) == 0 {
+			if searchErr == nil {
+				return fmt.Errorf("seed %s: %w", args[0], search.ErrNotFound)
+			}
+			return searchErr
+		}
+		err = output.WriteGraph(cmd.OutOrStdout(), format, graph)
+		if err != nil {
+			return err
+		}
+		if errors.Is(searchErr, search.ErrRequestBudgetExhausted) {
+			// the graph is complete up to the budget, which has its own exit code
+			return searchErr
+		}
+		if searchErr != nil {
+			return fmt.Errorf("%w:\n%w", errIncompleteResults, searchErr)
+		}
+		return nil
+	},
+}
+
+// isDigits checks whether the seed is an ICO rather than a name
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	graphCmd.Flags().IntVar(&graphDepthFlag, "depth", 1, "Number of relations followed from the seed, 0 shows only the seed")
+	graphCmd.Flags().IntVar(&graphMaxRequestsFlag, "max-requests", search.DefaultGraphMaxRequests, "Maximum number of subject lookups and person searches")
+	graphCmd.Flags().StringVarP(&graphOutputFlag, "output", "o", string(output.Table), "Output format, one of json, ndjson, csv, table, yaml")
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fstaffa/czsnoop/internal/output"
+)
+
+func Test_graphCmd_JSON(t *testing.T) {
+	stdout, err := executeCommand(t, "graph", "45678910", "--depth", "2", "--output", "json")
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	var graph output.Graph
+	if err := json.Unmarshal([]byte(stdout), &graph); err != nil {
+		t.Fatalf("Unable to decode output %v: %s", err, stdout)
+	}
+	if len(graph.Nodes) == 0 || graph.Nodes[0].Id != "subject:45678910" || graph.Nodes[0].Depth != 0 {
+		t.Fatalf("Expected seed subject first, got %+v", graph.Nodes)
+	}
+	if len(graph.Nodes) != 4 || graph.Nodes[3].Name != "Thomas Silvertonni" || graph.Nodes[3].Depth != 2 {
+		t.Errorf("Expected Thomas Silvertonni reached through his company, got %+v", graph.Nodes)
+	}
+	if len(graph.Edges) != 5 || graph.Edges[0].Role != "statutory body member" || graph.Edges[0].From != "2015-01-01" {
+		t.Errorf("Expected 5 role-labelled edges, got %+v", graph.Edges)
+	}
+	if graph.Truncated || graph.Requests != 3 {
+		t.Errorf("Expected complete graph after 3 requests, got %d requests, truncated %v", graph.Requests, graph.Truncated)
+	}
+}
+
+func Test_graphCmd_MaxRequests(t *testing.T) {
+	stdout, err := executeCommand(t, "graph", "45678910", "--depth", "2", "--max-requests", "1", "--output", "json")
+	if exitCode(err) != exitTooManyMatches {
+		t.Fatalf("Expected request budget exhausted error, got %v", err)
+	}
+
+	var graph output.Graph
+	if err := json.Unmarshal([]byte(stdout), &graph); err != nil {
+		t.Fatalf("Unable to decode output %v: %s", err, stdout)
+	}
+	if !graph.Truncated || graph.Requests != 1 {
+		t.Errorf("Expected graph truncated after 1 request, got %d requests, truncated %v", graph.Requests, graph.Truncated)
+	}
+}
+
+func Test_graphCmd_Table(t *testing.T) {
+	stdout, err := executeCommand(t, "graph", "45678910", "--depth", "2", "--max-requests", "1")
+	if exitCode(err) != exitTooManyMatches {
+		t.Fatalf("Expected request budget exhausted error, got %v", err)
+	}
+	if !strings.Contains(stdout, "Request budget exhausted after 1 requests") {
+		t.Errorf("Expected note about exhausted budget, got %s", stdout)
+	}
+}
+
+func Test_graphCmd_InvalidSeed(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+	}{
+		"invalid ico":    {args: []string{"graph", "12345678"}},
+		"only titles":    {args: []string{"graph", "Ing."}},
+		"negative depth": {args: []string{"graph", "45678910", "--depth", "-1"}},
+		"no request":     {args: []string{"graph", "45678910", "--max-requests", "0"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := executeCommand(t, test.args...)
+			if err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func Test_graphCmd_NotFound(t *testing.T) {
+	_, err := executeCommand(t, "graph", "27074358")
+	if exitCode(err) != exitNotFound {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
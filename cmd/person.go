@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/fstaffa/czsnoop/internal/output"
+	"github.com/fstaffa/czsnoop/internal/rzp"
 	"github.com/fstaffa/czsnoop/internal/search"
+	"github.com/fstaffa/czsnoop/internal/types"
 	"github.com/spf13/cobra"
 )
 
@@ -16,27 +21,49 @@ const bornAfterFlagName = "born-after"
 var bornBeforeFlag string
 var minAge int
 var maxAge int
+var maxRequests int
+var outputFlag string
+var birthNumberFlag string
+var failFastFlag bool
+var bestEffortFlag bool
+var roleFlag string
+var fuzzyFlag bool
+var firstNameFlag string
+var surnameFlag string
 
 var personCmd = &cobra.Command{
-	Use:   "person",
+	Use:   "person [name]",
 	Short: "Searches for person using all providers",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Searches for person using all providers.
+
+The name may contain academic titles, which the found persons must have, e.g. "Ing. Jan Novák, Ph.D.".
+The surname is the last word unless the name is written as "Novák, Jan" or starts with female surname,
+e.g. "Nováková Jana". Use --first-name and --surname instead of the name when it is ambiguous.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateName(args); err != nil {
+			return err
+		}
+		format, err := output.ParseFormat(outputFlag)
+		if err != nil {
+			return err
+		}
+		role, err := rzp.ParseSubjectRole(roleFlag)
+		if err != nil {
+			return err
+		}
 		var bornAfter time.Time
 		var bornBefore time.Time
-		var err error
 		if cmd.Flags().Changed(bornAfterFlagName) {
 			bornAfter, err = time.Parse("2006-01-02", bornAfterFlag)
 			if err != nil {
-				logger.Error("Unable to parse born-after flag", "error", err)
-				return
+				return fmt.Errorf("unable to parse born-after flag: %w", err)
 			}
 		}
 		if cmd.Flags().Changed(bornBeforeFlagName) {
 			bornBefore, err = time.Parse("2006-01-02", bornBeforeFlag)
 			if err != nil {
-				logger.Error("Unable to parse born-before flag", "error", err)
-				return
+				return fmt.Errorf("unable to parse born-before flag: %w", err)
 			}
 		}
 		if cmd.Flags().Changed("min-age") {
@@ -45,14 +72,52 @@ var personCmd = &cobra.Command{
 		if cmd.Flags().Changed("max-age") {
 			bornAfter = maxAgeToBornAfter(maxAge, time.Now())
 		}
+		if cmd.Flags().Changed("birth-number") {
+			birthNumber, err := types.CreateBirthNumber(birthNumberFlag)
+			if err != nil {
+				return fmt.Errorf("unable to parse birth-number flag: %w", err)
+			}
+			bornAfter = birthNumber.BirthDate()
+			bornBefore = birthNumber.BirthDate()
+		}
 
 		searchInput := search.PersonSearchInput{
-			BornAfter:  bornAfter,
-			BornBefore: bornBefore,
-			Query:      args[0],
+			BornAfter:         bornAfter,
+			BornBefore:        bornBefore,
+			Query:             strings.Join(args, " "),
+			FirstName:         firstNameFlag,
+			Surname:           surnameFlag,
+			MaxRequests:       maxRequests,
+			FailFast:          failFastFlag,
+			Concurrency:       concurrencyFlag,
+			IncludeHistorical: includeHistoricalFlag,
+			Role:              role,
+			Fuzzy:             fuzzyFlag,
+		}
+
+		providers, err := selectedProviders()
+		if err != nil {
+			return err
 		}
 
-		fmt.Print(search.Rzp(searchInput, logger))
+		cmd.SilenceUsage = true
+		persons, statuses, searchErr := search.SearchPersons(cmd.Context(), providers, searchInput, logger)
+		reportProviders(statuses)
+		if searchErr != nil && persons == nil {
+			return searchErr
+		}
+		err = output.WritePersons(cmd.OutOrStdout(), format, persons)
+		if err != nil {
+			return err
+		}
+		if errors.Is(searchErr, search.ErrRequestBudgetExhausted) {
+			// persons found within the budget were printed, the budget has its own exit code
+			return searchErr
+		}
+		if searchErr != nil {
+			return fmt.Errorf("%w:\n%w", errIncompleteResults, searchErr)
+		}
+		return nil
 	},
 }
 
@@ -63,8 +128,32 @@ func init() {
 	personCmd.Flags().StringVar(&bornBeforeFlag, bornBeforeFlagName, "", "Search for people born on given date or earlier")
 	personCmd.Flags().IntVar(&minAge, "min-age", 0, "Search for people at least given age")
 	personCmd.Flags().IntVar(&maxAge, "max-age", 120, "Search for people at most given age")
+	personCmd.Flags().IntVar(&maxRequests, "max-requests", search.DefaultMaxRequests, "Maximum number of requests used to split searches with too many matches")
+	personCmd.Flags().StringVarP(&outputFlag, "output", "o", string(output.Table), "Output format, one of json, ndjson, csv, table, yaml")
+	personCmd.Flags().StringVar(&birthNumberFlag, "birth-number", "", "Search for people born on date encoded in given birth number (rodne cislo)")
+	personCmd.Flags().BoolVar(&failFastFlag, "fail-fast", false, "Stop on the first error without printing any results")
+	personCmd.Flags().BoolVar(&bestEffortFlag, "best-effort", false, "Print results found even if searching some of them failed, the default opposite of --fail-fast")
+	personCmd.Flags().StringVar(&roleFlag, "role", string(rzp.SubjectRoleAny), "Search only subjects where the person has given role, one of entrepreneur, statutory, any")
+	personCmd.Flags().BoolVar(&fuzzyFlag, "fuzzy", false, "Search also female or male forms of the surname and forms without diacritics, accept similar names, e.g. with typos, and rank persons by match score")
+	personCmd.Flags().StringVar(&firstNameFlag, "first-name", "", "First names of the person, requires --surname")
+	personCmd.Flags().StringVar(&surnameFlag, "surname", "", "Surname of the person, used instead of the name argument")
+	personCmd.MarkFlagsMutuallyExclusive("fail-fast", "best-effort")
 	personCmd.MarkFlagsMutuallyExclusive("min-age", bornBeforeFlagName)
 	personCmd.MarkFlagsMutuallyExclusive("max-age", bornAfterFlagName)
+	personCmd.MarkFlagsMutuallyExclusive("birth-number", bornAfterFlagName, bornBeforeFlagName, "min-age", "max-age")
+}
+
+// validateName checks that the person is given either by the name argument or by --surname
+func validateName(args []string) error {
+	switch {
+	case len(args) > 0 && (firstNameFlag != "" || surnameFlag != ""):
+		return fmt.Errorf("name argument can not be combined with --first-name or --surname")
+	case firstNameFlag != "" && surnameFlag == "":
+		return fmt.Errorf("--first-name requires --surname")
+	case len(args) == 0 && surnameFlag == "":
+		return fmt.Errorf("name argument or --surname is required")
+	}
+	return nil
 }
 
 func minAgeToBornBefore(minAge int, today time.Time) time.Time {
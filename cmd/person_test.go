@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fstaffa/czsnoop/internal/output"
+	"github.com/fstaffa/czsnoop/internal/rzp/rzptest"
+)
+
+func Test_personCmd_JSON(t *testing.T) {
+	stdout, err := executeCommand(t, "person", "Jan Novák", "--output", "json", "--born-before", "1979-12-31")
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	var persons []output.Person
+	if err := json.Unmarshal([]byte(stdout), &persons); err != nil {
+		t.Fatalf("Unable to decode output %v: %s", err, stdout)
+	}
+	if len(persons) != 1 {
+		t.Fatalf("Expected 1 person born before 1980, got %d", len(persons))
+	}
+	if persons[0].BirthDate != "1975-03-14" {
+		t.Errorf("Expected birth date 1975-03-14, got %s", persons[0].BirthDate)
+	}
+}
+
+func Test_personCmd_Role(t *testing.T) {
+	tests := map[string]struct {
+		role         string
+		expectedName string
+		expectedIco  string
+	}{
+		"entrepreneur":   {role: "entrepreneur", expectedName: "Jan Novák", expectedIco: "87654326"},
+		"statutory body": {role: "statutory", expectedName: "Ing. Jan Novák", expectedIco: "45678910"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout, err := executeCommand(t, "person", "Jan Novák", "--output", "json", "--role", test.role)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+
+			var persons []output.Person
+			if err := json.Unmarshal([]byte(stdout), &persons); err != nil {
+				t.Fatalf("Unable to decode output %v: %s", err, stdout)
+			}
+			if len(persons) != 1 || persons[0].FullName != test.expectedName {
+				t.Fatalf("Expected only %s, got %v", test.expectedName, persons)
+			}
+			if subjects := persons[0].Subjects; len(subjects) != 1 || subjects[0].Ico != test.expectedIco {
+				t.Errorf("Expected single subject %s, got %v", test.expectedIco, subjects)
+			}
+		})
+	}
+}
+
+func Test_personCmd_InvalidRole(t *testing.T) {
+	_, err := executeCommand(t, "person", "Jan Novák", "--role", "director")
+	if err == nil {
+		t.Fatalf("Expected error for unknown role, got nil")
+	}
+}
+
+func Test_personCmd_BirthNumber(t *testing.T) {
+	stdout, err := executeCommand(t, "person", "Jan Novák", "--output", "json", "--birth-number", "750314/1239")
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	var persons []output.Person
+	if err := json.Unmarshal([]byte(stdout), &persons); err != nil {
+		t.Fatalf("Unable to decode output %v: %s", err, stdout)
+	}
+	if len(persons) != 1 || persons[0].BirthDate != "1975-03-14" {
+		t.Fatalf("Expected single person born 1975-03-14, got %v", persons)
+	}
+}
+
+func Test_personCmd_InvalidBirthNumber(t *testing.T) {
+	_, err := executeCommand(t, "person", "Jan Novák", "--birth-number", "7503141230")
+	if err == nil {
+		t.Fatalf("Expected error for invalid birth number, got nil")
+	}
+}
+
+func Test_personCmd_BestEffort(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+	}{
+		"default":  {args: []string{"person", "Jan Novák", "--output", "ndjson"}},
+		"explicit": {args: []string{"person", "Jan Novák", "--output", "ndjson", "--best-effort"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout, err := executeCommandWithFixtures(t, rzptest.Without("listiny_F7701.xml"), test.args...)
+			if exitCode(err) != exitIncompleteResult {
+				t.Fatalf("Expected incomplete results error, got %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(stdout), "\n")
+			if len(lines) != 2 {
+				t.Errorf("Expected partial results for both persons, got %s", stdout)
+			}
+		})
+	}
+}
+
+func Test_personCmd_BestEffortWithFailFast(t *testing.T) {
+	_, err := executeCommand(t, "person", "Jan Novák", "--best-effort", "--fail-fast")
+	if err == nil {
+		t.Fatalf("Expected error for conflicting modes, got nil")
+	}
+}
+
+func Test_personCmd_FailFast(t *testing.T) {
+	stdout, err := executeCommandWithFixtures(t, rzptest.Without("listiny_F7701.xml"), "person", "Jan Novák", "--fail-fast")
+	if err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+	if stdout != "" {
+		t.Errorf("Expected no output, got %s", stdout)
+	}
+}
+
+func Test_personCmd_MaxRequests(t *testing.T) {
+	stdout, err := executeCommand(t, "person", "novak", "--providers", "rzp", "--max-requests", "1", "--output", "ndjson")
+	if exitCode(err) != exitTooManyMatches {
+		t.Fatalf("Expected request budget exhausted error, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 {
+		t.Errorf("Expected persons found within the budget, got %s", stdout)
+	}
+}
+
+func Test_personCmd_Providers(t *testing.T) {
+	stdout, err := executeCommand(t, "person", "Jan Novák", "--providers", "justice", "--output", "json")
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	var persons []output.Person
+	if err := json.Unmarshal([]byte(stdout), &persons); err != nil {
+		t.Fatalf("Unable to decode output %v: %s", err, stdout)
+	}
+	if len(persons) != 1 || len(persons[0].Subjects) != 2 || persons[0].Subjects[1].Role != "shareholder" {
+		t.Errorf("Expected only board member and shareholder from the commercial register, got %v", persons)
+	}
+
+	stdout, err = executeCommand(t, "person", "Petr Novák", "--providers", "ares", "--output", "json")
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	persons = nil
+	if err := json.Unmarshal([]byte(stdout), &persons); err != nil {
+		t.Fatalf("Unable to decode output %v: %s", err, stdout)
+	}
+	if len(persons) != 1 || len(persons[0].Subjects) != 1 || persons[0].Subjects[0].LegalForm == "" || persons[0].Subjects[0].VatId == "" {
+		t.Errorf("Expected sole trader with legal form and VAT id from ARES, got %+v", persons)
+	}
+}
+
+func Test_personCmd_Fuzzy(t *testing.T) {
+	stdout, err := executeCommand(t, "person", "Jan Novák", "--fuzzy", "--output", "json")
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	var persons []output.Person
+	if err := json.Unmarshal([]byte(stdout), &persons); err != nil {
+		t.Fatalf("Unable to decode output %v: %s", err, stdout)
+	}
+	if len(persons) == 0 {
+		t.Fatalf("Expected persons, got none")
+	}
+	for i, person := range persons {
+		if person.Score == nil || (i > 0 && *person.Score > *persons[i-1].Score) {
+			t.Errorf("Expected persons ranked by score, got %v", persons)
+		}
+	}
+}
+
+func Test_personCmd_Name(t *testing.T) {
+	tests := map[string]struct {
+		args          []string
+		expectedNames []string
+	}{
+		"name":           {args: []string{"Jan Novák"}, expectedNames: []string{"Ing. Jan Novák", "Jan Novák"}},
+		"title":          {args: []string{"Ing. Jan Novák"}, expectedNames: []string{"Ing. Jan Novák"}},
+		"surname first":  {args: []string{"Novák, Jan"}, expectedNames: []string{"Ing. Jan Novák", "Jan Novák"}},
+		"explicit parts": {args: []string{"--first-name", "Jan", "--surname", "Novák"}, expectedNames: []string{"Ing. Jan Novák", "Jan Novák"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout, err := executeCommand(t, append([]string{"person", "--output", "json"}, test.args...)...)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			var persons []output.Person
+			if err := json.Unmarshal([]byte(stdout), &persons); err != nil {
+				t.Fatalf("Unable to decode output %v: %s", err, stdout)
+			}
+			var names []string
+			for _, person := range persons {
+				names = append(names, person.FullName)
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, test.expectedNames) {
+				t.Errorf("Expected persons %v, got %v", test.expectedNames, names)
+			}
+		})
+	}
+}
+
+func Test_personCmd_InvalidName(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+	}{
+		"no name":                {},
+		"first name only":        {args: []string{"--first-name", "Jan"}},
+		"name and surname":       {args: []string{"Jan Novák", "--surname", "Novák"}},
+		"name and first name":    {args: []string{"Novák", "--first-name", "Jan"}},
+		"more than one argument": {args: []string{"Jan", "Novák"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := executeCommand(t, append([]string{"person"}, test.args...)...)
+			if err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
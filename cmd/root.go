@@ -2,28 +2,112 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/fstaffa/czsnoop/internal/ares"
+	"github.com/fstaffa/czsnoop/internal/cassette"
+	"github.com/fstaffa/czsnoop/internal/justice"
+	"github.com/fstaffa/czsnoop/internal/registry"
+	"github.com/fstaffa/czsnoop/internal/rzp"
+	"github.com/fstaffa/czsnoop/internal/search"
 	"github.com/spf13/cobra"
 )
 
 var verboseFlag bool
+var recordFlag string
+var replayFlag string
+var concurrencyFlag int
+var rpsFlag float64
+var includeHistoricalFlag bool
+var providersFlag string
 var logger *slog.Logger
 
+// rzpOptions are passed to every RZP client created by commands, they are built from flags on every run
+var rzpOptions []rzp.Option
+
+// justiceOptions are passed to every commercial register client created by commands, they are built from flags on every run
+var justiceOptions []justice.Option
+
+// aresOptions are passed to every ARES client created by commands, they are built from flags on every run
+var aresOptions []ares.Option
+
+// rzpBaseOptions, justiceBaseOptions and aresBaseOptions precede options built from flags,
+// tests use them to point clients to fake registries
+var (
+	rzpBaseOptions     []rzp.Option
+	justiceBaseOptions []justice.Option
+	aresBaseOptions    []ares.Option
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "czsnoop",
 	Short: "Search OSINT data specific for the Czech Republic",
 	Long: `Search OSINT data specific for the Czech Republic. Uses:
 https://www.rzp.cz
-https://www.justice.cz`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+https://or.justice.cz
+https://ares.gov.cz
+
+Exit codes:
+  0  success
+  1  unspecified error
+  3  subject was not found
+  4  too many possible matches or request budget exhausted, query needs more details
+  5  rate limited by the registry
+  6  registry session expired
+  7  registry response has unexpected structure
+  8  results were printed but some of them are incomplete`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 
 		level := slog.LevelInfo
 		if verboseFlag {
 			level = slog.LevelDebug
 		}
 		logger = slog.New(slog.NewTextHandler(cmd.ErrOrStderr(), &slog.HandlerOptions{Level: level}))
+
+		if concurrencyFlag < 1 {
+			return fmt.Errorf("concurrency must be at least 1")
+		}
+		if rpsFlag < 0 {
+			return fmt.Errorf("rps must not be negative")
+		}
+		rzpOptions = append(slices.Clip(rzpBaseOptions), rzp.WithConcurrency(concurrencyFlag), rzp.WithRateLimit(rpsFlag))
+		justiceOptions = append(slices.Clip(justiceBaseOptions), justice.WithConcurrency(concurrencyFlag), justice.WithRateLimit(rpsFlag))
+		aresOptions = append(slices.Clip(aresBaseOptions), ares.WithConcurrency(concurrencyFlag), ares.WithRateLimit(rpsFlag))
+		if recordFlag != "" {
+			recorder, err := cassette.NewRecorder(recordFlag, rzp.DefaultTransport())
+			if err != nil {
+				return fmt.Errorf("unable to start recording: %w", err)
+			}
+			rzpOptions = append(rzpOptions, rzp.WithTransport(recorder))
+			justiceOptions = append(justiceOptions, justice.WithTransport(recorder))
+			aresOptions = append(aresOptions, ares.WithTransport(recorder))
+		}
+		if replayFlag != "" {
+			replayer, err := cassette.NewReplayer(replayFlag)
+			if err != nil {
+				return fmt.Errorf("unable to start replay: %w", err)
+			}
+			rzpOptions = append(rzpOptions, rzp.WithTransport(replayer))
+			justiceOptions = append(justiceOptions, justice.WithTransport(replayer))
+			aresOptions = append(aresOptions, ares.WithTransport(replayer))
+		}
+		// recording and replaying need the actual traffic, cached responses would hide it
+		if !noCacheFlag && recordFlag == "" && replayFlag == "" {
+			store, err := openCache()
+			if err != nil {
+				return err
+			}
+			rzpOptions = append(rzpOptions, rzp.WithCache(store))
+			justiceOptions = append(justiceOptions, justice.WithCache(store))
+			aresOptions = append(aresOptions, ares.WithCache(store))
+		}
+		return nil
 	},
 }
 
@@ -32,10 +116,79 @@ https://www.justice.cz`,
 func Execute() {
 	err := rootCmd.ExecuteContext(context.Background())
 	if err != nil {
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+}
+
+const (
+	exitError            = 1
+	exitNotFound         = 3
+	exitTooManyMatches   = 4
+	exitRateLimited      = 5
+	exitSessionExpired   = 6
+	exitSchemaChanged    = 7
+	exitIncompleteResult = 8
+)
+
+// errIncompleteResults marks errors returned after partial results were already printed
+var errIncompleteResults = errors.New("some results are incomplete")
+
+func exitCode(err error) int {
+	var schemaErr *registry.SchemaError
+	switch {
+	case errors.Is(err, errIncompleteResults):
+		return exitIncompleteResult
+	case errors.Is(err, rzp.ErrTooManyMatches), errors.Is(err, search.ErrRequestBudgetExhausted), errors.Is(err, ares.ErrTooManyMatches), errors.Is(err, justice.ErrTooManyMatches):
+		return exitTooManyMatches
+	case errors.Is(err, search.ErrNotFound), errors.Is(err, registry.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, registry.ErrRateLimited):
+		return exitRateLimited
+	case errors.Is(err, rzp.ErrSessionExpired):
+		return exitSessionExpired
+	case errors.As(err, &schemaErr):
+		return exitSchemaChanged
+	}
+	return exitError
+}
+
+// selectedProviders returns providers chosen by --providers, configured with options of their clients
+func selectedProviders() ([]search.Provider, error) {
+	var names []string
+	for _, name := range strings.Split(providersFlag, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return search.NewRegistryWith(rzpOptions, justiceOptions, aresOptions).Select(names)
+}
+
+// reportProviders logs how each provider did, so that missing results can be told apart from failed providers
+func reportProviders(statuses []search.ProviderStatus) {
+	for _, status := range statuses {
+		attrs := []any{slog.String("provider", status.Provider), slog.String("status", status.State())}
+		if !status.Skipped {
+			attrs = append(attrs, slog.Int("results", status.Results), slog.Duration("duration", status.Duration.Round(time.Millisecond)))
+		}
+		if status.State() == "failed" {
+			logger.Warn("Provider failed", append(attrs, slog.Any("error", status.Err))...)
+			continue
+		}
+		logger.Info("Provider finished", attrs...)
 	}
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&verboseFlag, "debug", false, "Enable verbose mode")
+	rootCmd.PersistentFlags().StringVar(&recordFlag, "record", "", "Record all registry traffic to given directory")
+	rootCmd.PersistentFlags().StringVar(&replayFlag, "replay", "", "Replay registry traffic recorded in given directory instead of contacting registries")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	rootCmd.PersistentFlags().IntVar(&concurrencyFlag, "concurrency", 4, "Maximum number of concurrent requests to each registry")
+	rootCmd.PersistentFlags().Float64Var(&rpsFlag, "rps", 5, "Maximum number of requests per second to each registry, 0 for unlimited")
+	rootCmd.PersistentFlags().BoolVar(&noCacheFlag, "no-cache", false, "Do not read nor store cached registry responses")
+	rootCmd.PersistentFlags().BoolVar(&refreshFlag, "refresh", false, "Ignore cached registry responses but store the new ones")
+	rootCmd.PersistentFlags().StringVar(&cacheDirFlag, "cache-dir", "", "Directory of cached registry responses, defaults to $XDG_CACHE_HOME/czsnoop")
+	rootCmd.MarkFlagsMutuallyExclusive("no-cache", "refresh")
+	rootCmd.PersistentFlags().StringVar(&providersFlag, "providers", strings.Join(search.NewRegistryWith(nil, nil, nil).Names(), ","), "Comma separated providers to search, empty for all")
+	rootCmd.PersistentFlags().BoolVar(&includeHistoricalFlag, "include-historical", false, "Include expired records like terminated trades and former roles, only currently valid records are shown by default")
 }
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/fstaffa/czsnoop/internal/ares"
+	"github.com/fstaffa/czsnoop/internal/ares/arestest"
+	"github.com/fstaffa/czsnoop/internal/justice"
+	"github.com/fstaffa/czsnoop/internal/justice/justicetest"
+	"github.com/fstaffa/czsnoop/internal/rzp"
+	"github.com/fstaffa/czsnoop/internal/rzp/rzptest"
+	"github.com/fstaffa/czsnoop/internal/search"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// executeCommand runs root command with given arguments against fake RZP and returns its standard output
+func executeCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	return executeCommandWithFixtures(t, rzptest.Fixtures, args...)
+}
+
+func executeCommandWithFixtures(t *testing.T, fixtures []rzptest.Fixture, args ...string) (string, error) {
+	t.Helper()
+	server := rzptest.NewServerWithFixtures(t, fixtures)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	rzpBaseOptions = []rzp.Option{rzp.WithBaseUrl(server.URL)}
+	justiceBaseOptions = []justice.Option{justice.WithBaseUrl(justicetest.NewServer(t).URL)}
+	aresBaseOptions = []ares.Option{ares.WithBaseUrl(arestest.NewServer(t).URL)}
+	resetFlags(rootCmd)
+
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&bytes.Buffer{})
+	rootCmd.SetArgs(args)
+	t.Cleanup(func() {
+		rzpBaseOptions = nil
+		justiceBaseOptions = nil
+		aresBaseOptions = nil
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+	})
+	err := rootCmd.Execute()
+	return stdout.String(), err
+}
+
+// resetFlags restores default values of flags, flag variables are global and kept between executions
+func resetFlags(cmd *cobra.Command) {
+	reset := func(flag *pflag.Flag) {
+		_ = flag.Value.Set(flag.DefValue)
+		flag.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, child := range cmd.Commands() {
+		resetFlags(child)
+	}
+}
+
+func Test_exitCode(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		err      error
+		expected int
+	}{
+		"generic":            {err: errors.New("boom"), expected: exitError},
+		"not found":          {err: fmt.Errorf("lookup: %w", &rzp.HTTPStatusError{StatusCode: http.StatusNotFound}), expected: exitNotFound},
+		"too many matches":   {err: fmt.Errorf("search: %w", rzp.ErrTooManyMatches), expected: exitTooManyMatches},
+		"too many pages":     {err: fmt.Errorf("search: %w", justice.ErrTooManyMatches), expected: exitTooManyMatches},
+		"budget exhausted":   {err: search.ErrRequestBudgetExhausted, expected: exitTooManyMatches},
+		"rate limited":       {err: &rzp.HTTPStatusError{StatusCode: http.StatusTooManyRequests}, expected: exitRateLimited},
+		"session expired":    {err: &rzp.HTTPStatusError{StatusCode: http.StatusUnauthorized, Err: rzp.ErrSessionExpired}, expected: exitSessionExpired},
+		"page changed":       {err: &justice.SchemaError{Path: "vypis"}, expected: exitSchemaChanged},
+		"schema changed":     {err: fmt.Errorf("details: %w", &rzp.SchemaError{Path: "listiny"}), expected: exitSchemaChanged},
+		"incomplete results": {err: fmt.Errorf("%w: %w", errIncompleteResults, rzp.ErrNotFound), expected: exitIncompleteResult},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := exitCode(test.err)
+			if actual != test.expected {
+				t.Errorf("Expected exit code %d, got %d", test.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_rootCmd_OptionsBuiltOnEveryRun(t *testing.T) {
+	var counts []int
+	for range 2 {
+		if _, err := executeCommand(t, "company", "01895541", "--output", "json"); err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+		counts = append(counts, len(rzpOptions), len(justiceOptions), len(aresOptions))
+	}
+	if !slices.Equal(counts[:3], counts[3:]) {
+		t.Errorf("Expected the same client options on every run, got %v", counts)
+	}
+}
@@ -0,0 +1,243 @@
+// Package ares queries ARES (Administrativní registr ekonomických subjektů), the register kept by
+// the Ministry of Finance aggregating basic data of economic subjects from other public registers.
+package ares
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/registry"
+	"github.com/fstaffa/czsnoop/internal/types"
+)
+
+const defaultBaseUrl = "https://ares.gov.cz"
+
+const (
+	subjectsPath = "/ekonomicke-subjekty-v-be/rest/ekonomicke-subjekty"
+	searchPath   = subjectsPath + "/vyhledat"
+)
+
+// MaxSearchResults is the most results ARES returns for a single search, searches matching more fail with ErrTooManyMatches
+const MaxSearchResults = 1000
+
+type Ares struct {
+	baseUrl string
+	client  http.Client
+	logger  *slog.Logger
+	context context.Context
+	cache   registry.ResponseCache
+}
+
+type clientOptions struct {
+	baseUrl           string
+	transport         http.RoundTripper
+	concurrency       int
+	requestsPerSecond float64
+	retry             registry.RetryPolicy
+	cache             registry.Cache
+}
+
+type Option func(*clientOptions)
+
+// WithBaseUrl points the client to a different ARES instance, e.g. local fake server in tests
+func WithBaseUrl(baseUrl string) Option {
+	return func(o *clientOptions) {
+		o.baseUrl = strings.TrimSuffix(baseUrl, "/")
+	}
+}
+
+// WithTransport replaces the default HTTP transport of the client
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *clientOptions) {
+		o.transport = transport
+	}
+}
+
+// WithConcurrency limits number of requests in flight, request is in flight until its response body is closed
+func WithConcurrency(concurrency int) Option {
+	return func(o *clientOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+// WithRateLimit limits number of requests started per second using token bucket, 0 means unlimited
+func WithRateLimit(requestsPerSecond float64) Option {
+	return func(o *clientOptions) {
+		o.requestsPerSecond = requestsPerSecond
+	}
+}
+
+// WithRetry sets how many times are failed requests retried and the initial backoff delay,
+// the delay doubles with each retry up to 30 seconds
+func WithRetry(maxRetries int, baseDelay time.Duration) Option {
+	return func(o *clientOptions) {
+		o.retry.MaxRetries = maxRetries
+		o.retry.BaseDelay = baseDelay
+	}
+}
+
+func CreateClient(ctx context.Context, logger *slog.Logger, options ...Option) *Ares {
+	opts := clientOptions{baseUrl: defaultBaseUrl, transport: http.DefaultTransport, retry: registry.DefaultRetryPolicy}
+	for _, option := range options {
+		option(&opts)
+	}
+	transport := registry.NewRetryingTransport(registry.NewLimitedTransport(opts.transport, opts.concurrency, opts.requestsPerSecond), opts.retry, logger)
+	return &Ares{
+		baseUrl: opts.baseUrl,
+		client:  http.Client{Timeout: 60 * time.Second, Transport: transport},
+		logger:  logger,
+		context: ctx,
+		cache:   registry.ResponseCache{Cache: opts.cache, Logger: logger},
+	}
+}
+
+// EconomicSubject is basic data of a subject as ARES states them
+type EconomicSubject struct {
+	Ico     types.Ico `json:"ico"`
+	Name    string    `json:"obchodniJmeno"`
+	Address Address   `json:"sidlo"`
+	// LegalFormCode is a code from the classification of legal forms, see LegalForm
+	LegalFormCode string `json:"pravniForma"`
+	// VatId (DIČ) is assigned also to subjects which are not VAT payers, see VatPayer
+	VatId         string        `json:"dic"`
+	NaceCodes     []string      `json:"czNace"`
+	EstablishedOn Iso8601Date   `json:"datumVzniku"`
+	TerminatedOn  Iso8601Date   `json:"datumZaniku"`
+	Registrations Registrations `json:"seznamRegistraci"`
+}
+
+type Address struct {
+	Text string `json:"textovaAdresa"`
+}
+
+// Registrations describe state of the subject in source registers of ARES, e.g. AKTIVNI or HISTORICKY
+type Registrations struct {
+	CommercialRegister string `json:"stavZdrojeVr"`
+	TradeRegister      string `json:"stavZdrojeRzp"`
+	Vat                string `json:"stavZdrojeDph"`
+}
+
+const registrationActive = "AKTIVNI"
+
+// VatPayer checks whether the subject is currently registered for VAT
+func (s EconomicSubject) VatPayer() bool {
+	return s.Registrations.Vat == registrationActive
+}
+
+// LegalForm returns name of the legal form, or its code if the name is not known
+func (s EconomicSubject) LegalForm() string {
+	if name, ok := legalForms[s.LegalFormCode]; ok {
+		return name
+	}
+	return s.LegalFormCode
+}
+
+// NaturalPerson checks whether the subject is a natural person doing business, e.g. a sole trader, whose business
+// name is the name of the person. Codes of such legal forms lie between 100 and 109.
+func (s EconomicSubject) NaturalPerson() bool {
+	code, err := strconv.Atoi(s.LegalFormCode)
+	return err == nil && code >= 100 && code < 110
+}
+
+// legalForms names the most common legal forms of the classification kept by the Czech Statistical Office
+var legalForms = map[string]string{
+	"101": "Fyzická osoba podnikající dle živnostenského zákona",
+	"102": "Fyzická osoba podnikající dle živnostenského zákona zapsaná v obchodním rejstříku",
+	"105": "Fyzická osoba podnikající dle jiných zákonů než živnostenského a zákona o zemědělství",
+	"107": "Zemědělský podnikatel - fyzická osoba nezapsaná v obchodním rejstříku",
+	"111": "Veřejná obchodní společnost",
+	"112": "Společnost s ručením omezeným",
+	"113": "Společnost komanditní",
+	"117": "Nadace",
+	"118": "Nadační fond",
+	"121": "Akciová společnost",
+	"141": "Obecně prospěšná společnost",
+	"145": "Společenství vlastníků jednotek",
+	"205": "Družstvo",
+	"301": "Státní podnik",
+	"325": "Organizační složka státu",
+	"331": "Příspěvková organizace",
+	"706": "Spolek",
+	"801": "Obec",
+}
+
+// Iso8601Date is a date in YYYY-MM-DD format, empty string and null are zero date
+type Iso8601Date = registry.Iso8601Date
+
+// GetSubject returns subject with given ICO
+func (a *Ares) GetSubject(ico types.Ico) (EconomicSubject, error) {
+	var subject EconomicSubject
+	if a.cache.Get(a.context, CacheEndpointSubjects, string(ico), &subject) {
+		return subject, nil
+	}
+	req, err := http.NewRequestWithContext(a.context, http.MethodGet, a.baseUrl+subjectsPath+"/"+string(ico), nil)
+	if err != nil {
+		return EconomicSubject{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	if err := a.do(req, "ekonomicke-subjekty", &subject); err != nil {
+		return EconomicSubject{}, err
+	}
+	a.cache.Put(a.context, CacheEndpointSubjects, string(ico), subject)
+	return subject, nil
+}
+
+type SearchQuery struct {
+	// Name is matched against the business name (obchodní jméno) of subjects
+	Name string `json:"obchodniJmeno,omitempty"`
+	// Start is offset of the first returned result, Count how many results to return, 0 means ARES default
+	Start int `json:"start"`
+	Count int `json:"pocet,omitempty"`
+}
+
+type SearchResponse struct {
+	// Total is number of all matching subjects, only part of them might be in Subjects
+	Total    int               `json:"pocetCelkem"`
+	Subjects []EconomicSubject `json:"ekonomickeSubjekty"`
+}
+
+// Search finds subjects matching the query
+func (a *Ares) Search(query SearchQuery) (SearchResponse, error) {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return SearchResponse{}, fmt.Errorf("unable to encode query: %w", err)
+	}
+	var response SearchResponse
+	if a.cache.Get(a.context, CacheEndpointSearch, string(body), &response) {
+		return response, nil
+	}
+	req, err := http.NewRequestWithContext(a.context, http.MethodPost, a.baseUrl+searchPath, bytes.NewReader(body))
+	if err != nil {
+		return SearchResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := a.do(req, "ekonomicke-subjekty/vyhledat", &response); err != nil {
+		return SearchResponse{}, err
+	}
+	a.logger.DebugContext(a.context, "Search result", slog.Int("total", response.Total), slog.Int("count", len(response.Subjects)))
+	a.cache.Put(a.context, CacheEndpointSearch, string(body), response)
+	return response, nil
+}
+
+func (a *Ares) do(req *http.Request, path string, v any) error {
+	req.Header.Set("Accept", "application/json")
+	a.logger.DebugContext(a.context, "Requesting ARES", slog.String("method", req.Method), slog.String("url", req.URL.String()))
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to do request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newHTTPStatusError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return &SchemaError{Path: path, Err: err}
+	}
+	return nil
+}
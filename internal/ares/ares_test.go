@@ -0,0 +1,149 @@
+package ares
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/ares/arestest"
+	"github.com/fstaffa/czsnoop/internal/types"
+)
+
+func createTestClient(t *testing.T, url string) *Ares {
+	t.Helper()
+	return CreateClient(context.Background(), slog.Default(), WithBaseUrl(url))
+}
+
+func Test_GetSubject(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		ico               types.Ico
+		expectedName      string
+		expectedLegalForm string
+		expectedVatPayer  bool
+		expectedNace      []string
+		terminated        bool
+	}{
+		"limited company": {
+			ico: "01895541", expectedName: "THOMAS SILVERTONNI s.r.o.", expectedLegalForm: "Společnost s ručením omezeným",
+			expectedVatPayer: true, expectedNace: []string{"62010", "62020", "63110"},
+		},
+		"natural person": {
+			ico: "73452301", expectedName: "Ing. Petr Novák, Ph.D.", expectedLegalForm: "Fyzická osoba podnikající dle živnostenského zákona",
+			expectedNace: []string{"62020", "58110", "711"},
+		},
+		"terminated": {
+			ico: "27345009", expectedName: "Hans Müller", expectedLegalForm: "Fyzická osoba podnikající dle živnostenského zákona",
+			expectedNace: []string{}, terminated: true,
+		},
+	}
+	client := createTestClient(t, arestest.NewServer(t).URL)
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			subject, err := client.GetSubject(test.ico)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if subject.Ico != test.ico || subject.Name != test.expectedName {
+				t.Errorf("Expected %s with ICO %s, got %+v", test.expectedName, test.ico, subject)
+			}
+			if subject.LegalForm() != test.expectedLegalForm {
+				t.Errorf("Expected legal form %s, got %s", test.expectedLegalForm, subject.LegalForm())
+			}
+			if subject.VatPayer() != test.expectedVatPayer {
+				t.Errorf("Expected VAT payer %v, got %+v", test.expectedVatPayer, subject.Registrations)
+			}
+			if !slices.Equal(subject.NaceCodes, test.expectedNace) {
+				t.Errorf("Expected NACE codes %v, got %v", test.expectedNace, subject.NaceCodes)
+			}
+			if time.Time(subject.EstablishedOn).IsZero() {
+				t.Errorf("Expected date of establishment, got zero")
+			}
+			if test.terminated == time.Time(subject.TerminatedOn).IsZero() {
+				t.Errorf("Expected terminated %v, got date of termination %v", test.terminated, time.Time(subject.TerminatedOn))
+			}
+		})
+	}
+}
+
+func Test_GetSubject_NotFound(t *testing.T) {
+	t.Parallel()
+	client := createTestClient(t, arestest.NewServer(t).URL)
+
+	_, err := client.GetSubject("27074358")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != "NENALEZENO" {
+		t.Errorf("Expected error code NENALEZENO, got %v", err)
+	}
+}
+
+func Test_Search(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		query         SearchQuery
+		expectedTotal int
+		expectedIcos  []types.Ico
+	}{
+		"all at once":  {query: SearchQuery{Name: "Novák", Count: 100}, expectedTotal: 3, expectedIcos: []types.Ico{"45678910", "24681351", "73452301"}},
+		"first page":   {query: SearchQuery{Name: "Novák", Count: 2}, expectedTotal: 3, expectedIcos: []types.Ico{"45678910", "24681351"}},
+		"second page":  {query: SearchQuery{Name: "Novák", Start: 2, Count: 2}, expectedTotal: 3, expectedIcos: []types.Ico{"73452301"}},
+		"no such name": {query: SearchQuery{Name: "Silvertonni", Count: 100}, expectedTotal: 0},
+	}
+	client := createTestClient(t, arestest.NewServer(t).URL)
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			response, err := client.Search(test.query)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if response.Total != test.expectedTotal {
+				t.Errorf("Expected %d subjects in total, got %d", test.expectedTotal, response.Total)
+			}
+			var icos []types.Ico
+			for _, subject := range response.Subjects {
+				icos = append(icos, subject.Ico)
+			}
+			if !slices.Equal(icos, test.expectedIcos) {
+				t.Errorf("Expected subjects %v, got %v", test.expectedIcos, icos)
+			}
+		})
+	}
+}
+
+func Test_Search_TooManyMatches(t *testing.T) {
+	t.Parallel()
+	client := createTestClient(t, arestest.NewServer(t).URL)
+
+	_, err := client.Search(SearchQuery{Name: "s.r.o.", Count: 100})
+	if !errors.Is(err, ErrTooManyMatches) {
+		t.Errorf("Expected ErrTooManyMatches, got %v", err)
+	}
+}
+
+func Test_GetSubject_SchemaError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ico": "01895541", "datumVzniku": "28.3.2013"}`))
+	}))
+	t.Cleanup(server.Close)
+	client := createTestClient(t, server.URL)
+
+	_, err := client.GetSubject("01895541")
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Expected SchemaError, got %v", err)
+	}
+	if schemaErr.Path != "ekonomicke-subjekty" {
+		t.Errorf("Expected path ekonomicke-subjekty, got %s", schemaErr.Path)
+	}
+}
@@ -0,0 +1,160 @@
+// Package arestest provides a local stand-in for ARES serving recorded responses,
+// so that the ARES client and everything built on top of it can be tested without network.
+package arestest
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+)
+
+//go:embed testdata
+var testdata embed.FS
+
+const jsonContentType = "application/json"
+
+// Fixture maps a request to a recorded response in testdata
+type Fixture struct {
+	Path string
+	// Body of search requests, it is matched after normalization, see NormalizeBody
+	Body string
+	File string
+	// StatusCode of the response, zero means 200 OK
+	StatusCode int
+}
+
+const (
+	subjectsPath = "/ekonomicke-subjekty-v-be/rest/ekonomicke-subjekty"
+	searchPath   = subjectsPath + "/vyhledat"
+)
+
+const (
+	// emptySearch is served for searches without recorded fixture
+	emptySearch = "vyhledat_empty.json"
+	// notFound is served for subjects without recorded fixture, as ARES answers them
+	notFound = "nenalezeno.json"
+)
+
+func subjectFixture(ico string) Fixture {
+	return Fixture{Path: subjectsPath + "/" + ico, File: "subjekt_" + ico + ".json"}
+}
+
+// Fixtures are the recorded responses served by NewServer
+var Fixtures = []Fixture{
+	subjectFixture("45678910"),
+	subjectFixture("01895541"),
+	subjectFixture("24681351"),
+	subjectFixture("73452301"),
+	subjectFixture("27345009"),
+	{Path: searchPath, Body: `{"obchodniJmeno":"Novák","start":0,"pocet":100}`, File: "vyhledat_novak.json"},
+	{Path: searchPath, Body: `{"obchodniJmeno":"Novák","start":0,"pocet":2}`, File: "vyhledat_novak_0.json"},
+	{Path: searchPath, Body: `{"obchodniJmeno":"Novák","start":2,"pocet":2}`, File: "vyhledat_novak_2.json"},
+	{Path: searchPath, Body: `{"obchodniJmeno":"Petr Novák","start":0,"pocet":100}`, File: "vyhledat_petr_novak.json"},
+	{Path: searchPath, Body: `{"obchodniJmeno":"s.r.o.","start":0,"pocet":100}`, File: "vyhledat_prilis_mnoho.json", StatusCode: http.StatusBadRequest},
+}
+
+// NormalizeBody makes JSON bodies comparable regardless of key order and formatting
+func NormalizeBody(body []byte) (string, error) {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return "", err
+	}
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(normalized), nil
+}
+
+// NewServer starts fake ARES serving Fixtures, the server is closed when the test finishes
+func NewServer(t testing.TB) *httptest.Server {
+	t.Helper()
+	return NewServerWithFixtures(t, Fixtures)
+}
+
+// NewServerWithFixtures starts fake ARES serving only given fixtures, the server is closed when the test finishes
+func NewServerWithFixtures(t testing.TB, fixtures []Fixture) *httptest.Server {
+	t.Helper()
+	handler, err := NewHandler(fixtures)
+	if err != nil {
+		t.Fatalf("Unable to create fake ARES: %v", err)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Without returns Fixtures except those served from given files
+func Without(files ...string) []Fixture {
+	result := make([]Fixture, 0, len(Fixtures))
+	for _, fixture := range Fixtures {
+		if !slices.Contains(files, fixture.File) {
+			result = append(result, fixture)
+		}
+	}
+	return result
+}
+
+// NewHandler creates handler of fake ARES serving given fixtures, searches without a fixture
+// find nothing and subjects without a fixture are not found
+func NewHandler(fixtures []Fixture) (http.Handler, error) {
+	index := make(map[string]Fixture, len(fixtures))
+	for _, fixture := range fixtures {
+		key := fixture.Path
+		if fixture.Body != "" {
+			body, err := NormalizeBody([]byte(fixture.Body))
+			if err != nil {
+				return nil, fmt.Errorf("invalid body in fixture %s: %v", fixture.File, err)
+			}
+			key += " " + body
+		}
+		index[key] = fixture
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Path
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == searchPath:
+			content, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			body, err := NormalizeBody(content)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			key += " " + body
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, subjectsPath+"/"):
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		fixture, ok := index[key]
+		if !ok && r.URL.Path == searchPath {
+			fixture, ok = Fixture{File: emptySearch}, true
+		}
+		if !ok {
+			fixture = Fixture{File: notFound, StatusCode: http.StatusNotFound}
+		}
+		content, err := testdata.ReadFile(path.Join("testdata", fixture.File))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", jsonContentType)
+		if fixture.StatusCode != 0 {
+			w.WriteHeader(fixture.StatusCode)
+		}
+		_, _ = w.Write(content)
+	}), nil
+}
@@ -0,0 +1,27 @@
+package ares
+
+import (
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/registry"
+)
+
+// Cache endpoints of the client
+const (
+	CacheEndpointSubjects = "ares/ekonomicke-subjekty"
+	CacheEndpointSearch   = "ares/vyhledat"
+)
+
+// CacheTTLs is the default time to live of cached responses per endpoint
+var CacheTTLs = map[string]time.Duration{
+	CacheEndpointSubjects: 7 * 24 * time.Hour,
+	CacheEndpointSearch:   24 * time.Hour,
+}
+
+// WithCache stores subjects and search results in the cache and answers repeated queries from it,
+// searches are keyed by the JSON encoded query
+func WithCache(cache registry.Cache) Option {
+	return func(o *clientOptions) {
+		o.cache = cache
+	}
+}
@@ -0,0 +1,101 @@
+package ares
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/ares/arestest"
+)
+
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func (c *memoryCache) Get(endpoint string, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.entries[endpoint+"|"+key]
+	return data, ok
+}
+
+func (c *memoryCache) Put(endpoint string, key string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[endpoint+"|"+key] = data
+	return nil
+}
+
+// countingServer serves the fixtures, failing the first failures requests with 503
+func countingServer(t *testing.T, failures int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	handler, err := arestest.NewHandler(arestest.Fixtures)
+	if err != nil {
+		t.Fatalf("Unable to create handler %v", err)
+	}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func Test_WithCache_AnswersRepeatedQueries(t *testing.T) {
+	t.Parallel()
+	server, requests := countingServer(t, 0)
+	cache := &memoryCache{entries: map[string][]byte{}}
+	lookup := func() (EconomicSubject, SearchResponse) {
+		client := CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL), WithRetry(0, 0), WithCache(cache))
+		subject, err := client.GetSubject("01895541")
+		if err != nil {
+			t.Fatalf("Unable to get subject %v", err)
+		}
+		response, err := client.Search(SearchQuery{Name: "Novák", Count: 100})
+		if err != nil {
+			t.Fatalf("Unable to search %v", err)
+		}
+		return subject, response
+	}
+
+	subject, response := lookup()
+	fetched := requests.Load()
+	cachedSubject, cachedResponse := lookup()
+
+	if requests.Load() != fetched {
+		t.Errorf("Expected no requests when answered from cache, got %d", requests.Load()-fetched)
+	}
+	if cachedSubject.Name != subject.Name || !time.Time(cachedSubject.EstablishedOn).Equal(time.Time(subject.EstablishedOn)) {
+		t.Errorf("Expected cached subject %+v, got %+v", subject, cachedSubject)
+	}
+	if cachedResponse.Total != response.Total || len(cachedResponse.Subjects) != len(response.Subjects) {
+		t.Errorf("Expected cached response %+v, got %+v", response, cachedResponse)
+	}
+}
+
+func Test_CreateClient_RetriesUnavailableServer(t *testing.T) {
+	t.Parallel()
+	server, requests := countingServer(t, 2)
+	client := CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL), WithRetry(2, 0))
+
+	response, err := client.Search(SearchQuery{Name: "Novák", Count: 100})
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if response.Total != 3 {
+		t.Errorf("Expected 3 subjects in total, got %d", response.Total)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests.Load())
+	}
+}
@@ -0,0 +1,50 @@
+package ares
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/fstaffa/czsnoop/internal/registry"
+)
+
+var (
+	// ErrNotFound is returned when ARES does not know the requested subject
+	ErrNotFound = registry.ErrNotFound
+	// ErrRateLimited is returned when ARES rejects requests with 429 Too Many Requests
+	ErrRateLimited = registry.ErrRateLimited
+	// ErrTooManyMatches is returned when a search matches more than MaxSearchResults subjects
+	ErrTooManyMatches = errors.New("too many possible matches, please provide more details")
+)
+
+// tooManyResultsCode is the sub code of ARES error response to searches matching too many subjects
+const tooManyResultsCode = "VYSTUP_PRILIS_MNOHO_VYSLEDKU"
+
+// HTTPStatusError is returned when ARES responds with unexpected status code
+type HTTPStatusError = registry.HTTPStatusError
+
+// SchemaError is returned when response of ARES does not match the expected structure
+type SchemaError = registry.SchemaError
+
+// errorResponse is the body ARES sends with error status codes
+type errorResponse struct {
+	Code        string `json:"kod"`
+	SubCode     string `json:"subKod"`
+	Description string `json:"popis"`
+}
+
+// newHTTPStatusError keeps code and description of ARES error response, searches matching
+// too many subjects are marked with ErrTooManyMatches
+func newHTTPStatusError(resp *http.Response) *HTTPStatusError {
+	err := registry.NewHTTPStatusError(resp)
+	var response errorResponse
+	// error responses of proxies in front of ARES are not JSON, they are kept only as body
+	_ = json.Unmarshal([]byte(err.Body), &response)
+	err.Code = response.Code
+	err.SubCode = response.SubCode
+	err.Description = response.Description
+	if response.SubCode == tooManyResultsCode {
+		err.Err = ErrTooManyMatches
+	}
+	return err
+}
@@ -0,0 +1,259 @@
+// Package cache stores registry responses on disk so that repeated lookups do not hit the registries.
+//
+// Entries are addressed by SHA-256 of their endpoint and key and stored as JSON files
+// in two level directory structure under Dir. Every endpoint has its own time to live.
+// Stats, Prune and Clear touch only files named this way, so Dir may be shared with other data.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultTTL is used for endpoints without configured time to live
+const DefaultTTL = 24 * time.Hour
+
+type entry struct {
+	Endpoint string          `json:"endpoint"`
+	Key      string          `json:"key"`
+	StoredAt time.Time       `json:"storedAt"`
+	Data     json.RawMessage `json:"data"`
+}
+
+type Store struct {
+	Dir string
+	// TTLs maps endpoint to time to live of its entries
+	TTLs map[string]time.Duration
+	// Refresh ignores existing entries while still storing new ones
+	Refresh bool
+	now     func() time.Time
+}
+
+// DefaultDir returns czsnoop directory in user cache directory, $XDG_CACHE_HOME/czsnoop on Linux
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to find user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "czsnoop"), nil
+}
+
+func New(dir string, ttls map[string]time.Duration) *Store {
+	return &Store{Dir: dir, TTLs: ttls, now: time.Now}
+}
+
+func (s *Store) ttl(endpoint string) time.Duration {
+	if ttl, ok := s.TTLs[endpoint]; ok {
+		return ttl
+	}
+	return DefaultTTL
+}
+
+func (s *Store) path(endpoint string, key string) string {
+	sum := sha256.Sum256([]byte(endpoint + "\x00" + key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(s.Dir, name[:2], name+".json")
+}
+
+func (s *Store) expired(e entry) bool {
+	return s.now().Sub(e.StoredAt) > s.ttl(e.Endpoint)
+}
+
+// Get returns data stored for endpoint and key unless it is missing or expired
+func (s *Store) Get(endpoint string, key string) ([]byte, bool) {
+	if s.Refresh {
+		return nil, false
+	}
+	e, err := readEntry(s.path(endpoint, key))
+	if err != nil || e.Endpoint != endpoint || e.Key != key || s.expired(e) {
+		return nil, false
+	}
+	return e.Data, true
+}
+
+func (s *Store) Put(endpoint string, key string, data []byte) error {
+	content, err := json.Marshal(entry{Endpoint: endpoint, Key: key, StoredAt: s.now().UTC(), Data: data})
+	if err != nil {
+		return fmt.Errorf("unable to marshal cache entry: %w", err)
+	}
+	path := s.path(endpoint, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("unable to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return fmt.Errorf("unable to create cache entry: %w", err)
+	}
+	_, err = tmp.Write(content)
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("unable to write cache entry: %w", err)
+	}
+	return nil
+}
+
+func readEntry(path string) (entry, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return entry{}, err
+	}
+	var e entry
+	err = json.Unmarshal(content, &e)
+	return e, err
+}
+
+type EndpointStats struct {
+	Endpoint string
+	Entries  int
+	Expired  int
+	Bytes    int64
+}
+
+type Stats struct {
+	Dir       string
+	Entries   int
+	Expired   int
+	Bytes     int64
+	Endpoints []EndpointStats
+}
+
+// walk calls fn for every entry file, unreadable entries are reported with empty endpoint. Only files named
+// by the store are visited, other files in Dir are never read nor removed.
+func (s *Store) walk(fn func(path string, e entry, size int64, err error) error) error {
+	shards, err := os.ReadDir(s.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, shard := range shards {
+		if !shard.IsDir() || !isHex(shard.Name(), 2) {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(s.Dir, shard.Name()))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if !isEntryName(shard.Name(), file) {
+				continue
+			}
+			info, err := file.Info()
+			if err != nil {
+				return err
+			}
+			path := filepath.Join(s.Dir, shard.Name(), file.Name())
+			e, readErr := readEntry(path)
+			if err := fn(path, e, info.Size(), readErr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isEntryName checks that the file is named like entries in the shard directory, see Store.path
+func isEntryName(shard string, file fs.DirEntry) bool {
+	name, ok := strings.CutSuffix(file.Name(), ".json")
+	return ok && file.Type().IsRegular() && isHex(name, sha256.Size*2) && strings.HasPrefix(name, shard)
+}
+
+// isHex checks that s consists of length lowercase hexadecimal digits
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Store) Stats() (Stats, error) {
+	stats := Stats{Dir: s.Dir}
+	endpoints := map[string]*EndpointStats{}
+	err := s.walk(func(path string, e entry, size int64, err error) error {
+		endpoint := e.Endpoint
+		if err != nil {
+			endpoint = "[unreadable]"
+		}
+		es, ok := endpoints[endpoint]
+		if !ok {
+			es = &EndpointStats{Endpoint: endpoint}
+			endpoints[endpoint] = es
+		}
+		es.Entries++
+		es.Bytes += size
+		stats.Entries++
+		stats.Bytes += size
+		if err != nil || s.expired(e) {
+			es.Expired++
+			stats.Expired++
+		}
+		return nil
+	})
+	if err != nil {
+		return Stats{}, fmt.Errorf("unable to read cache: %w", err)
+	}
+	for _, es := range endpoints {
+		stats.Endpoints = append(stats.Endpoints, *es)
+	}
+	sort.Slice(stats.Endpoints, func(i, j int) bool { return stats.Endpoints[i].Endpoint < stats.Endpoints[j].Endpoint })
+	return stats, nil
+}
+
+// Prune removes expired and unreadable entries and returns how many were removed
+func (s *Store) Prune() (int, error) {
+	removed := 0
+	err := s.walk(func(path string, e entry, size int64, err error) error {
+		if err == nil && !s.expired(e) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("unable to prune cache: %w", err)
+	}
+	return removed, nil
+}
+
+// Clear removes all entries. Dir itself and files not created by the store are kept, shard directories
+// are removed only when they end up empty.
+func (s *Store) Clear() error {
+	shards := map[string]bool{}
+	err := s.walk(func(path string, e entry, size int64, err error) error {
+		shards[filepath.Dir(path)] = true
+		return os.Remove(path)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to clear cache: %w", err)
+	}
+	for shard := range shards {
+		// fails for shards holding other files, which are left in place
+		_ = os.Remove(shard)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T, now *time.Time) *Store {
+	t.Helper()
+	store := New(t.TempDir(), map[string]time.Duration{"short": time.Hour, "long": 24 * time.Hour})
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func Test_Store_GetPut(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(t, &now)
+
+	if _, ok := store.Get("short", "a"); ok {
+		t.Errorf("Expected empty cache to miss")
+	}
+	if err := store.Put("short", "a", []byte(`{"a":1}`)); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	data, ok := store.Get("short", "a")
+	if !ok || string(data) != `{"a":1}` {
+		t.Errorf("Expected stored data, got %s", data)
+	}
+	if _, ok := store.Get("long", "a"); ok {
+		t.Errorf("Expected different endpoint with the same key to miss")
+	}
+}
+
+func Test_Store_Expiry(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(t, &now)
+	for _, endpoint := range []string{"short", "long", "default"} {
+		if err := store.Put(endpoint, "a", []byte(`1`)); err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+	}
+
+	now = now.Add(2 * time.Hour)
+	tests := map[string]struct {
+		endpoint string
+		expected bool
+	}{
+		"expired":     {endpoint: "short", expected: false},
+		"valid":       {endpoint: "long", expected: true},
+		"default ttl": {endpoint: "default", expected: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, ok := store.Get(test.endpoint, "a")
+			if ok != test.expected {
+				t.Errorf("Expected hit %v, got %v", test.expected, ok)
+			}
+		})
+	}
+}
+
+func Test_Store_Refresh(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(t, &now)
+	if err := store.Put("short", "a", []byte(`1`)); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	store.Refresh = true
+	if _, ok := store.Get("short", "a"); ok {
+		t.Errorf("Expected refresh to ignore stored entry")
+	}
+	if err := store.Put("short", "a", []byte(`2`)); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	store.Refresh = false
+	if data, _ := store.Get("short", "a"); string(data) != "2" {
+		t.Errorf("Expected refreshed entry 2, got %s", data)
+	}
+}
+
+func Test_Store_StatsPruneClear(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(t, &now)
+	for _, key := range []string{"a", "b"} {
+		if err := store.Put("short", key, []byte(`1`)); err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+	}
+	if err := store.Put("long", "a", []byte(`1`)); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+
+	stats, err := store.Stats()
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if stats.Entries != 3 || stats.Expired != 2 {
+		t.Errorf("Expected 3 entries with 2 expired, got %d with %d expired", stats.Entries, stats.Expired)
+	}
+	if len(stats.Endpoints) != 2 || stats.Endpoints[0].Endpoint != "long" || stats.Endpoints[1].Entries != 2 {
+		t.Errorf("Expected stats of long and short endpoints, got %+v", stats.Endpoints)
+	}
+
+	removed, err := store.Prune()
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 pruned entries, got %d", removed)
+	}
+	if _, ok := store.Get("long", "a"); !ok {
+		t.Errorf("Expected valid entry to survive pruning")
+	}
+
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	stats, err = store.Stats()
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if stats.Entries != 0 {
+		t.Errorf("Expected no entries after clear, got %d", stats.Entries)
+	}
+}
+
+func Test_Store_KeepsForeignFiles(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(t, &now)
+	if err := store.Put("short", "a", []byte(`1`)); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	shard := filepath.Dir(store.path("short", "a"))
+	foreign := []string{
+		filepath.Join(store.Dir, "notes.json"),
+		filepath.Join(store.Dir, "cassette", "0001.json"),
+		filepath.Join(shard, "notes.json"),
+		filepath.Join(store.Dir, "ff", strings.Repeat("0", 64)+".json"),
+	}
+	for _, path := range foreign {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+		if err := os.WriteFile(path, []byte(`not an entry`), 0o644); err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+	}
+
+	stats, err := store.Stats()
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if stats.Entries != 1 {
+		t.Errorf("Expected only the stored entry, got %d entries", stats.Entries)
+	}
+	if removed, err := store.Prune(); err != nil || removed != 0 {
+		t.Errorf("Expected nothing pruned, got %d with error %v", removed, err)
+	}
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if _, ok := store.Get("short", "a"); ok {
+		t.Errorf("Expected entry to be cleared")
+	}
+	for _, path := range foreign {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be kept, got %v", path, err)
+		}
+	}
+}
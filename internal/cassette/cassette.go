@@ -0,0 +1,353 @@
+// Package cassette records HTTP traffic to a directory and replays it later.
+//
+// Every request/response pair is stored as a separate JSON file (an interaction) in the cassette
+// directory. Response bodies are stored byte for byte, as text when they are valid UTF-8 and
+// base64 encoded otherwise, together with their SHA-256 so that recorded evidence can be verified.
+// Session identifiers (Sesid header, mseidf cookie and sesid returned by session start) are scrubbed.
+// The hash is always computed from the body as it was served, a body with scrubbed sesid thus no longer
+// matches it and the hash of the stored body is recorded next to it.
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const Scrubbed = "[scrubbed]"
+
+const interactionSuffix = ".json"
+
+type Interaction struct {
+	Request    Request   `json:"request"`
+	Response   Response  `json:"response"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	Url    string      `json:"url"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query"`
+	Header http.Header `json:"header"`
+	// BodySha256 identifies body of requests which have one, e.g. ARES search
+	BodySha256 string `json:"bodySha256,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Status     string      `json:"status"`
+	Header     http.Header `json:"header"`
+	// BodyEncoding is either "text" or "base64"
+	BodyEncoding string `json:"bodyEncoding"`
+	Body         string `json:"body"`
+	// BodySha256 is hash of the body as it was served
+	BodySha256 string `json:"bodySha256"`
+	// ScrubbedBodySha256 is hash of Body when session identifiers were scrubbed from it, empty otherwise
+	ScrubbedBodySha256 string `json:"scrubbedBodySha256,omitempty"`
+}
+
+func (r Response) body() ([]byte, error) {
+	if r.BodyEncoding == "base64" {
+		return base64.StdEncoding.DecodeString(r.Body)
+	}
+	return []byte(r.Body), nil
+}
+
+// NormalizeQuery drops parameters with empty values and sorts the rest by key
+func NormalizeQuery(query url.Values) string {
+	normalized := url.Values{}
+	for key, values := range query {
+		for _, value := range values {
+			if value != "" {
+				normalized.Add(key, value)
+			}
+		}
+	}
+	return normalized.Encode()
+}
+
+func key(request Request) string {
+	k := request.Method + " " + request.Path + "?" + request.Query
+	if request.BodySha256 != "" {
+		k += " " + request.BodySha256
+	}
+	return k
+}
+
+// readRequest describes the request as it is matched, the body is read and replaced so that it can be sent
+func readRequest(req *http.Request) (Request, error) {
+	request := Request{
+		Method: req.Method,
+		Url:    req.URL.Scheme + "://" + req.URL.Host + req.URL.Path,
+		Path:   req.URL.Path,
+		Query:  NormalizeQuery(req.URL.Query()),
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return request, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return Request{}, fmt.Errorf("unable to read request body: %v", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) > 0 {
+		request.BodySha256 = sha256Hex(body)
+	}
+	return request, nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+var sessionBodyPattern = regexp.MustCompile(`("sesid"\s*:\s*)"[^"]*"`)
+
+func scrubRequestHeader(header http.Header) http.Header {
+	scrubbed := header.Clone()
+	if scrubbed == nil {
+		scrubbed = http.Header{}
+	}
+	if scrubbed.Get("Sesid") != "" {
+		scrubbed.Set("Sesid", Scrubbed)
+	}
+	if scrubbed.Get("Cookie") != "" {
+		scrubbed.Set("Cookie", scrubCookies(scrubbed.Get("Cookie")))
+	}
+	return scrubbed
+}
+
+func scrubResponseHeader(header http.Header) http.Header {
+	scrubbed := header.Clone()
+	if scrubbed == nil {
+		scrubbed = http.Header{}
+	}
+	cookies := scrubbed.Values("Set-Cookie")
+	scrubbed.Del("Set-Cookie")
+	for _, cookie := range cookies {
+		scrubbed.Add("Set-Cookie", scrubCookies(cookie))
+	}
+	return scrubbed
+}
+
+// scrubCookies replaces value of mseidf cookie in Cookie or Set-Cookie header value
+func scrubCookies(value string) string {
+	parts := strings.Split(value, ";")
+	for i, part := range parts {
+		name, _, found := strings.Cut(strings.TrimSpace(part), "=")
+		if found && name == "mseidf" {
+			parts[i] = strings.Replace(part, strings.TrimSpace(part), "mseidf="+Scrubbed, 1)
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// Recorder is http.RoundTripper passing requests to the wrapped transport and storing interactions in Dir.
+// Interactions are numbered after the highest number already in Dir and existing files are never overwritten.
+type Recorder struct {
+	Dir       string
+	Transport http.RoundTripper
+
+	mu   sync.Mutex
+	next int
+}
+
+func NewRecorder(dir string, transport http.RoundTripper) (*Recorder, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create cassette directory: %v", err)
+	}
+	existing, err := interactionFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	next := 0
+	for _, file := range existing {
+		prefix, _, _ := strings.Cut(filepath.Base(file), "-")
+		if index, err := strconv.Atoi(prefix); err == nil && index >= next {
+			next = index + 1
+		}
+	}
+	return &Recorder{Dir: dir, Transport: transport, next: next}, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	request, err := readRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	request.Header = scrubRequestHeader(req.Header)
+	resp, err := r.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body for recording: %v", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	storedBody := body
+	if sessionBodyPattern.Match(body) {
+		storedBody = sessionBodyPattern.ReplaceAll(body, []byte(`${1}"`+Scrubbed+`"`))
+	}
+	interaction := Interaction{
+		Request: request,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     scrubResponseHeader(resp.Header),
+		},
+		RecordedAt: time.Now().UTC(),
+	}
+	interaction.Response.BodySha256 = sha256Hex(body)
+	if !bytes.Equal(storedBody, body) {
+		interaction.Response.ScrubbedBodySha256 = sha256Hex(storedBody)
+	}
+	if utf8.Valid(storedBody) {
+		interaction.Response.BodyEncoding = "text"
+		interaction.Response.Body = string(storedBody)
+	} else {
+		interaction.Response.BodyEncoding = "base64"
+		interaction.Response.Body = base64.StdEncoding.EncodeToString(storedBody)
+	}
+
+	if err := r.store(interaction); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (r *Recorder) store(interaction Interaction) error {
+	data, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal interaction: %v", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for {
+		name := fmt.Sprintf("%05d-%s%s", r.next, strings.ToLower(interaction.Request.Method), interactionSuffix)
+		r.next++
+		file, err := os.OpenFile(filepath.Join(r.Dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			// written by someone else since the recorder was created
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("unable to store interaction: %v", err)
+		}
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("unable to store interaction: %v", err)
+		}
+		return nil
+	}
+}
+
+// Replayer is http.RoundTripper answering requests with interactions recorded in a cassette directory.
+// Requests are matched on method, path, normalized query and hash of the body, repeated requests are answered
+// with recorded interactions in order and the last one is reused when they run out.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions map[string][]Interaction
+	served       map[string]int
+}
+
+func NewReplayer(dir string) (*Replayer, error) {
+	files, err := interactionFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no interactions recorded in %s", dir)
+	}
+	replayer := &Replayer{interactions: map[string][]Interaction{}, served: map[string]int{}}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read interaction: %v", err)
+		}
+		var interaction Interaction
+		err = json.Unmarshal(data, &interaction)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal interaction %s: %v", file, err)
+		}
+		k := key(interaction.Request)
+		replayer.interactions[k] = append(replayer.interactions[k], interaction)
+	}
+	return replayer, nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	request, err := readRequest(req.Clone(req.Context()))
+	if err != nil {
+		return nil, err
+	}
+	k := key(request)
+	r.mu.Lock()
+	recorded, ok := r.interactions[k]
+	index := r.served[k]
+	if ok && index < len(recorded)-1 {
+		r.served[k] = index + 1
+	}
+	r.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no interaction recorded for %s", k)
+	}
+
+	interaction := recorded[min(index, len(recorded)-1)]
+	body, err := interaction.Response.body()
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode recorded body for %s: %v", k, err)
+	}
+	return &http.Response{
+		Status:        interaction.Response.Status,
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Response.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func interactionFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cassette directory: %v", err)
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), interactionSuffix) {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
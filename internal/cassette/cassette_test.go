@@ -0,0 +1,181 @@
+package cassette_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fstaffa/czsnoop/internal/ares"
+	"github.com/fstaffa/czsnoop/internal/ares/arestest"
+	"github.com/fstaffa/czsnoop/internal/cassette"
+	"github.com/fstaffa/czsnoop/internal/rzp"
+	"github.com/fstaffa/czsnoop/internal/rzp/rzptest"
+)
+
+func Test_RecordAndReplay(t *testing.T) {
+	t.Parallel()
+	server := rzptest.NewServer(t)
+	dir := t.TempDir()
+
+	recorder, err := cassette.NewRecorder(dir, rzp.DefaultTransport())
+	if err != nil {
+		t.Fatalf("Unable to create recorder %v", err)
+	}
+	client, err := rzp.CreateClient(context.Background(), slog.Default(), rzp.WithBaseUrl(server.URL), rzp.WithTransport(recorder))
+	if err != nil {
+		t.Fatalf("Unable to create client %v", err)
+	}
+	recorded, err := client.GetSubjectDetails("F4410")
+	if err != nil {
+		t.Fatalf("Unable to get subject details %v", err)
+	}
+	server.Close()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatalf("Unable to list interactions %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("Expected session, subject and statement interactions, got %d", len(files))
+	}
+	encodings := map[string]int{}
+	scrubbedBodies := 0
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Unable to read interaction %v", err)
+		}
+		var interaction cassette.Interaction
+		if err := json.Unmarshal(content, &interaction); err != nil {
+			t.Fatalf("Unable to unmarshal interaction %v", err)
+		}
+		encodings[interaction.Response.BodyEncoding]++
+		if scrubbed := interaction.Response.ScrubbedBodySha256; scrubbed != "" {
+			scrubbedBodies++
+			if scrubbed == interaction.Response.BodySha256 {
+				t.Errorf("Expected hash of the served body to differ from the scrubbed one in %s", filepath.Base(file))
+			}
+			if sum := sha256.Sum256([]byte(interaction.Response.Body)); hex.EncodeToString(sum[:]) != scrubbed {
+				t.Errorf("Expected scrubbed hash to match stored body in %s", filepath.Base(file))
+			}
+		}
+		if strings.Contains(string(content), rzptest.SessionId) {
+			t.Errorf("Expected session id to be scrubbed in %s", filepath.Base(file))
+		}
+		if strings.Contains(string(content), "c5a1e0f2") {
+			t.Errorf("Expected mseidf cookie to be scrubbed in %s", filepath.Base(file))
+		}
+	}
+
+	if scrubbedBodies != 1 {
+		t.Errorf("Expected only session start body to be scrubbed, got %d", scrubbedBodies)
+	}
+	if encodings["base64"] != 1 {
+		t.Errorf("Expected windows-1250 statement to be stored as base64, got encodings %v", encodings)
+	}
+
+	replayer, err := cassette.NewReplayer(dir)
+	if err != nil {
+		t.Fatalf("Unable to create replayer %v", err)
+	}
+	client, err = rzp.CreateClient(context.Background(), slog.Default(), rzp.WithBaseUrl(server.URL), rzp.WithTransport(replayer), rzp.WithRetry(0, 0))
+	if err != nil {
+		t.Fatalf("Unable to create replaying client %v", err)
+	}
+	replayed, err := client.GetSubjectDetails("F4410")
+	if err != nil {
+		t.Fatalf("Unable to replay subject details %v", err)
+	}
+	if replayed.FullNameWithTitles != recorded.FullNameWithTitles || replayed.Citizenship != recorded.Citizenship || len(replayed.Trades) != len(recorded.Trades) {
+		t.Errorf("Expected replayed details %v to match recorded %v", replayed, recorded)
+	}
+
+	_, err = client.GetSubjectDetails("F5521")
+	if err == nil {
+		t.Errorf("Expected error for request that was not recorded")
+	}
+}
+
+func Test_Recorder_ContinuesAfterExistingInteractions(t *testing.T) {
+	t.Parallel()
+	server := rzptest.NewServer(t)
+	dir := t.TempDir()
+	for _, name := range []string{"00000-get.json", "00007-get.json", "notes.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o644); err != nil {
+			t.Fatalf("Unable to write %s %v", name, err)
+		}
+	}
+
+	recorder, err := cassette.NewRecorder(dir, rzp.DefaultTransport())
+	if err != nil {
+		t.Fatalf("Unable to create recorder %v", err)
+	}
+	_, err = rzp.CreateClient(context.Background(), slog.Default(), rzp.WithBaseUrl(server.URL), rzp.WithTransport(recorder))
+	if err != nil {
+		t.Fatalf("Unable to create client %v", err)
+	}
+
+	for _, name := range []string{"00000-get.json", "00007-get.json", "notes.json"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(content) != "{}" {
+			t.Errorf("Expected %s to be kept, got %s %v", name, content, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "00008-get.json")); err != nil {
+		t.Errorf("Expected session start recorded after the highest existing number, got %v", err)
+	}
+}
+
+func Test_RecordAndReplay_MatchesRequestBody(t *testing.T) {
+	t.Parallel()
+	server := arestest.NewServer(t)
+	dir := t.TempDir()
+
+	recorder, err := cassette.NewRecorder(dir, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("Unable to create recorder %v", err)
+	}
+	client := ares.CreateClient(context.Background(), slog.Default(), ares.WithBaseUrl(server.URL), ares.WithTransport(recorder))
+	for _, query := range []ares.SearchQuery{{Name: "Novák", Count: 100}, {Name: "Silvertonni", Count: 100}} {
+		if _, err := client.Search(query); err != nil {
+			t.Fatalf("Unable to search %v", err)
+		}
+	}
+	server.Close()
+
+	replayer, err := cassette.NewReplayer(dir)
+	if err != nil {
+		t.Fatalf("Unable to create replayer %v", err)
+	}
+	client = ares.CreateClient(context.Background(), slog.Default(), ares.WithBaseUrl(server.URL), ares.WithTransport(replayer), ares.WithRetry(0, 0))
+	tests := map[string]struct {
+		query         ares.SearchQuery
+		expectedTotal int
+	}{
+		"matching subjects": {query: ares.SearchQuery{Name: "Novák", Count: 100}, expectedTotal: 3},
+		"no such name":      {query: ares.SearchQuery{Name: "Silvertonni", Count: 100}, expectedTotal: 0},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			response, err := client.Search(test.query)
+			if err != nil {
+				t.Fatalf("Unable to replay search %v", err)
+			}
+			if response.Total != test.expectedTotal {
+				t.Errorf("Expected %d subjects in total, got %d", test.expectedTotal, response.Total)
+			}
+		})
+	}
+
+	_, err = client.Search(ares.SearchQuery{Name: "Svoboda", Count: 100})
+	if err == nil {
+		t.Errorf("Expected error for search that was not recorded")
+	}
+}
@@ -0,0 +1,29 @@
+package justice
+
+import (
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/registry"
+)
+
+// Cache endpoints of the client
+const (
+	CacheEndpointCompanies = "justice/rejstrik-firma"
+	CacheEndpointPersons   = "justice/rejstrik-osoba"
+	CacheEndpointExtracts  = "justice/vypis"
+)
+
+// CacheTTLs is the default time to live of cached responses per endpoint,
+// extracts change rarely compared to search results
+var CacheTTLs = map[string]time.Duration{
+	CacheEndpointCompanies: 24 * time.Hour,
+	CacheEndpointPersons:   24 * time.Hour,
+	CacheEndpointExtracts:  7 * 24 * time.Hour,
+}
+
+// WithCache stores search results and extracts in the cache and answers repeated queries from it
+func WithCache(cache registry.Cache) Option {
+	return func(o *clientOptions) {
+		o.cache = cache
+	}
+}
@@ -0,0 +1,99 @@
+package justice
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fstaffa/czsnoop/internal/justice/justicetest"
+)
+
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func (c *memoryCache) Get(endpoint string, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.entries[endpoint+"|"+key]
+	return data, ok
+}
+
+func (c *memoryCache) Put(endpoint string, key string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[endpoint+"|"+key] = data
+	return nil
+}
+
+// countingServer serves the fixtures, failing the first failures requests with 503
+func countingServer(t *testing.T, failures int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	handler, err := justicetest.NewHandler(justicetest.Fixtures)
+	if err != nil {
+		t.Fatalf("Unable to create handler %v", err)
+	}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func Test_WithCache_AnswersRepeatedQueries(t *testing.T) {
+	t.Parallel()
+	server, requests := countingServer(t, 0)
+	cache := &memoryCache{entries: map[string][]byte{}}
+	lookup := func() ([]SearchResult, Extract) {
+		client := CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL), WithRetry(0, 0), WithCache(cache))
+		results, err := client.SearchIco("01895541")
+		if err != nil {
+			t.Fatalf("Unable to search ICO %v", err)
+		}
+		extract, err := client.GetExtract(results[0].SubjectId, false)
+		if err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+		return results, extract
+	}
+
+	results, extract := lookup()
+	fetched := requests.Load()
+	cachedResults, cachedExtract := lookup()
+
+	if requests.Load() != fetched {
+		t.Errorf("Expected no requests when answered from cache, got %d", requests.Load()-fetched)
+	}
+	if len(cachedResults) != len(results) || cachedResults[0].SubjectId != results[0].SubjectId {
+		t.Errorf("Expected cached results %+v, got %+v", results, cachedResults)
+	}
+	if cachedExtract.Name != extract.Name || len(cachedExtract.StatutoryBodies) != len(extract.StatutoryBodies) {
+		t.Errorf("Expected cached extract %+v, got %+v", extract, cachedExtract)
+	}
+}
+
+func Test_CreateClient_RetriesUnavailableServer(t *testing.T) {
+	t.Parallel()
+	server, requests := countingServer(t, 2)
+	client := CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL), WithRetry(2, 0))
+	results, err := client.SearchIco("01895541")
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("Expected 1 result, got %d", len(results))
+	}
+	if requests.Load() != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests.Load())
+	}
+}
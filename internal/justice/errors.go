@@ -0,0 +1,23 @@
+package justice
+
+import (
+	"errors"
+
+	"github.com/fstaffa/czsnoop/internal/registry"
+)
+
+var (
+	// ErrNotFound is returned when the register does not know the requested subject
+	ErrNotFound = registry.ErrNotFound
+	// ErrTooManyMatches is returned when search results span more pages than the client reads
+	ErrTooManyMatches = errors.New("too many matches")
+	// ErrRateLimited is returned when the register rejects requests with 429 Too Many Requests
+	ErrRateLimited = registry.ErrRateLimited
+)
+
+// HTTPStatusError is returned when the register responds with unexpected status code
+type HTTPStatusError = registry.HTTPStatusError
+
+// SchemaError is returned when page of the register does not have the expected structure,
+// which usually means that the register changed its web
+type SchemaError = registry.SchemaError
@@ -0,0 +1,405 @@
+package justice
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/names"
+	"github.com/fstaffa/czsnoop/internal/types"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Extract (výpis) of a subject from the register
+type Extract struct {
+	Name    string
+	Ico     types.Ico
+	Address string
+	// LegalForm, e.g. "Společnost s ručením omezeným"
+	LegalForm string
+	// FileNumber is the file number (spisová značka) together with the registering court
+	FileNumber   string
+	RegisteredOn time.Time
+	// RegisteredCapital as stated by the register, e.g. "200 000 Kč"
+	RegisteredCapital string
+	StatutoryBodies   []StatutoryBody
+	Shareholders      []Shareholder
+	// History lists deleted values of name, address, legal form and registered capital, only full extract has them
+	History []Record
+}
+
+// Period is a time interval, zero To means the period has not ended
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// Record is a value of the extract which was replaced or deleted
+type Record struct {
+	// Label of the record as stated by the register, e.g. "Sídlo"
+	Label string
+	Value string
+	Period
+}
+
+// Person is a natural person or legal entity stated in the extract, names of legal entities are in EntityName
+type Person struct {
+	FullName        string
+	FirstName       string
+	LastName        string
+	TitleBeforeName string
+	TitleAfterName  string
+	BirthDate       time.Time
+	Address         string
+	EntityName      string
+	EntityIco       types.Ico
+}
+
+type StatutoryBody struct {
+	// Name of the body, e.g. "představenstvo", "Statutární orgán" if the register does not name it
+	Name           string
+	MannerOfActing string
+	Members        []Member
+}
+
+type Member struct {
+	// Function as stated by the register, e.g. "jednatel" or "předseda představenstva"
+	Function string
+	Person
+	Period
+}
+
+type Shareholder struct {
+	Person
+	// Share describes the deposit and ownership interest, e.g. "Vklad: 100 000 Kč, Obchodní podíl: 50 %"
+	Share string
+	Period
+}
+
+// row is a row of the extract with nested rows, e.g. members of a statutory body
+type row struct {
+	label    string
+	values   []string
+	period   Period
+	children []row
+}
+
+var czechMonths = map[string]time.Month{
+	"ledna": time.January, "února": time.February, "března": time.March, "dubna": time.April,
+	"května": time.May, "června": time.June, "července": time.July, "srpna": time.August,
+	"září": time.September, "října": time.October, "listopadu": time.November, "prosince": time.December,
+}
+
+// parseCzechDate parses dates like "1. ledna 2005" used by the register, blank value is zero date
+func parseCzechDate(value string) (time.Time, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return time.Time{}, nil
+	}
+	if len(fields) != 3 || strings.HasSuffix(fields[1], ".") {
+		return time.Parse("2.1.2006", strings.Join(fields, ""))
+	}
+	day, err := strconv.Atoi(strings.TrimSuffix(fields[0], "."))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid day in date %q", value)
+	}
+	month, ok := czechMonths[fields[1]]
+	if !ok {
+		return time.Time{}, fmt.Errorf("unknown month in date %q", value)
+	}
+	year, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid year in date %q", value)
+	}
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return time.Time{}, fmt.Errorf("invalid day in date %q", value)
+	}
+	return date, nil
+}
+
+func parseSearchResults(page *node) ([]SearchResult, error) {
+	containers := page.findClass("search-results")
+	if len(containers) == 0 {
+		return nil, &SchemaError{Path: "search-results", Err: errors.New("missing search results")}
+	}
+	var results []SearchResult
+	for _, item := range containers[0].findClass("result") {
+		fields := map[string]string{}
+		for _, tr := range item.findTag("tr") {
+			var label string
+			for _, cell := range tr.children {
+				switch cell.tag {
+				case "th":
+					label = strings.TrimSuffix(cell.textContent(), ":")
+				case "td":
+					fields[label] = cell.textContent()
+				}
+			}
+		}
+		result := SearchResult{
+			Name:       fields["Název subjektu"],
+			FileNumber: fields["Spisová značka"],
+			Address:    fields["Sídlo"],
+		}
+		path := "search-results/" + result.Name
+		for _, link := range item.findTag("a") {
+			href, err := url.Parse(link.attrs["href"])
+			if err == nil && href.Query().Get("subjektId") != "" {
+				result.SubjectId = SubjectId(href.Query().Get("subjektId"))
+				break
+			}
+		}
+		if result.SubjectId == "" {
+			return nil, &SchemaError{Path: path, Err: errors.New("missing link to extract")}
+		}
+		if ico := fields["IČO"]; ico != "" {
+			parsed, err := types.CreateIco(strings.ReplaceAll(ico, " ", ""))
+			if err != nil {
+				return nil, &SchemaError{Path: path + "/IČO", Err: err}
+			}
+			result.Ico = parsed
+		}
+		registeredOn, err := parseCzechDate(fields["Den zápisu"])
+		if err != nil {
+			return nil, &SchemaError{Path: path + "/Den zápisu", Err: err}
+		}
+		result.RegisteredOn = registeredOn
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// parseNextPage returns query of the next page of search results linked from the pager, nil on the last page
+func parseNextPage(page *node) url.Values {
+	for _, link := range page.findClass("next") {
+		if link.tag != "a" {
+			continue
+		}
+		href, err := url.Parse(link.attrs["href"])
+		if err == nil && href.RawQuery != "" {
+			return href.Query()
+		}
+	}
+	return nil
+}
+
+func parseRows(n *node) ([]row, error) {
+	var rows []row
+	for _, element := range n.findClass("div-row") {
+		r, err := parseRow(element)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r)
+	}
+	return rows, nil
+}
+
+func parseRow(element *node) (row, error) {
+	var r row
+	parts := element.find(func(c *node) bool {
+		return c.hasClass("vr-hlavicka") || c.hasClass("vr-child") || c.hasClass("zapsano") || c.hasClass("vymazano") || c.tag == "span"
+	})
+	for _, part := range parts {
+		var err error
+		switch {
+		case part.hasClass("vr-hlavicka"):
+			r.label = strings.TrimSuffix(part.textContent(), ":")
+		case part.hasClass("vr-child"):
+			var children []row
+			children, err = parseRows(part)
+			r.children = append(r.children, children...)
+		case part.hasClass("zapsano"):
+			r.period.From, err = parseCzechDate(strings.TrimPrefix(part.textContent(), "zapsáno"))
+		case part.hasClass("vymazano"):
+			r.period.To, err = parseCzechDate(strings.TrimPrefix(part.textContent(), "vymazáno"))
+		default:
+			if text := part.textContent(); text != "" {
+				r.values = append(r.values, text)
+			}
+		}
+		if err != nil {
+			return row{}, &SchemaError{Path: "vypis/" + r.label, Err: err}
+		}
+	}
+	return r, nil
+}
+
+func (r row) value() string {
+	return strings.Join(r.values, ", ")
+}
+
+func parseExtract(page *node) (Extract, error) {
+	contents := page.findClass("aunp-content")
+	if len(contents) == 0 {
+		return Extract{}, &SchemaError{Path: "vypis", Err: errors.New("missing extract content")}
+	}
+	rows, err := parseRows(contents[0])
+	if err != nil {
+		return Extract{}, err
+	}
+
+	var extract Extract
+	for _, r := range rows {
+		path := "vypis/" + r.label
+		if !r.period.To.IsZero() && isHistorised(r.label) {
+			extract.History = append(extract.History, Record{Label: r.label, Value: r.value(), Period: r.period})
+			continue
+		}
+		switch {
+		case r.label == "Datum vzniku a zápisu":
+			extract.RegisteredOn, err = parseCzechDate(r.value())
+		case r.label == "Spisová značka":
+			extract.FileNumber = r.value()
+		case r.label == "Obchodní firma":
+			extract.Name = r.value()
+		case r.label == "Sídlo":
+			extract.Address = r.value()
+		case r.label == "Právní forma":
+			extract.LegalForm = r.value()
+		case r.label == "Základní kapitál":
+			extract.RegisteredCapital = r.value()
+		case r.label == "Identifikační číslo":
+			extract.Ico, err = types.CreateIco(strings.ReplaceAll(r.value(), " ", ""))
+		case strings.HasPrefix(r.label, "Statutární orgán"):
+			var body StatutoryBody
+			body, err = parseStatutoryBody(r)
+			extract.StatutoryBodies = append(extract.StatutoryBodies, body)
+		case r.label == "Společníci" || r.label == "Akcionáři":
+			for _, child := range r.children {
+				var shareholder Shareholder
+				shareholder, err = parseShareholder(child)
+				if err != nil {
+					break
+				}
+				extract.Shareholders = append(extract.Shareholders, shareholder)
+			}
+		case r.label == "Jediný společník" || r.label == "Jediný akcionář":
+			var shareholder Shareholder
+			shareholder, err = parseShareholder(r)
+			extract.Shareholders = append(extract.Shareholders, shareholder)
+		}
+		if err != nil {
+			var schemaErr *SchemaError
+			if errors.As(err, &schemaErr) {
+				return Extract{}, err
+			}
+			return Extract{}, &SchemaError{Path: path, Err: err}
+		}
+	}
+	return extract, nil
+}
+
+// isHistorised checks whether deleted values of the row are kept in Extract.History
+func isHistorised(label string) bool {
+	switch label {
+	case "Obchodní firma", "Sídlo", "Právní forma", "Základní kapitál":
+		return true
+	}
+	return false
+}
+
+func parseStatutoryBody(r row) (StatutoryBody, error) {
+	body := StatutoryBody{Name: r.label}
+	if _, name, ok := strings.Cut(r.label, " - "); ok {
+		body.Name = name
+	}
+	for _, child := range r.children {
+		if child.label == "Způsob jednání" {
+			body.MannerOfActing = child.value()
+			continue
+		}
+		person, period, err := parsePersonRow(child)
+		if err != nil {
+			return StatutoryBody{}, err
+		}
+		body.Members = append(body.Members, Member{Function: strings.ToLower(child.label), Person: person, Period: period})
+	}
+	return body, nil
+}
+
+func parseShareholder(r row) (Shareholder, error) {
+	person, period, err := parsePersonRow(r)
+	if err != nil {
+		return Shareholder{}, err
+	}
+	shareholder := Shareholder{Person: person, Period: period}
+	for _, child := range r.children {
+		if child.label == "Podíl" {
+			shareholder.Share = strings.Join(child.values, ", ")
+		}
+	}
+	return shareholder, nil
+}
+
+// parsePersonRow parses person from the first value of the row, the following values are address
+// and dates of the function which take precedence over dates of the record
+func parsePersonRow(r row) (Person, Period, error) {
+	path := "vypis/" + r.label
+	if len(r.values) == 0 {
+		return Person{}, Period{}, &SchemaError{Path: path, Err: errors.New("missing person")}
+	}
+	person, err := parsePerson(r.values[0])
+	if err != nil {
+		return Person{}, Period{}, &SchemaError{Path: path, Err: err}
+	}
+	period := r.period
+	for _, value := range r.values[1:] {
+		label, date, found := strings.Cut(value, ":")
+		if !found || !strings.HasPrefix(label, "Den ") {
+			person.Address = value
+			continue
+		}
+		parsed, err := parseCzechDate(date)
+		if err != nil {
+			return Person{}, Period{}, &SchemaError{Path: path + "/" + label, Err: err}
+		}
+		switch label {
+		case "Den vzniku funkce", "Den vzniku členství":
+			period.From = parsed
+		case "Den zániku funkce", "Den zániku členství":
+			period.To = parsed
+		}
+	}
+	return person, period, nil
+}
+
+var titleCase = cases.Title(language.Czech)
+
+// parsePerson parses persons stated like "Ing. JAN NOVÁK, Ph.D., dat. nar. 1. června 1980", see names.Parse
+// for how the name is split, and legal entities stated like "NOVÁK s.r.o., IČ: 018 95 541"
+func parsePerson(value string) (Person, error) {
+	if name, ico, ok := strings.Cut(value, ", IČ:"); ok {
+		parsed, err := types.CreateIco(strings.ReplaceAll(ico, " ", ""))
+		if err != nil {
+			return Person{}, err
+		}
+		return Person{FullName: name, EntityName: name, EntityIco: parsed}, nil
+	}
+	name, birthDate, ok := strings.Cut(value, ", dat. nar.")
+	if !ok {
+		return Person{FullName: value, EntityName: value}, nil
+	}
+	parsed, err := parseCzechDate(birthDate)
+	if err != nil {
+		return Person{}, err
+	}
+	parsedName := names.Parse(name)
+	if parsedName.Surname == "" {
+		return Person{}, fmt.Errorf("missing name in %q", value)
+	}
+	person := Person{
+		FullName:        name,
+		FirstName:       titleCase.String(parsedName.FirstName),
+		LastName:        titleCase.String(parsedName.Surname),
+		TitleBeforeName: parsedName.TitleBeforeName,
+		TitleAfterName:  parsedName.TitleAfterName,
+		BirthDate:       parsed,
+	}
+	return person, nil
+}
@@ -0,0 +1,106 @@
+package justice
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// node is an element or text of parsed HTML page
+type node struct {
+	tag      string
+	attrs    map[string]string
+	text     string
+	children []*node
+}
+
+var scriptPattern = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)>`)
+
+// parseHTML parses the page with lenient XML decoder, scripts and styles are dropped
+// as they may contain characters the decoder does not accept
+func parseHTML(r io.Reader) (*node, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content = scriptPattern.ReplaceAll(content, nil)
+
+	decoder := xml.NewDecoder(strings.NewReader(string(content)))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	root := &node{tag: "#document"}
+	stack := []*node{root}
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &node{tag: strings.ToLower(t.Name.Local), attrs: make(map[string]string, len(t.Attr))}
+			for _, attr := range t.Attr {
+				element.attrs[strings.ToLower(attr.Name.Local)] = attr.Value
+			}
+			parent.children = append(parent.children, element)
+			stack = append(stack, element)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.children = append(parent.children, &node{text: string(t)})
+		}
+	}
+	return root, nil
+}
+
+func (n *node) hasClass(class string) bool {
+	return n.tag != "" && strings.Contains(" "+n.attrs["class"]+" ", " "+class+" ")
+}
+
+// find returns descendants matching the predicate, descendants of a match are not searched
+func (n *node) find(match func(*node) bool) []*node {
+	var result []*node
+	for _, child := range n.children {
+		if match(child) {
+			result = append(result, child)
+			continue
+		}
+		result = append(result, child.find(match)...)
+	}
+	return result
+}
+
+func (n *node) findClass(class string) []*node {
+	return n.find(func(c *node) bool { return c.hasClass(class) })
+}
+
+func (n *node) findTag(tag string) []*node {
+	return n.find(func(c *node) bool { return c.tag == tag })
+}
+
+// textContent returns text of the node and its descendants with whitespace collapsed
+func (n *node) textContent() string {
+	var b strings.Builder
+	var collect func(*node)
+	collect = func(n *node) {
+		if n.tag == "" {
+			b.WriteString(n.text)
+			b.WriteString(" ")
+			return
+		}
+		for _, child := range n.children {
+			collect(child)
+		}
+	}
+	collect(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,214 @@
+// Package justice searches the Public Register (obchodní rejstřík) kept by courts of the Czech Republic
+// and published at https://or.justice.cz. The register has no API, search results and extracts (výpis)
+// are parsed from its web pages.
+package justice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/registry"
+	"github.com/fstaffa/czsnoop/internal/types"
+)
+
+const defaultBaseUrl = "https://or.justice.cz"
+
+const (
+	searchCompanyPath = "/ias/ui/rejstrik-$firma"
+	searchPersonPath  = "/ias/ui/rejstrik-$osoba"
+	extractPath       = "/ias/ui/vypis-vypis"
+)
+
+type Justice struct {
+	baseUrl string
+	client  http.Client
+	logger  *slog.Logger
+	context context.Context
+	cache   registry.ResponseCache
+}
+
+type clientOptions struct {
+	baseUrl           string
+	transport         http.RoundTripper
+	concurrency       int
+	requestsPerSecond float64
+	retry             registry.RetryPolicy
+	cache             registry.Cache
+}
+
+type Option func(*clientOptions)
+
+// WithBaseUrl points the client to a different register instance, e.g. local fake server in tests
+func WithBaseUrl(baseUrl string) Option {
+	return func(o *clientOptions) {
+		o.baseUrl = strings.TrimSuffix(baseUrl, "/")
+	}
+}
+
+// WithTransport replaces the default HTTP transport of the client
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *clientOptions) {
+		o.transport = transport
+	}
+}
+
+// WithConcurrency limits number of requests in flight, request is in flight until its response body is closed
+func WithConcurrency(concurrency int) Option {
+	return func(o *clientOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+// WithRateLimit limits number of requests started per second using token bucket, 0 means unlimited
+func WithRateLimit(requestsPerSecond float64) Option {
+	return func(o *clientOptions) {
+		o.requestsPerSecond = requestsPerSecond
+	}
+}
+
+// WithRetry sets how many times are failed requests retried and the initial backoff delay,
+// the delay doubles with each retry up to 30 seconds
+func WithRetry(maxRetries int, baseDelay time.Duration) Option {
+	return func(o *clientOptions) {
+		o.retry.MaxRetries = maxRetries
+		o.retry.BaseDelay = baseDelay
+	}
+}
+
+func CreateClient(ctx context.Context, logger *slog.Logger, options ...Option) *Justice {
+	opts := clientOptions{baseUrl: defaultBaseUrl, transport: http.DefaultTransport, retry: registry.DefaultRetryPolicy}
+	for _, option := range options {
+		option(&opts)
+	}
+	transport := registry.NewRetryingTransport(registry.NewLimitedTransport(opts.transport, opts.concurrency, opts.requestsPerSecond), opts.retry, logger)
+	return &Justice{
+		baseUrl: opts.baseUrl,
+		client:  http.Client{Timeout: 60 * time.Second, Transport: transport},
+		logger:  logger,
+		context: ctx,
+		cache:   registry.ResponseCache{Cache: opts.cache, Logger: logger},
+	}
+}
+
+// SubjectId identifies subject in the register, it is stable unlike the ICO which some subjects do not have
+type SubjectId string
+
+// SearchResult is a subject found in the register
+type SearchResult struct {
+	SubjectId SubjectId
+	Name      string
+	Ico       types.Ico
+	// FileNumber is the file number (spisová značka) together with the registering court
+	FileNumber   string
+	Address      string
+	RegisteredOn time.Time
+}
+
+type SearchPersonQuery struct {
+	FirstName   string
+	Surname     string
+	DateOfBirth time.Time
+	// IncludeHistorical finds also subjects where the person no longer has any role
+	IncludeHistorical bool
+}
+
+// SearchPerson finds subjects in which a person with given name is or was registered
+func (j *Justice) SearchPerson(query SearchPersonQuery) ([]SearchResult, error) {
+	q := url.Values{}
+	q.Add("prijmeni", query.Surname)
+	if query.FirstName != "" {
+		q.Add("jmeno", query.FirstName)
+	}
+	if !query.DateOfBirth.IsZero() {
+		q.Add("narozeni", query.DateOfBirth.Format("2.1.2006"))
+	}
+	if query.IncludeHistorical {
+		q.Add("historicke", "true")
+	}
+	return j.search(searchPersonPath, CacheEndpointPersons, q)
+}
+
+// SearchIco finds subject with given ICO
+func (j *Justice) SearchIco(ico types.Ico) ([]SearchResult, error) {
+	return j.search(searchCompanyPath, CacheEndpointCompanies, url.Values{"ico": {string(ico)}})
+}
+
+// maxSearchPages limits how many pages of search results are read, the register shows 50 results per page
+const maxSearchPages = 10
+
+// search reads all pages of search results following links to next page
+func (j *Justice) search(path string, endpoint string, q url.Values) ([]SearchResult, error) {
+	var results []SearchResult
+	if j.cache.Get(j.context, endpoint, q.Encode(), &results) {
+		return results, nil
+	}
+	pageQuery := q
+	for pages := 0; pageQuery != nil; pages++ {
+		if pages == maxSearchPages {
+			return nil, fmt.Errorf("search found more than %d pages of results: %w", maxSearchPages, ErrTooManyMatches)
+		}
+		page, err := j.get(path, pageQuery)
+		if err != nil {
+			return nil, err
+		}
+		pageResults, err := parseSearchResults(page)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, pageResults...)
+		pageQuery = parseNextPage(page)
+	}
+	j.logger.DebugContext(j.context, "Search result", slog.String("path", path), slog.Int("count", len(results)))
+	j.cache.Put(j.context, endpoint, q.Encode(), results)
+	return results, nil
+}
+
+// GetExtract returns extract of the subject, full extract includes deleted records which are otherwise omitted
+func (j *Justice) GetExtract(id SubjectId, full bool) (Extract, error) {
+	extractType := "PLATNY"
+	if full {
+		extractType = "UPLNY"
+	}
+	q := url.Values{"subjektId": {string(id)}, "typ": {extractType}}
+	var extract Extract
+	if j.cache.Get(j.context, CacheEndpointExtracts, q.Encode(), &extract) {
+		return extract, nil
+	}
+	page, err := j.get(extractPath, q)
+	if err != nil {
+		return Extract{}, err
+	}
+	extract, err = parseExtract(page)
+	if err != nil {
+		return Extract{}, err
+	}
+	j.cache.Put(j.context, CacheEndpointExtracts, q.Encode(), extract)
+	return extract, nil
+}
+
+func (j *Justice) get(path string, q url.Values) (*node, error) {
+	req, err := http.NewRequestWithContext(j.context, http.MethodGet, j.baseUrl+path+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("Accept-Language", "cs")
+	j.logger.DebugContext(j.context, "Requesting commercial register", slog.String("url", req.URL.String()))
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to do request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, registry.NewHTTPStatusError(resp)
+	}
+	page, err := parseHTML(resp.Body)
+	if err != nil {
+		return nil, &SchemaError{Path: path, Err: err}
+	}
+	return page, nil
+}
@@ -0,0 +1,288 @@
+package justice
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/justice/justicetest"
+	"github.com/fstaffa/czsnoop/internal/types"
+)
+
+func createTestClient(t *testing.T, fixtures []justicetest.Fixture) *Justice {
+	t.Helper()
+	server := justicetest.NewServerWithFixtures(t, fixtures)
+	return CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL))
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func Test_SearchIco(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		ico               types.Ico
+		expectedSubjectId SubjectId
+		expectedCount     int
+	}{
+		"registered company": {ico: "01895541", expectedSubjectId: "801337", expectedCount: 1},
+		"not registered":     {ico: "73452301", expectedCount: 0},
+	}
+	client := createTestClient(t, justicetest.Fixtures)
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			results, err := client.SearchIco(test.ico)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if len(results) != test.expectedCount {
+				t.Fatalf("Expected %d results, got %d", test.expectedCount, len(results))
+			}
+			if test.expectedCount == 0 {
+				return
+			}
+			result := results[0]
+			if result.SubjectId != test.expectedSubjectId || result.Ico != test.ico {
+				t.Errorf("Expected subject %s with ICO %s, got %+v", test.expectedSubjectId, test.ico, result)
+			}
+			if !result.RegisteredOn.Equal(date(2013, time.March, 28)) {
+				t.Errorf("Expected registration on 2013-03-28, got %v", result.RegisteredOn)
+			}
+		})
+	}
+}
+
+func Test_SearchPerson(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		includeHistorical bool
+		expectedCount     int
+	}{
+		"only current":    {includeHistorical: false, expectedCount: 1},
+		"with historical": {includeHistorical: true, expectedCount: 2},
+	}
+	client := createTestClient(t, justicetest.Fixtures)
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			results, err := client.SearchPerson(SearchPersonQuery{FirstName: "Jan", Surname: "Novák", IncludeHistorical: test.includeHistorical})
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if len(results) != test.expectedCount {
+				t.Fatalf("Expected %d results, got %d", test.expectedCount, len(results))
+			}
+			if results[0].Name != "NOVÁK & PARTNEŘI a.s." {
+				t.Errorf("Expected NOVÁK & PARTNEŘI a.s., got %s", results[0].Name)
+			}
+		})
+	}
+}
+
+func Test_SearchPerson_FollowsPages(t *testing.T) {
+	t.Parallel()
+	client := createTestClient(t, justicetest.Fixtures)
+
+	results, err := client.SearchPerson(SearchPersonQuery{FirstName: "Petr", Surname: "Svoboda"})
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results from both pages, got %d", len(results))
+	}
+	if results[0].SubjectId != "620530" || results[1].SubjectId != "712004" {
+		t.Errorf("Expected subjects 620530 and 712004 in page order, got %+v", results)
+	}
+}
+
+func Test_SearchPerson_TooManyPages(t *testing.T) {
+	t.Parallel()
+	// second page links to itself, as if the search matched more subjects than the client reads
+	fixtures := slices.Clone(justicetest.Fixtures)
+	for i, fixture := range fixtures {
+		if fixture.File == "search_osoba_petr_svoboda_2.html" {
+			fixtures[i].File = "search_osoba_petr_svoboda.html"
+		}
+	}
+	client := createTestClient(t, fixtures)
+
+	_, err := client.SearchPerson(SearchPersonQuery{FirstName: "Petr", Surname: "Svoboda"})
+	if !errors.Is(err, ErrTooManyMatches) {
+		t.Errorf("Expected ErrTooManyMatches, got %v", err)
+	}
+}
+
+func Test_GetExtract(t *testing.T) {
+	t.Parallel()
+	client := createTestClient(t, justicetest.Fixtures)
+
+	extract, err := client.GetExtract("620530", false)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if extract.Name != "NOVÁK & PARTNEŘI a.s." || extract.Ico != "45678910" || extract.LegalForm != "Akciová společnost" {
+		t.Errorf("Expected NOVÁK & PARTNEŘI a.s. with ICO 45678910, got %+v", extract)
+	}
+	if extract.FileNumber != "B 4521 vedená u Městského soudu v Praze" {
+		t.Errorf("Expected file number, got %s", extract.FileNumber)
+	}
+	if extract.RegisteredCapital != "2 000 000 Kč, Splaceno: 100%" {
+		t.Errorf("Expected registered capital, got %s", extract.RegisteredCapital)
+	}
+	if len(extract.History) != 0 {
+		t.Errorf("Expected no history in extract of valid records, got %v", extract.History)
+	}
+	if len(extract.StatutoryBodies) != 1 {
+		t.Fatalf("Expected 1 statutory body, got %d", len(extract.StatutoryBodies))
+	}
+	body := extract.StatutoryBodies[0]
+	if body.Name != "představenstvo" || body.MannerOfActing == "" || len(body.Members) != 2 {
+		t.Fatalf("Expected board with 2 members, got %+v", body)
+	}
+	chairman := body.Members[0]
+	if chairman.Function != "předseda představenstva" || chairman.FirstName != "Jan" || chairman.LastName != "Novák" || chairman.TitleBeforeName != "Ing." {
+		t.Errorf("Expected Ing. Jan Novák as chairman, got %+v", chairman)
+	}
+	if !chairman.BirthDate.Equal(date(1980, time.June, 1)) || !chairman.From.Equal(date(2015, time.January, 1)) {
+		t.Errorf("Expected chairman born 1980-06-01 since 2015-01-01, got %+v", chairman)
+	}
+	if entity := body.Members[1]; entity.EntityName != "THOMAS SILVERTONNI s.r.o." || entity.EntityIco != "01895541" {
+		t.Errorf("Expected legal entity member, got %+v", entity)
+	}
+	if len(extract.Shareholders) != 1 || extract.Shareholders[0].LastName != "Novák" {
+		t.Errorf("Expected sole shareholder Novák, got %+v", extract.Shareholders)
+	}
+}
+
+func Test_GetExtract_Full(t *testing.T) {
+	t.Parallel()
+	client := createTestClient(t, justicetest.Fixtures)
+
+	extract, err := client.GetExtract("712004", true)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	members := extract.StatutoryBodies[0].Members
+	if len(members) != 2 {
+		t.Fatalf("Expected former and current jednatel, got %+v", members)
+	}
+	if former := members[0]; former.LastName != "Novák" || !former.To.Equal(date(2012, time.June, 30)) {
+		t.Errorf("Expected Novák as jednatel until 2012-06-30, got %+v", former)
+	}
+	if len(extract.Shareholders) != 2 || extract.Shareholders[0].Share != "Vklad: 200 000 Kč, Splaceno: 100%, Obchodní podíl: 100%" {
+		t.Errorf("Expected 2 shareholders with shares, got %+v", extract.Shareholders)
+	}
+	if !extract.Shareholders[0].To.Equal(date(2012, time.July, 2)) {
+		t.Errorf("Expected former shareholder deleted on 2012-07-02, got %v", extract.Shareholders[0].To)
+	}
+
+	extract, err = client.GetExtract("801337", true)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if len(extract.History) != 1 || extract.History[0].Label != "Sídlo" || !extract.History[0].To.Equal(date(2016, time.July, 1)) {
+		t.Errorf("Expected former seat in history, got %+v", extract.History)
+	}
+	if extract.Address != "Mazovská 479/8, Troja, 181 00 Praha 8" {
+		t.Errorf("Expected current seat, got %s", extract.Address)
+	}
+}
+
+func Test_GetExtract_NotFound(t *testing.T) {
+	t.Parallel()
+	client := createTestClient(t, justicetest.Without("vypis_620530_platny.html"))
+
+	_, err := client.GetExtract("620530", false)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func Test_parseCzechDate(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		value       string
+		expected    time.Time
+		expectError bool
+	}{
+		"genitive month": {value: "1. června 1980", expected: date(1980, time.June, 1)},
+		"numeric":        {value: "2. 7. 2012", expected: date(2012, time.July, 2)},
+		"blank":          {value: " "},
+		"unknown month":  {value: "1. juna 1980", expectError: true},
+		"invalid day":    {value: "31. února 2020", expectError: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			parsed, err := parseCzechDate(test.value)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("Expected error for %q, got %v", test.value, parsed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if !parsed.Equal(test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, parsed)
+			}
+		})
+	}
+}
+
+func Test_parsePerson(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		value    string
+		expected Person
+	}{
+		"titles": {
+			value: "Ing. JAN NOVÁK, Ph.D., dat. nar. 1. června 1980",
+			expected: Person{FullName: "Ing. JAN NOVÁK, Ph.D.", FirstName: "Jan", LastName: "Novák", TitleBeforeName: "Ing.",
+				TitleAfterName: "Ph.D.", BirthDate: date(1980, time.June, 1)},
+		},
+		"several first names": {
+			value:    "JAN PETR NOVÁK, dat. nar. 14. března 1975",
+			expected: Person{FullName: "JAN PETR NOVÁK", FirstName: "Jan Petr", LastName: "Novák", BirthDate: date(1975, time.March, 14)},
+		},
+		"double female surname": {
+			value:    "JANA NOVÁKOVÁ SVOBODOVÁ, dat. nar. 2. června 1980",
+			expected: Person{FullName: "JANA NOVÁKOVÁ SVOBODOVÁ", FirstName: "Jana", LastName: "Nováková Svobodová", BirthDate: date(1980, time.June, 2)},
+		},
+		"surname first": {
+			value:    "NOVÁKOVÁ JANA, dat. nar. 2. června 1980",
+			expected: Person{FullName: "NOVÁKOVÁ JANA", FirstName: "Jana", LastName: "Nováková", BirthDate: date(1980, time.June, 2)},
+		},
+		"several titles": {
+			value: "doc. Ing. JAN NOVÁK, CSc., MBA, dat. nar. 1. června 1980",
+			expected: Person{FullName: "doc. Ing. JAN NOVÁK, CSc., MBA", FirstName: "Jan", LastName: "Novák", TitleBeforeName: "doc. Ing.",
+				TitleAfterName: "CSc. MBA", BirthDate: date(1980, time.June, 1)},
+		},
+		"legal entity": {
+			value:    "THOMAS SILVERTONNI s.r.o., IČ: 018 95 541",
+			expected: Person{FullName: "THOMAS SILVERTONNI s.r.o.", EntityName: "THOMAS SILVERTONNI s.r.o.", EntityIco: "01895541"},
+		},
+		"foreign legal entity": {
+			value:    "Silvertonni Holding GmbH",
+			expected: Person{FullName: "Silvertonni Holding GmbH", EntityName: "Silvertonni Holding GmbH"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			person, err := parsePerson(test.value)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if person != test.expected {
+				t.Errorf("Expected %+v, got %+v", test.expected, person)
+			}
+		})
+	}
+}
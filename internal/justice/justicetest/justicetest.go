@@ -0,0 +1,124 @@
+// Package justicetest provides a local stand-in for or.justice.cz serving recorded pages,
+// so that the commercial register client and everything built on top of it can be tested without network.
+package justicetest
+
+import (
+	"embed"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"slices"
+	"testing"
+
+	"github.com/fstaffa/czsnoop/internal/cassette"
+)
+
+//go:embed testdata
+var testdata embed.FS
+
+const htmlContentType = "text/html;charset=UTF-8"
+
+// Fixture maps a request to a recorded page in testdata
+type Fixture struct {
+	Path string
+	// Query is matched after normalization, see cassette.NormalizeQuery
+	Query string
+	File  string
+}
+
+const (
+	searchCompanyPath = "/ias/ui/rejstrik-$firma"
+	searchPersonPath  = "/ias/ui/rejstrik-$osoba"
+	extractPath       = "/ias/ui/vypis-vypis"
+)
+
+// emptySearch is served for searches without recorded fixture, the register answers them with empty result
+const emptySearch = "search_empty.html"
+
+func extractFixtures(subjectId string) []Fixture {
+	return []Fixture{
+		{Path: extractPath, Query: "subjektId=" + subjectId + "&typ=PLATNY", File: "vypis_" + subjectId + "_platny.html"},
+		{Path: extractPath, Query: "subjektId=" + subjectId + "&typ=UPLNY", File: "vypis_" + subjectId + "_uplny.html"},
+	}
+}
+
+// Fixtures are the recorded pages served by NewServer
+var Fixtures = slices.Concat([]Fixture{
+	{Path: searchCompanyPath, Query: "ico=45678910", File: "search_ico_45678910.html"},
+	{Path: searchCompanyPath, Query: "ico=01895541", File: "search_ico_01895541.html"},
+	{Path: searchCompanyPath, Query: "ico=24681351", File: "search_ico_24681351.html"},
+	{Path: searchPersonPath, Query: "jmeno=Jan&prijmeni=Novák", File: "search_osoba_jan_novak.html"},
+	{Path: searchPersonPath, Query: "historicke=true&jmeno=Jan&prijmeni=Novák", File: "search_osoba_jan_novak_historicke.html"},
+	{Path: searchPersonPath, Query: "jmeno=Petr&prijmeni=Svoboda", File: "search_osoba_petr_svoboda.html"},
+	{Path: searchPersonPath, Query: "jmeno=Petr&prijmeni=Svoboda&stranka=1", File: "search_osoba_petr_svoboda_2.html"},
+},
+	extractFixtures("620530"),
+	extractFixtures("801337"),
+	extractFixtures("712004"),
+)
+
+// NewServer starts fake register serving Fixtures, the server is closed when the test finishes
+func NewServer(t testing.TB) *httptest.Server {
+	t.Helper()
+	return NewServerWithFixtures(t, Fixtures)
+}
+
+// NewServerWithFixtures starts fake register serving only given fixtures, the server is closed when the test finishes
+func NewServerWithFixtures(t testing.TB, fixtures []Fixture) *httptest.Server {
+	t.Helper()
+	handler, err := NewHandler(fixtures)
+	if err != nil {
+		t.Fatalf("Unable to create fake commercial register: %v", err)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Without returns Fixtures except those served from given files
+func Without(files ...string) []Fixture {
+	result := make([]Fixture, 0, len(Fixtures))
+	for _, fixture := range Fixtures {
+		if !slices.Contains(files, fixture.File) {
+			result = append(result, fixture)
+		}
+	}
+	return result
+}
+
+// NewHandler creates handler of fake register serving given fixtures, searches without
+// a fixture find nothing and other pages without a fixture are not found
+func NewHandler(fixtures []Fixture) (http.Handler, error) {
+	index := make(map[string]Fixture, len(fixtures))
+	for _, fixture := range fixtures {
+		query, err := url.ParseQuery(fixture.Query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query in fixture %s: %v", fixture.File, err)
+		}
+		index[fixture.Path+"?"+cassette.NormalizeQuery(query)] = fixture
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		fixture, ok := index[r.URL.Path+"?"+cassette.NormalizeQuery(r.URL.Query())]
+		if !ok && (r.URL.Path == searchCompanyPath || r.URL.Path == searchPersonPath) {
+			fixture, ok = Fixture{File: emptySearch}, true
+		}
+		if !ok {
+			http.Error(w, "no fixture recorded for "+r.URL.String(), http.StatusNotFound)
+			return
+		}
+		content, err := testdata.ReadFile(path.Join("testdata", fixture.File))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", htmlContentType)
+		_, _ = w.Write(content)
+	}), nil
+}
@@ -0,0 +1,210 @@
+// Package names compares Czech personal names, which registries and users write with or without diacritics,
+// in capitals and with female forms of surnames.
+package names
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Threshold is the lowest Score at which a name is considered to match the query
+const Threshold = 0.88
+
+// Weights of surname and first names in Score when the query states first names
+const (
+	surnameWeight   = 0.7
+	firstNameWeight = 0.3
+)
+
+// Fold removes diacritics, "Jiří Novák" becomes "Jiri Novak"
+func Fold(s string) string {
+	result, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s)
+	if err != nil {
+		return s
+	}
+	return result
+}
+
+// Normalize makes names comparable regardless of case, diacritics and spacing
+func Normalize(name string) string {
+	return strings.Join(strings.Fields(Fold(strings.ToLower(name))), " ")
+}
+
+// Equal compares names ignoring case, diacritics and spacing
+func Equal(a string, b string) bool {
+	return Normalize(a) == Normalize(b)
+}
+
+// SurnameKey normalizes the surname and strips the ending which differs between its female and male forms,
+// Nováková and Novák give "novak", Svobodová and Svoboda give "svobod", Černá and Černý give "cern".
+// Each part of double surnames is stripped separately.
+func SurnameKey(surname string) string {
+	parts := strings.FieldsFunc(Normalize(surname), func(r rune) bool { return r == ' ' || r == '-' })
+	for i, part := range parts {
+		switch {
+		case len(part) > 4 && strings.HasSuffix(part, "ova"):
+			parts[i] = strings.TrimSuffix(part, "ova")
+		case len(part) > 2 && (strings.HasSuffix(part, "a") || strings.HasSuffix(part, "y")):
+			parts[i] = part[:len(part)-1]
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// SurnameVariants returns other forms under which registries may know the surname, its female or male forms
+// and the forms without diacritics, e.g. Nováková, Novak and Novakova for Novák. Female surnames ending with
+// -ová give both possible male forms, Svobodová gives Svobod and Svoboda. Surnames of more words are varied
+// only by diacritics.
+func SurnameVariants(surname string) []string {
+	surname = strings.Join(strings.Fields(surname), " ")
+	forms := []string{surname}
+	if surname != "" && !strings.ContainsAny(surname, " -") {
+		forms = append(forms, genderForms(surname)...)
+	}
+	seen := map[string]bool{surname: true}
+	var variants []string
+	for _, form := range forms {
+		for _, variant := range []string{form, Fold(form)} {
+			if !seen[variant] {
+				seen[variant] = true
+				variants = append(variants, variant)
+			}
+		}
+	}
+	return variants
+}
+
+// genderForms returns female forms of male surnames and male forms of female surnames, written with diacritics
+// only if the surname has them
+func genderForms(surname string) []string {
+	plain := Fold(surname) == surname
+	female, adjectiveMale, adjectiveFemale := "ová", "ý", "á"
+	if plain {
+		female, adjectiveMale, adjectiveFemale = "ova", "y", "a"
+	}
+	lower := strings.ToLower(surname)
+	switch {
+	case strings.HasSuffix(lower, female) && utf8.RuneCountInString(surname) > 4:
+		base := surname[:len(surname)-len(female)]
+		return []string{base, base + "a"}
+	case !plain && strings.HasSuffix(lower, adjectiveFemale):
+		// without diacritics the ending is the same as of male surnames like Svoboda
+		return []string{surname[:len(surname)-len(adjectiveFemale)] + adjectiveMale}
+	case strings.HasSuffix(lower, adjectiveMale):
+		return []string{surname[:len(surname)-len(adjectiveMale)] + adjectiveFemale}
+	case strings.HasSuffix(lower, "a"):
+		return []string{surname[:len(surname)-1] + female}
+	}
+	return []string{surname + female}
+}
+
+// Score rates how well the name matches the queried one between 0 and 1. Surnames are compared by their
+// SurnameKey so that female and male forms match, first names count only when the query states them and
+// each of the queried first names is compared with the most similar first name of the person.
+func Score(queryFirstName string, querySurname string, firstName string, surname string) float64 {
+	surnameScore := Similarity(SurnameKey(querySurname), SurnameKey(surname))
+	queried := strings.Fields(Normalize(queryFirstName))
+	if len(queried) == 0 {
+		return surnameScore
+	}
+	known := strings.Fields(Normalize(firstName))
+	var firstNameScore float64
+	for _, query := range queried {
+		best := 0.0
+		for _, name := range known {
+			best = max(best, Similarity(query, name))
+		}
+		firstNameScore += best / float64(len(queried))
+	}
+	return surnameWeight*surnameScore + firstNameWeight*firstNameScore
+}
+
+// Similarity of strings between 0 and 1, the higher of Jaro-Winkler similarity, which tolerates transposed
+// letters and favours common prefixes, and Levenshtein distance relative to the longer string, which tolerates
+// typos at the start of the name
+func Similarity(a string, b string) float64 {
+	longer := max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	if longer == 0 {
+		return 1
+	}
+	return max(JaroWinkler(a, b), 1-float64(Levenshtein(a, b))/float64(longer))
+}
+
+// JaroWinkler similarity of strings between 0 and 1, 1 for equal strings
+func JaroWinkler(a string, b string) float64 {
+	jaro := Jaro(a, b)
+	prefix := 0
+	for ra, rb := []rune(a), []rune(b); prefix < min(len(ra), len(rb), 4) && ra[prefix] == rb[prefix]; prefix++ {
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// Jaro similarity of strings between 0 and 1, 1 for equal strings
+func Jaro(a string, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	window := max(max(len(ra), len(rb))/2-1, 0)
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		for j := max(0, i-window); j < min(len(rb), i+window+1); j++ {
+			if !matchedB[j] && ra[i] == rb[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+	transpositions := 0
+	j := 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	return (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+}
+
+// Levenshtein is the number of inserted, deleted or substituted characters turning a into b
+func Levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := range ra {
+		current[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+			current[j+1] = min(previous[j+1]+1, current[j]+1, previous[j]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
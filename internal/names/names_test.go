@@ -0,0 +1,155 @@
+package names
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func Test_Normalize(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		name     string
+		expected string
+	}{
+		"diacritics": {name: "Jiří Novák", expected: "jiri novak"},
+		"capitals":   {name: "JIŘÍ  NOVÁK", expected: "jiri novak"},
+		"spacing":    {name: " Jiří\tNovák ", expected: "jiri novak"},
+		"empty":      {name: "", expected: ""},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if normalized := Normalize(test.name); normalized != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, normalized)
+			}
+		})
+	}
+}
+
+func Test_SurnameKey(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		male   string
+		female string
+	}{
+		"consonant":      {male: "Novák", female: "Nováková"},
+		"vowel":          {male: "Svoboda", female: "Svobodová"},
+		"adjective":      {male: "Černý", female: "Černá"},
+		"double surname": {male: "Novák Svoboda", female: "Nováková-Svobodová"},
+		"no diacritics":  {male: "NOVAK", female: "Novakova"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if male, female := SurnameKey(test.male), SurnameKey(test.female); male != female {
+				t.Errorf("Expected the same key, got %q and %q", male, female)
+			}
+		})
+	}
+}
+
+func Test_SurnameVariants(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		surname  string
+		expected []string
+	}{
+		"consonant":         {surname: "Novák", expected: []string{"Novak", "Nováková", "Novakova"}},
+		"vowel":             {surname: "Kučera", expected: []string{"Kucera", "Kučerová", "Kucerova"}},
+		"female":            {surname: "Svobodová", expected: []string{"Svobodova", "Svobod", "Svoboda"}},
+		"adjective":         {surname: "Černý", expected: []string{"Cerny", "Černá", "Cerna"}},
+		"female adjective":  {surname: "Černá", expected: []string{"Cerna", "Černý", "Cerny"}},
+		"no diacritics":     {surname: "Novak", expected: []string{"Novakova"}},
+		"double surname":    {surname: "Nováková-Svobodová", expected: []string{"Novakova-Svobodova"}},
+		"empty":             {surname: "", expected: nil},
+		"surrounding space": {surname: " Novak ", expected: []string{"Novakova"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if actual := SurnameVariants(test.surname); !slices.Equal(actual, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_JaroWinkler(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		a        string
+		b        string
+		expected float64
+	}{
+		"equal":       {a: "novak", b: "novak", expected: 1},
+		"transposed":  {a: "martha", b: "marhta", expected: 0.9611},
+		"different":   {a: "jan", b: "petr", expected: 0},
+		"both empty":  {a: "", b: "", expected: 1},
+		"one empty":   {a: "jan", b: "", expected: 0},
+		"diacritics":  {a: "jiří", b: "jiří", expected: 1},
+		"long prefix": {a: "dwayne", b: "duane", expected: 0.84},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if similarity := JaroWinkler(test.a, test.b); math.Abs(similarity-test.expected) > 0.0001 {
+				t.Errorf("Expected %v, got %v", test.expected, similarity)
+			}
+		})
+	}
+}
+
+func Test_Levenshtein(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		a        string
+		b        string
+		expected int
+	}{
+		"equal":       {a: "novak", b: "novak", expected: 0},
+		"substituted": {a: "novak", b: "nowak", expected: 1},
+		"inserted":    {a: "jan", b: "jana", expected: 1},
+		"runes":       {a: "jiří", b: "jiri", expected: 2},
+		"empty":       {a: "", b: "jan", expected: 3},
+		"kitten":      {a: "kitten", b: "sitting", expected: 3},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if distance := Levenshtein(test.a, test.b); distance != test.expected {
+				t.Errorf("Expected %d, got %d", test.expected, distance)
+			}
+		})
+	}
+}
+
+func Test_Score(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		queryFirstName string
+		querySurname   string
+		firstName      string
+		surname        string
+		expectMatch    bool
+	}{
+		"same name":                {queryFirstName: "Jan", querySurname: "Novák", firstName: "Jan", surname: "Novák", expectMatch: true},
+		"without diacritics":       {queryFirstName: "Jiri", querySurname: "Novak", firstName: "JIŘÍ", surname: "NOVÁK", expectMatch: true},
+		"female form":              {querySurname: "Novák", firstName: "Jana", surname: "Nováková", expectMatch: true},
+		"typo":                     {queryFirstName: "Jan", querySurname: "Nowák", firstName: "Jan", surname: "Novák", expectMatch: true},
+		"one of first names":       {queryFirstName: "Jan", querySurname: "Novák", firstName: "Jan Petr", surname: "Novák", expectMatch: true},
+		"different first name":     {queryFirstName: "Petr", querySurname: "Novák", firstName: "Jan", surname: "Novák"},
+		"similar surname":          {queryFirstName: "Jan", querySurname: "Novák", firstName: "Jan", surname: "Novotný"},
+		"different surname":        {querySurname: "Novák", firstName: "Jan", surname: "Dvořák"},
+		"first name not in person": {queryFirstName: "Jan", querySurname: "Novák", surname: "Novák"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			score := Score(test.queryFirstName, test.querySurname, test.firstName, test.surname)
+			if (score >= Threshold) != test.expectMatch {
+				t.Errorf("Expected match %v, got score %v", test.expectMatch, score)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package names
+
+import (
+	"slices"
+	"strings"
+)
+
+// Name is a personal name split into its parts, multiple titles are separated by spaces, e.g. "doc. Ing."
+type Name struct {
+	TitleBeforeName string
+	FirstName       string
+	Surname         string
+	TitleAfterName  string
+}
+
+// titlesBeforeName are academic titles written before the name by titleKey
+var titlesBeforeName = map[string]string{
+	"bc":      "Bc.",
+	"bca":     "BcA.",
+	"ing":     "Ing.",
+	"arch":    "arch.",
+	"mgr":     "Mgr.",
+	"mga":     "MgA.",
+	"mudr":    "MUDr.",
+	"mddr":    "MDDr.",
+	"mvdr":    "MVDr.",
+	"judr":    "JUDr.",
+	"phdr":    "PhDr.",
+	"rndr":    "RNDr.",
+	"pharmdr": "PharmDr.",
+	"paeddr":  "PaedDr.",
+	"thdr":    "ThDr.",
+	"thlic":   "ThLic.",
+	"rsdr":    "RSDr.",
+	"icdr":    "ICDr.",
+	"dr":      "Dr.",
+	"doc":     "doc.",
+	"prof":    "prof.",
+}
+
+// titlesAfterName are academic titles written after the name by titleKey
+var titlesAfterName = map[string]string{
+	"phd":  "Ph.D.",
+	"thd":  "Th.D.",
+	"artd": "ArtD.",
+	"csc":  "CSc.",
+	"drsc": "DrSc.",
+	"dis":  "DiS.",
+	"mba":  "MBA",
+	"dba":  "DBA",
+	"mpa":  "MPA",
+	"llm":  "LL.M.",
+	"msc":  "MSc.",
+}
+
+// titleKey identifies title regardless of case, dots and diacritics, "Ph.D." and "PhD" give "phd"
+func titleKey(title string) string {
+	return strings.ReplaceAll(Normalize(title), ".", "")
+}
+
+// Parse splits the name into titles, first names and surname. Titles are recognized anywhere in the name.
+// The surname is the last word unless the name is written as "Surname, First names", or female surnames
+// ending with -ová show that the surname is written first, e.g. "Nováková Jana", or consists of more words,
+// e.g. "Jana Nováková Svobodová".
+func Parse(name string) Name {
+	var before, after []string
+	var segments [][]string
+	var words []string
+	for _, token := range strings.Fields(strings.ReplaceAll(name, ",", " , ")) {
+		key := titleKey(token)
+		if title, ok := titlesBeforeName[key]; ok {
+			before = append(before, title)
+			continue
+		}
+		if title, ok := titlesAfterName[key]; ok {
+			after = append(after, title)
+			continue
+		}
+		if token == "," {
+			if len(words) > 0 {
+				segments = append(segments, words)
+			}
+			words = nil
+			continue
+		}
+		words = append(words, token)
+	}
+	if len(words) > 0 {
+		segments = append(segments, words)
+	}
+
+	parsed := Name{TitleBeforeName: strings.Join(before, " "), TitleAfterName: strings.Join(after, " ")}
+	switch {
+	case len(segments) == 0:
+		return parsed
+	case len(segments) > 1:
+		parsed.Surname = strings.Join(segments[0], " ")
+		parsed.FirstName = strings.Join(slices.Concat(segments[1:]...), " ")
+		return parsed
+	}
+	words = segments[0]
+	last := len(words) - 1
+	split := last
+	if isFemaleSurname(words[0]) && !isFemaleSurname(words[last]) {
+		// surname written first
+		split = 0
+		for split < last && isFemaleSurname(words[split+1]) {
+			split++
+		}
+		parsed.Surname = strings.Join(words[:split+1], " ")
+		parsed.FirstName = strings.Join(words[split+1:], " ")
+		return parsed
+	}
+	for split > 0 && isFemaleSurname(words[split]) && isFemaleSurname(words[split-1]) {
+		split--
+	}
+	parsed.FirstName = strings.Join(words[:split], " ")
+	parsed.Surname = strings.Join(words[split:], " ")
+	return parsed
+}
+
+func isFemaleSurname(word string) bool {
+	normalized := Normalize(word)
+	return len(normalized) > 4 && strings.HasSuffix(normalized, "ova")
+}
+
+// HasTitles checks that the person has all of the queried titles, regardless of their order and spelling.
+// Empty query matches any titles.
+func HasTitles(queried string, titles string) bool {
+	known := make(map[string]bool)
+	for _, title := range strings.Fields(titles) {
+		known[titleKey(title)] = true
+	}
+	for _, title := range strings.Fields(queried) {
+		if !known[titleKey(title)] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package names
+
+import (
+	"testing"
+)
+
+func Test_Parse(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		name     string
+		expected Name
+	}{
+		"first name and surname":  {name: "Jan Novák", expected: Name{FirstName: "Jan", Surname: "Novák"}},
+		"surname only":            {name: "Novák", expected: Name{Surname: "Novák"}},
+		"more first names":        {name: "Jan Petr Novák", expected: Name{FirstName: "Jan Petr", Surname: "Novák"}},
+		"titles":                  {name: "Ing. Jan Novák Ph.D.", expected: Name{TitleBeforeName: "Ing.", FirstName: "Jan", Surname: "Novák", TitleAfterName: "Ph.D."}},
+		"title after comma":       {name: "doc. MUDr. Jan Novák, CSc.", expected: Name{TitleBeforeName: "doc. MUDr.", FirstName: "Jan", Surname: "Novák", TitleAfterName: "CSc."}},
+		"title spelling":          {name: "ING. ARCH. Jan Novák PhD", expected: Name{TitleBeforeName: "Ing. arch.", FirstName: "Jan", Surname: "Novák", TitleAfterName: "Ph.D."}},
+		"surname with comma":      {name: "Novák, Jan", expected: Name{FirstName: "Jan", Surname: "Novák"}},
+		"double surname, comma":   {name: "Novák Svoboda, Jan Petr, Ph.D.", expected: Name{FirstName: "Jan Petr", Surname: "Novák Svoboda", TitleAfterName: "Ph.D."}},
+		"female surname first":    {name: "Nováková Jana", expected: Name{FirstName: "Jana", Surname: "Nováková"}},
+		"female double surname":   {name: "Mgr. Jana Nováková Svobodová", expected: Name{TitleBeforeName: "Mgr.", FirstName: "Jana", Surname: "Nováková Svobodová"}},
+		"double surname first":    {name: "Nováková Svobodová Jana", expected: Name{FirstName: "Jana", Surname: "Nováková Svobodová"}},
+		"hyphenated surname":      {name: "Jana Nováková-Svobodová", expected: Name{FirstName: "Jana", Surname: "Nováková-Svobodová"}},
+		"extra spaces":            {name: "  Jan   Novák ", expected: Name{FirstName: "Jan", Surname: "Novák"}},
+		"short surname with -ová": {name: "Jan Ova", expected: Name{FirstName: "Jan", Surname: "Ova"}},
+		"titles only":             {name: "Ing.", expected: Name{TitleBeforeName: "Ing."}},
+		"empty":                   {name: "", expected: Name{}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if parsed := Parse(test.name); parsed != test.expected {
+				t.Errorf("Expected %+v, got %+v", test.expected, parsed)
+			}
+		})
+	}
+}
+
+func Test_HasTitles(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		queried  string
+		titles   string
+		expected bool
+	}{
+		"no query":          {titles: "Ing.", expected: true},
+		"same title":        {queried: "Ing.", titles: "Ing.", expected: true},
+		"one of titles":     {queried: "Ing.", titles: "doc. Ing.", expected: true},
+		"another spelling":  {queried: "Ph.D.", titles: "PhD", expected: true},
+		"missing title":     {queried: "Ing.", titles: "", expected: false},
+		"different title":   {queried: "Mgr.", titles: "Ing.", expected: false},
+		"one title missing": {queried: "doc. Ing.", titles: "Ing.", expected: false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if has := HasTitles(test.queried, test.titles); has != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, has)
+			}
+		})
+	}
+}
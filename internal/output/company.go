@@ -0,0 +1,430 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fstaffa/czsnoop/internal/search"
+)
+
+type Company struct {
+	Name              string             `json:"name"`
+	Ico               string             `json:"ico"`
+	Address           string             `json:"address"`
+	LegalForm         string             `json:"legalForm"`
+	RegisteringOffice string             `json:"registeringOffice"`
+	FileNumber        string             `json:"fileNumber"`
+	RegisteredCapital string             `json:"registeredCapital"`
+	Trades            []Trade            `json:"trades"`
+	Persons           []AssociatedPerson `json:"persons"`
+	History           []Record           `json:"history"`
+	VatId             string             `json:"vatId"`
+	VatPayer          bool               `json:"vatPayer"`
+	NaceCodes         []string           `json:"naceCodes"`
+	EstablishedOn     string             `json:"establishedOn"`
+	TerminatedOn      string             `json:"terminatedOn"`
+}
+
+type Record struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type Trade struct {
+	Number                     string             `json:"number"`
+	TradeType                  string             `json:"tradeType"`
+	Fields                     []string           `json:"fields"`
+	Kind                       string             `json:"kind"`
+	DateOfOrigin               string             `json:"dateOfOrigin"`
+	ValidityOfLicense          string             `json:"validityOfLicense"`
+	DateOfTermination          string             `json:"dateOfTermination"`
+	Suspensions                []Period           `json:"suspensions"`
+	Establishments             []Establishment    `json:"establishments"`
+	ResponsibleRepresentatives []AssociatedPerson `json:"responsibleRepresentatives"`
+}
+
+type Period struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Establishment struct {
+	Number  string `json:"number"`
+	Address string `json:"address"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+type AssociatedPerson struct {
+	Role            string `json:"role"`
+	Function        string `json:"function"`
+	FullName        string `json:"fullName"`
+	FirstName       string `json:"firstName"`
+	LastName        string `json:"lastName"`
+	TitleBeforeName string `json:"titleBeforeName"`
+	TitleAfterName  string `json:"titleAfterName"`
+	BirthDate       string `json:"birthDate"`
+	Citizenship     string `json:"citizenship"`
+	Ico             string `json:"ico"`
+	Share           string `json:"share"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+}
+
+func FromCompany(company search.Company) Company {
+	trades := make([]Trade, 0, len(company.Trades))
+	for _, trade := range company.Trades {
+		trades = append(trades, fromTrade(trade))
+	}
+	history := make([]Record, 0, len(company.History))
+	for _, record := range company.History {
+		history = append(history, Record{Label: record.Label, Value: record.Value, From: formatDate(record.From), To: formatDate(record.To)})
+	}
+	naceCodes := company.NaceCodes
+	if naceCodes == nil {
+		naceCodes = []string{}
+	}
+	return Company{
+		Name:              company.Name,
+		Ico:               string(company.Ico),
+		Address:           company.Address,
+		LegalForm:         company.LegalForm,
+		RegisteringOffice: company.RegisteringOffice,
+		FileNumber:        company.FileNumber,
+		RegisteredCapital: company.RegisteredCapital,
+		Trades:            trades,
+		Persons:           fromAssociatedPersons(company.Persons),
+		History:           history,
+		VatId:             company.VatId,
+		VatPayer:          company.VatPayer,
+		NaceCodes:         naceCodes,
+		EstablishedOn:     formatDate(company.EstablishedOn),
+		TerminatedOn:      formatDate(company.TerminatedOn),
+	}
+}
+
+func fromTrade(trade search.Trade) Trade {
+	fields := trade.Fields
+	if fields == nil {
+		fields = []string{}
+	}
+	suspensions := make([]Period, 0, len(trade.Suspensions))
+	for _, suspension := range trade.Suspensions {
+		suspensions = append(suspensions, Period{From: formatDate(suspension.From), To: formatDate(suspension.To)})
+	}
+	establishments := make([]Establishment, 0, len(trade.Establishments))
+	for _, establishment := range trade.Establishments {
+		establishments = append(establishments, Establishment{
+			Number:  establishment.Number,
+			Address: establishment.Address,
+			From:    formatDate(establishment.From),
+			To:      formatDate(establishment.To),
+		})
+	}
+	return Trade{
+		Number:                     trade.Number,
+		TradeType:                  trade.TradeType,
+		Fields:                     fields,
+		Kind:                       string(trade.Kind),
+		DateOfOrigin:               formatDate(trade.DateOfOrigin),
+		ValidityOfLicense:          trade.ValidityOfLicense,
+		DateOfTermination:          formatDate(trade.DateOfTermination),
+		Suspensions:                suspensions,
+		Establishments:             establishments,
+		ResponsibleRepresentatives: fromAssociatedPersons(trade.ResponsibleRepresentatives),
+	}
+}
+
+func fromAssociatedPersons(associated []search.AssociatedPerson) []AssociatedPerson {
+	persons := make([]AssociatedPerson, 0, len(associated))
+	for _, person := range associated {
+		persons = append(persons, AssociatedPerson{
+			Role:            person.Role,
+			Function:        person.Function,
+			FullName:        person.FullName,
+			FirstName:       person.FirstName,
+			LastName:        person.LastName,
+			TitleBeforeName: person.TitleBeforeName,
+			TitleAfterName:  person.TitleAfterName,
+			BirthDate:       formatDate(person.BirthDate),
+			Citizenship:     person.Citizenship,
+			Ico:             string(person.Ico),
+			Share:           person.Share,
+			From:            formatDate(person.From),
+			To:              formatDate(person.To),
+		})
+	}
+	return persons
+}
+
+// WriteCompany renders company profile to w in the given format
+func WriteCompany(w io.Writer, format Format, company search.Company) error {
+	record := FromCompany(company)
+
+	switch format {
+	case JSON, NDJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		if format == JSON {
+			encoder.SetIndent("", "  ")
+		}
+		return encoder.Encode(record)
+	case CSV:
+		return writeCompanyCsv(w, record)
+	case Table:
+		return writeCompanyTable(w, record)
+	case YAML:
+		return writeCompanyYaml(w, record)
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// listSeparator joins multiple values in a single CSV column
+const listSeparator = "; "
+
+func formatPeriod(from string, to string) string {
+	return from + ".." + to
+}
+
+func writeCompanyCsv(w io.Writer, record Company) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"name", "ico", "address", "registering_office", "trade_number", "trade_type", "trade_kind", "trade_fields",
+		"trade_date_of_origin", "trade_validity_of_license", "trade_date_of_termination", "trade_suspensions", "trade_establishments",
+		"trade_responsible_representatives", "file_number", "registered_capital", "legal_form", "vat_id", "vat_payer", "nace_codes", "established_on",
+		"terminated_on"})
+	if err != nil {
+		return err
+	}
+	trades := record.Trades
+	if len(trades) == 0 {
+		trades = []Trade{{}}
+	}
+	for _, trade := range trades {
+		suspensions := make([]string, 0, len(trade.Suspensions))
+		for _, suspension := range trade.Suspensions {
+			suspensions = append(suspensions, formatPeriod(suspension.From, suspension.To))
+		}
+		establishments := make([]string, 0, len(trade.Establishments))
+		for _, establishment := range trade.Establishments {
+			establishments = append(establishments, establishment.Address)
+		}
+		representatives := make([]string, 0, len(trade.ResponsibleRepresentatives))
+		for _, representative := range trade.ResponsibleRepresentatives {
+			representatives = append(representatives, representative.FullName)
+		}
+		err := writer.Write([]string{record.Name, record.Ico, record.Address, record.RegisteringOffice, trade.Number, trade.TradeType, trade.Kind,
+			strings.Join(trade.Fields, listSeparator), trade.DateOfOrigin, trade.ValidityOfLicense, trade.DateOfTermination,
+			strings.Join(suspensions, listSeparator), strings.Join(establishments, listSeparator), strings.Join(representatives, listSeparator),
+			record.FileNumber, record.RegisteredCapital, record.LegalForm, record.VatId, strconv.FormatBool(record.VatPayer), strings.Join(record.NaceCodes, listSeparator),
+			record.EstablishedOn, record.TerminatedOn})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeCompanyTable(w io.Writer, record Company) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "NAME:\t%s\n", record.Name)
+	fmt.Fprintf(tw, "ICO:\t%s\n", record.Ico)
+	fmt.Fprintf(tw, "ADDRESS:\t%s\n", record.Address)
+	if record.LegalForm != "" {
+		fmt.Fprintf(tw, "LEGAL FORM:\t%s\n", record.LegalForm)
+	}
+	if record.RegisteringOffice != "" {
+		fmt.Fprintf(tw, "REGISTERING OFFICE:\t%s\n", record.RegisteringOffice)
+	}
+	if record.FileNumber != "" {
+		fmt.Fprintf(tw, "FILE NUMBER:\t%s\n", record.FileNumber)
+	}
+	if record.RegisteredCapital != "" {
+		fmt.Fprintf(tw, "REGISTERED CAPITAL:\t%s\n", record.RegisteredCapital)
+	}
+	if record.VatId != "" {
+		vatPayer := ""
+		if record.VatPayer {
+			vatPayer = " (VAT payer)"
+		}
+		fmt.Fprintf(tw, "VAT ID:\t%s%s\n", record.VatId, vatPayer)
+	}
+	if len(record.NaceCodes) > 0 {
+		fmt.Fprintf(tw, "NACE:\t%s\n", strings.Join(record.NaceCodes, ", "))
+	}
+	if record.EstablishedOn != "" {
+		fmt.Fprintf(tw, "ESTABLISHED:\t%s\n", record.EstablishedOn)
+	}
+	if record.TerminatedOn != "" {
+		fmt.Fprintf(tw, "TERMINATED:\t%s\n", record.TerminatedOn)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(tw, "NO\tTRADE\tKIND\tDATE OF ORIGIN\tVALIDITY\tTERMINATED")
+	for _, trade := range record.Trades {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", trade.Number, trade.TradeType, trade.Kind, trade.DateOfOrigin, trade.ValidityOfLicense, trade.DateOfTermination)
+		for _, field := range trade.Fields {
+			fmt.Fprintf(tw, "\t  %s\t\t\t\t\n", field)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var suspensions, establishments []string
+	for _, trade := range record.Trades {
+		for _, suspension := range trade.Suspensions {
+			suspensions = append(suspensions, fmt.Sprintf("%s\t%s\t%s\n", trade.Number, suspension.From, suspension.To))
+		}
+		for _, establishment := range trade.Establishments {
+			establishments = append(establishments, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\n", trade.Number, establishment.Number, establishment.Address, establishment.From, establishment.To))
+		}
+	}
+	if len(suspensions) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(tw, "SUSPENDED TRADE\tFROM\tTO")
+		for _, line := range suspensions {
+			fmt.Fprint(tw, line)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(establishments) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(tw, "TRADE\tESTABLISHMENT\tADDRESS\tFROM\tTO")
+		for _, line := range establishments {
+			fmt.Fprint(tw, line)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(tw, "ROLE\tFUNCTION\tNAME\tICO\tBIRTH DATE\tCITIZENSHIP\tSHARE\tFROM\tTO")
+	for _, person := range record.Persons {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", person.Role, person.Function, person.FullName, person.Ico, person.BirthDate, person.Citizenship, person.Share, person.From, person.To)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(record.History) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(tw, "FORMER\tVALUE\tFROM\tTO")
+		for _, history := range record.History {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", history.Label, history.Value, history.From, history.To)
+		}
+	}
+	return tw.Flush()
+}
+
+func writeCompanyYaml(w io.Writer, record Company) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "name: %s\n", yamlString(record.Name))
+	fmt.Fprintf(&b, "ico: %s\n", yamlString(record.Ico))
+	fmt.Fprintf(&b, "address: %s\n", yamlString(record.Address))
+	fmt.Fprintf(&b, "legalForm: %s\n", yamlString(record.LegalForm))
+	fmt.Fprintf(&b, "registeringOffice: %s\n", yamlString(record.RegisteringOffice))
+	fmt.Fprintf(&b, "fileNumber: %s\n", yamlString(record.FileNumber))
+	fmt.Fprintf(&b, "registeredCapital: %s\n", yamlString(record.RegisteredCapital))
+	fmt.Fprintf(&b, "vatId: %s\n", yamlString(record.VatId))
+	fmt.Fprintf(&b, "vatPayer: %t\n", record.VatPayer)
+	writeYamlStrings(&b, "", "naceCodes", record.NaceCodes)
+	fmt.Fprintf(&b, "establishedOn: %s\n", yamlString(record.EstablishedOn))
+	fmt.Fprintf(&b, "terminatedOn: %s\n", yamlString(record.TerminatedOn))
+	if len(record.Trades) == 0 {
+		b.WriteString("trades: []\n")
+	} else {
+		b.WriteString("trades:\n")
+		for _, trade := range record.Trades {
+			fmt.Fprintf(&b, "  - number: %s\n", yamlString(trade.Number))
+			fmt.Fprintf(&b, "    tradeType: %s\n", yamlString(trade.TradeType))
+			writeYamlStrings(&b, "    ", "fields", trade.Fields)
+			fmt.Fprintf(&b, "    kind: %s\n", yamlString(trade.Kind))
+			fmt.Fprintf(&b, "    dateOfOrigin: %s\n", yamlString(trade.DateOfOrigin))
+			fmt.Fprintf(&b, "    validityOfLicense: %s\n", yamlString(trade.ValidityOfLicense))
+			fmt.Fprintf(&b, "    dateOfTermination: %s\n", yamlString(trade.DateOfTermination))
+			if len(trade.Suspensions) == 0 {
+				b.WriteString("    suspensions: []\n")
+			} else {
+				b.WriteString("    suspensions:\n")
+				for _, suspension := range trade.Suspensions {
+					fmt.Fprintf(&b, "      - from: %s\n", yamlString(suspension.From))
+					fmt.Fprintf(&b, "        to: %s\n", yamlString(suspension.To))
+				}
+			}
+			if len(trade.Establishments) == 0 {
+				b.WriteString("    establishments: []\n")
+			} else {
+				b.WriteString("    establishments:\n")
+				for _, establishment := range trade.Establishments {
+					fmt.Fprintf(&b, "      - number: %s\n", yamlString(establishment.Number))
+					fmt.Fprintf(&b, "        address: %s\n", yamlString(establishment.Address))
+					fmt.Fprintf(&b, "        from: %s\n", yamlString(establishment.From))
+					fmt.Fprintf(&b, "        to: %s\n", yamlString(establishment.To))
+				}
+			}
+			writeYamlPersons(&b, "    ", "responsibleRepresentatives", trade.ResponsibleRepresentatives)
+		}
+	}
+	writeYamlPersons(&b, "", "persons", record.Persons)
+	if len(record.History) == 0 {
+		b.WriteString("history: []\n")
+	} else {
+		b.WriteString("history:\n")
+		for _, history := range record.History {
+			fmt.Fprintf(&b, "  - label: %s\n", yamlString(history.Label))
+			fmt.Fprintf(&b, "    value: %s\n", yamlString(history.Value))
+			fmt.Fprintf(&b, "    from: %s\n", yamlString(history.From))
+			fmt.Fprintf(&b, "    to: %s\n", yamlString(history.To))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeYamlStrings(b *strings.Builder, indent string, key string, values []string) {
+	if len(values) == 0 {
+		fmt.Fprintf(b, "%s%s: []\n", indent, key)
+		return
+	}
+	fmt.Fprintf(b, "%s%s:\n", indent, key)
+	for _, value := range values {
+		fmt.Fprintf(b, "%s  - %s\n", indent, yamlString(value))
+	}
+}
+
+func writeYamlPersons(b *strings.Builder, indent string, key string, persons []AssociatedPerson) {
+	if len(persons) == 0 {
+		fmt.Fprintf(b, "%s%s: []\n", indent, key)
+		return
+	}
+	fmt.Fprintf(b, "%s%s:\n", indent, key)
+	for _, person := range persons {
+		fmt.Fprintf(b, "%s  - role: %s\n", indent, yamlString(person.Role))
+		fmt.Fprintf(b, "%s    function: %s\n", indent, yamlString(person.Function))
+		fmt.Fprintf(b, "%s    fullName: %s\n", indent, yamlString(person.FullName))
+		fmt.Fprintf(b, "%s    firstName: %s\n", indent, yamlString(person.FirstName))
+		fmt.Fprintf(b, "%s    lastName: %s\n", indent, yamlString(person.LastName))
+		fmt.Fprintf(b, "%s    titleBeforeName: %s\n", indent, yamlString(person.TitleBeforeName))
+		fmt.Fprintf(b, "%s    titleAfterName: %s\n", indent, yamlString(person.TitleAfterName))
+		fmt.Fprintf(b, "%s    birthDate: %s\n", indent, yamlString(person.BirthDate))
+		fmt.Fprintf(b, "%s    citizenship: %s\n", indent, yamlString(person.Citizenship))
+		fmt.Fprintf(b, "%s    ico: %s\n", indent, yamlString(person.Ico))
+		fmt.Fprintf(b, "%s    share: %s\n", indent, yamlString(person.Share))
+		fmt.Fprintf(b, "%s    from: %s\n", indent, yamlString(person.From))
+		fmt.Fprintf(b, "%s    to: %s\n", indent, yamlString(person.To))
+	}
+}
@@ -0,0 +1,191 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fstaffa/czsnoop/internal/search"
+)
+
+type Graph struct {
+	Nodes     []Node `json:"nodes"`
+	Edges     []Edge `json:"edges"`
+	Requests  int    `json:"requests"`
+	Truncated bool   `json:"truncated"`
+}
+
+type Node struct {
+	Id        string `json:"id"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Ico       string `json:"ico"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	BirthDate string `json:"birthDate"`
+	Address   string `json:"address"`
+	Depth     int    `json:"depth"`
+	Expanded  bool   `json:"expanded"`
+}
+
+type Edge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Role   string `json:"role"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+func FromGraph(graph search.Graph) Graph {
+	nodes := make([]Node, 0, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		nodes = append(nodes, Node{
+			Id:        node.Id,
+			Kind:      string(node.Kind),
+			Name:      node.Name,
+			Ico:       string(node.Ico),
+			FirstName: node.FirstName,
+			LastName:  node.LastName,
+			BirthDate: formatDate(node.BirthDate),
+			Address:   node.Address,
+			Depth:     node.Depth,
+			Expanded:  node.Expanded,
+		})
+	}
+	edges := make([]Edge, 0, len(graph.Edges))
+	for _, edge := range graph.Edges {
+		edges = append(edges, Edge{
+			Source: edge.Source,
+			Target: edge.Target,
+			Role:   edge.Role,
+			From:   formatDate(edge.From),
+			To:     formatDate(edge.To),
+		})
+	}
+	return Graph{Nodes: nodes, Edges: edges, Requests: graph.Requests, Truncated: graph.Truncated}
+}
+
+// WriteGraph renders the graph of persons and subjects to w in the given format
+func WriteGraph(w io.Writer, format Format, graph search.Graph) error {
+	record := FromGraph(graph)
+
+	switch format {
+	case JSON, NDJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		if format == JSON {
+			encoder.SetIndent("", "  ")
+		}
+		return encoder.Encode(record)
+	case CSV:
+		return writeGraphCsv(w, record)
+	case Table:
+		return writeGraphTable(w, record)
+	case YAML:
+		return writeGraphYaml(w, record)
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// nodes indexes nodes of the graph by their id
+func (g Graph) nodes() map[string]Node {
+	nodes := make(map[string]Node, len(g.Nodes))
+	for _, node := range g.Nodes {
+		nodes[node.Id] = node
+	}
+	return nodes
+}
+
+func writeGraphCsv(w io.Writer, record Graph) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"source_id", "source_kind", "source_name", "role", "target_id", "target_name", "target_ico", "valid_from", "valid_to"})
+	if err != nil {
+		return err
+	}
+	nodes := record.nodes()
+	connected := make(map[string]bool, len(record.Nodes))
+	for _, edge := range record.Edges {
+		source, target := nodes[edge.Source], nodes[edge.Target]
+		connected[source.Id], connected[target.Id] = true, true
+		err := writer.Write([]string{source.Id, source.Kind, source.Name, edge.Role, target.Id, target.Name, target.Ico, edge.From, edge.To})
+		if err != nil {
+			return err
+		}
+	}
+	for _, node := range record.Nodes {
+		if connected[node.Id] {
+			continue
+		}
+		if err := writer.Write([]string{node.Id, node.Kind, node.Name, "", "", "", "", "", ""}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeGraphTable(w io.Writer, record Graph) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tKIND\tICO\tBIRTH DATE\tDEPTH")
+	for _, node := range record.Nodes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", node.Name, node.Kind, node.Ico, node.BirthDate, node.Depth)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	nodes := record.nodes()
+	fmt.Fprintln(tw, "SOURCE\tROLE\tTARGET\tFROM\tTO")
+	for _, edge := range record.Edges {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", nodes[edge.Source].Name, edge.Role, nodes[edge.Target].Name, edge.From, edge.To)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if record.Truncated {
+		fmt.Fprintf(w, "\nRequest budget exhausted after %d requests, some nodes were not expanded\n", record.Requests)
+	}
+	return nil
+}
+
+func writeGraphYaml(w io.Writer, record Graph) error {
+	var b strings.Builder
+	if len(record.Nodes) == 0 {
+		b.WriteString("nodes: []\n")
+	} else {
+		b.WriteString("nodes:\n")
+	}
+	for _, node := range record.Nodes {
+		fmt.Fprintf(&b, "  - id: %s\n", yamlString(node.Id))
+		fmt.Fprintf(&b, "    kind: %s\n", yamlString(node.Kind))
+		fmt.Fprintf(&b, "    name: %s\n", yamlString(node.Name))
+		fmt.Fprintf(&b, "    ico: %s\n", yamlString(node.Ico))
+		fmt.Fprintf(&b, "    firstName: %s\n", yamlString(node.FirstName))
+		fmt.Fprintf(&b, "    lastName: %s\n", yamlString(node.LastName))
+		fmt.Fprintf(&b, "    birthDate: %s\n", yamlString(node.BirthDate))
+		fmt.Fprintf(&b, "    address: %s\n", yamlString(node.Address))
+		fmt.Fprintf(&b, "    depth: %d\n", node.Depth)
+		fmt.Fprintf(&b, "    expanded: %s\n", strconv.FormatBool(node.Expanded))
+	}
+	if len(record.Edges) == 0 {
+		b.WriteString("edges: []\n")
+	} else {
+		b.WriteString("edges:\n")
+	}
+	for _, edge := range record.Edges {
+		fmt.Fprintf(&b, "  - source: %s\n", yamlString(edge.Source))
+		fmt.Fprintf(&b, "    target: %s\n", yamlString(edge.Target))
+		fmt.Fprintf(&b, "    role: %s\n", yamlString(edge.Role))
+		fmt.Fprintf(&b, "    from: %s\n", yamlString(edge.From))
+		fmt.Fprintf(&b, "    to: %s\n", yamlString(edge.To))
+	}
+	fmt.Fprintf(&b, "requests: %d\n", record.Requests)
+	fmt.Fprintf(&b, "truncated: %s\n", strconv.FormatBool(record.Truncated))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,388 @@
+// Package output renders search results in formats suitable for humans and other tools.
+//
+// JSON, NDJSON and YAML formats share the same schema. Person is an object with fields
+//
+//	fullName         full name as displayed by the registry, including titles
+//	firstName        first name
+//	lastName         last name
+//	titleBeforeName  academic title before the name, e.g. "Ing."
+//	titleAfterName   academic title after the name, e.g. "Ph.D."
+//	birthDate        date of birth in YYYY-MM-DD format, empty if unknown
+//	citizenship      citizenship as reported by the registry
+//	address          address of the person
+//	subjects         list of economic subjects the person is associated with
+//	score            how well the name matches the query between 0 and 1, present only with fuzzy search
+//	sources          list of records of the person as each provider reported them, objects with
+//	                 provider, fullName, birthDate and address
+//	merges           list of decisions to merge records of other providers into the person, objects
+//	                 with provider, fullName, confidence between 0 and 1 and reasons
+//
+// and each economic subject is an object with fields name, address, ico, role of the person
+// in the subject, one of entrepreneur, statutory body member, responsible representative, shareholder
+// or empty if it is not known, from and to dates of validity of the record, to is empty while it is valid,
+// legalForm, vatId, vatPayer and naceCodes (known only from ARES) and sources, the list of providers which
+// reported the subject.
+// Expired records are included only when searching with historical records.
+// JSON format is an array of persons, NDJSON has one person per line.
+//
+// CSV format has one row per person and economic subject pair with columns
+// full_name, first_name, last_name, title_before_name, title_after_name, birth_date,
+// citizenship, address, subject_name, subject_ico, subject_address, subject_role,
+// subject_valid_from, subject_valid_to, subject_legal_form, subject_vat_id, subject_vat_payer,
+// subject_nace_codes, sources, subject_sources, score where lists are joined by "; "
+// and score is empty without fuzzy search.
+// Persons without economic subjects have single row with empty subject columns.
+//
+// Company profile is an object with fields name, ico, address, legalForm (empty for natural
+// persons), registeringOffice, fileNumber and registeredCapital (empty for subjects missing in the
+// commercial register), trades, persons, history, vatId, vatPayer, naceCodes, establishedOn and
+// terminatedOn (known only from ARES). Each trade licence is an object with fields
+//
+//	number                      order number of the licence within the subject
+//	tradeType                   subject of business
+//	fields                      list of fields of activity of free trade
+//	kind                        one of free, craft, regulated, concession
+//	dateOfOrigin                date the licence was issued
+//	validityOfLicense           validity as stated by the registry, e.g. "na dobu neurčitou"
+//	dateOfTermination           date the licence ended, empty while it is valid
+//	suspensions                 list of objects with from and to, empty to means ongoing
+//	establishments              list of objects with number, address, from and to
+//	responsibleRepresentatives  list of associated persons guaranteeing the trade
+//
+// and persons are objects with role, function as stated by the registry, the same personal
+// fields as Person, ico of persons which are legal entities, share of shareholders and from and to
+// dates of the role if the registry states them. History lists former names, seats, legal forms and
+// registered capitals as objects with label, value, from and to. Company CSV format has one row per trade
+// with columns name, ico, address, registering_office, trade_number, trade_type, trade_kind,
+// trade_fields, trade_date_of_origin, trade_validity_of_license, trade_date_of_termination,
+// trade_suspensions, trade_establishments, trade_responsible_representatives, file_number,
+// registered_capital, legal_form, vat_id, vat_payer, nace_codes, established_on, terminated_on where lists
+// are joined by "; " and periods are written as from..to.
+//
+// Graph of relations is an object with nodes, edges, requests, the number of person searches and subject
+// lookups made, and truncated, set when the request budget ran out before all nodes were expanded. Nodes are
+// objects with id, kind (person or subject), name, ico, firstName, lastName, birthDate, address, depth, the
+// number of relations from the seed, and expanded, set when relations of the node were searched. Edges are
+// objects with source and target ids of nodes, role of the source in the target and from and to dates of the
+// role. Graph CSV format has one row per edge with columns source_id, source_kind, source_name, role,
+// target_id, target_name, target_ico, valid_from, valid_to, nodes without edges have a row with empty edge columns.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/search"
+)
+
+type Format string
+
+const (
+	JSON   Format = "json"
+	NDJSON Format = "ndjson"
+	CSV    Format = "csv"
+	Table  Format = "table"
+	YAML   Format = "yaml"
+)
+
+var Formats = []Format{JSON, NDJSON, CSV, Table, YAML}
+
+func ParseFormat(format string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == format {
+			return f, nil
+		}
+	}
+	names := make([]string, 0, len(Formats))
+	for _, f := range Formats {
+		names = append(names, string(f))
+	}
+	return "", fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(names, ", "))
+}
+
+type Person struct {
+	FullName        string            `json:"fullName"`
+	FirstName       string            `json:"firstName"`
+	LastName        string            `json:"lastName"`
+	TitleBeforeName string            `json:"titleBeforeName"`
+	TitleAfterName  string            `json:"titleAfterName"`
+	BirthDate       string            `json:"birthDate"`
+	Citizenship     string            `json:"citizenship"`
+	Address         string            `json:"address"`
+	Subjects        []EconomicSubject `json:"subjects"`
+	Score           *float64          `json:"score,omitempty"`
+	Sources         []PersonSource    `json:"sources"`
+	Merges          []MergeDecision   `json:"merges"`
+}
+
+type PersonSource struct {
+	Provider  string `json:"provider"`
+	FullName  string `json:"fullName"`
+	BirthDate string `json:"birthDate"`
+	Address   string `json:"address"`
+}
+
+type MergeDecision struct {
+	Provider   string   `json:"provider"`
+	FullName   string   `json:"fullName"`
+	Confidence float64  `json:"confidence"`
+	Reasons    []string `json:"reasons"`
+}
+
+type EconomicSubject struct {
+	Name      string   `json:"name"`
+	Address   string   `json:"address"`
+	Ico       string   `json:"ico"`
+	Role      string   `json:"role"`
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	LegalForm string   `json:"legalForm"`
+	VatId     string   `json:"vatId"`
+	VatPayer  bool     `json:"vatPayer"`
+	NaceCodes []string `json:"naceCodes"`
+	Sources   []string `json:"sources"`
+}
+
+func FromPerson(person search.Person) Person {
+	subjects := make([]EconomicSubject, 0, len(person.Subjects))
+	for _, subject := range person.Subjects {
+		subjects = append(subjects, FromEconomicSubject(subject))
+	}
+	sources := make([]PersonSource, 0, len(person.Sources))
+	for _, source := range person.Sources {
+		sources = append(sources, PersonSource{
+			Provider:  source.Provider,
+			FullName:  source.FullName,
+			BirthDate: formatDate(source.BirthDate),
+			Address:   source.Address,
+		})
+	}
+	merges := make([]MergeDecision, 0, len(person.Merges))
+	for _, merge := range person.Merges {
+		merges = append(merges, MergeDecision{
+			Provider:   merge.Provider,
+			FullName:   merge.FullName,
+			Confidence: merge.Confidence,
+			Reasons:    nonNil(merge.Reasons),
+		})
+	}
+	return Person{
+		FullName:        person.FullName,
+		FirstName:       person.FirstName,
+		LastName:        person.LastName,
+		TitleBeforeName: person.TitleBeforeName,
+		TitleAfterName:  person.TitleAfterName,
+		BirthDate:       formatDate(person.BirthDate),
+		Citizenship:     person.Citizenship,
+		Address:         person.Address,
+		Subjects:        subjects,
+		Score:           person.Score,
+		Sources:         sources,
+		Merges:          merges,
+	}
+}
+
+func FromEconomicSubject(subject search.EconomicSubject) EconomicSubject {
+	return EconomicSubject{
+		Name:      subject.Name,
+		Address:   subject.Address,
+		Ico:       string(subject.Ico),
+		Role:      subject.Role,
+		From:      formatDate(subject.From),
+		To:        formatDate(subject.To),
+		LegalForm: subject.LegalForm,
+		VatId:     subject.VatId,
+		VatPayer:  subject.VatPayer,
+		NaceCodes: nonNil(subject.NaceCodes),
+		Sources:   nonNil(subject.Sources),
+	}
+}
+
+// nonNil makes empty lists render as empty arrays rather than null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// providers lists providers of the person sources
+func (p Person) providers() []string {
+	providers := make([]string, 0, len(p.Sources))
+	for _, source := range p.Sources {
+		providers = append(providers, source.Provider)
+	}
+	return providers
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(time.DateOnly)
+}
+
+// WritePersons renders persons to w in the given format
+func WritePersons(w io.Writer, format Format, persons []search.Person) error {
+	records := make([]Person, 0, len(persons))
+	for _, person := range persons {
+		records = append(records, FromPerson(person))
+	}
+
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case NDJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	case CSV:
+		return writePersonsCsv(w, records)
+	case Table:
+		return writePersonsTable(w, records)
+	case YAML:
+		return writePersonsYaml(w, records)
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+func writePersonsCsv(w io.Writer, records []Person) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"full_name", "first_name", "last_name", "title_before_name", "title_after_name", "birth_date", "citizenship", "address", "subject_name", "subject_ico", "subject_address", "subject_role", "subject_valid_from", "subject_valid_to", "subject_legal_form", "subject_vat_id", "subject_vat_payer", "subject_nace_codes", "sources", "subject_sources", "score"})
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		sources := strings.Join(record.providers(), "; ")
+		score := ""
+		if record.Score != nil {
+			score = formatScore(*record.Score)
+		}
+		subjects := record.Subjects
+		if len(subjects) == 0 {
+			subjects = []EconomicSubject{{}}
+		}
+		for _, subject := range subjects {
+			vatPayer := ""
+			if subject.Ico != "" {
+				vatPayer = strconv.FormatBool(subject.VatPayer)
+			}
+			err := writer.Write([]string{record.FullName, record.FirstName, record.LastName, record.TitleBeforeName, record.TitleAfterName, record.BirthDate, record.Citizenship, record.Address, subject.Name, subject.Ico, subject.Address, subject.Role, subject.From, subject.To,
+				subject.LegalForm, subject.VatId, vatPayer, strings.Join(subject.NaceCodes, "; "), sources, strings.Join(subject.Sources, "; "), score})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writePersonsTable(w io.Writer, records []Person) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tBIRTH DATE\tCITIZENSHIP\tADDRESS\tSUBJECTS\tSOURCES")
+	for _, record := range records {
+		icos := make([]string, 0, len(record.Subjects))
+		for _, subject := range record.Subjects {
+			if subject.To != "" {
+				icos = append(icos, fmt.Sprintf("%s (until %s)", subject.Ico, subject.To))
+				continue
+			}
+			icos = append(icos, subject.Ico)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", record.FullName, record.BirthDate, record.Citizenship, record.Address, strings.Join(icos, ", "), strings.Join(record.providers(), ", "))
+	}
+	return tw.Flush()
+}
+
+func writePersonsYaml(w io.Writer, records []Person) error {
+	if len(records) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+	var b strings.Builder
+	for _, record := range records {
+		fmt.Fprintf(&b, "- fullName: %s\n", yamlString(record.FullName))
+		fmt.Fprintf(&b, "  firstName: %s\n", yamlString(record.FirstName))
+		fmt.Fprintf(&b, "  lastName: %s\n", yamlString(record.LastName))
+		fmt.Fprintf(&b, "  titleBeforeName: %s\n", yamlString(record.TitleBeforeName))
+		fmt.Fprintf(&b, "  titleAfterName: %s\n", yamlString(record.TitleAfterName))
+		fmt.Fprintf(&b, "  birthDate: %s\n", yamlString(record.BirthDate))
+		fmt.Fprintf(&b, "  citizenship: %s\n", yamlString(record.Citizenship))
+		fmt.Fprintf(&b, "  address: %s\n", yamlString(record.Address))
+		if len(record.Subjects) == 0 {
+			b.WriteString("  subjects: []\n")
+		} else {
+			b.WriteString("  subjects:\n")
+		}
+		for _, subject := range record.Subjects {
+			fmt.Fprintf(&b, "    - name: %s\n", yamlString(subject.Name))
+			fmt.Fprintf(&b, "      address: %s\n", yamlString(subject.Address))
+			fmt.Fprintf(&b, "      ico: %s\n", yamlString(subject.Ico))
+			fmt.Fprintf(&b, "      role: %s\n", yamlString(subject.Role))
+			fmt.Fprintf(&b, "      from: %s\n", yamlString(subject.From))
+			fmt.Fprintf(&b, "      to: %s\n", yamlString(subject.To))
+			fmt.Fprintf(&b, "      legalForm: %s\n", yamlString(subject.LegalForm))
+			fmt.Fprintf(&b, "      vatId: %s\n", yamlString(subject.VatId))
+			fmt.Fprintf(&b, "      vatPayer: %t\n", subject.VatPayer)
+			writeYamlStrings(&b, "      ", "naceCodes", subject.NaceCodes)
+			writeYamlStrings(&b, "      ", "sources", subject.Sources)
+		}
+		if record.Score != nil {
+			fmt.Fprintf(&b, "  score: %s\n", formatScore(*record.Score))
+		}
+		if len(record.Sources) == 0 {
+			b.WriteString("  sources: []\n")
+		} else {
+			b.WriteString("  sources:\n")
+		}
+		for _, source := range record.Sources {
+			fmt.Fprintf(&b, "    - provider: %s\n", yamlString(source.Provider))
+			fmt.Fprintf(&b, "      fullName: %s\n", yamlString(source.FullName))
+			fmt.Fprintf(&b, "      birthDate: %s\n", yamlString(source.BirthDate))
+			fmt.Fprintf(&b, "      address: %s\n", yamlString(source.Address))
+		}
+		if len(record.Merges) == 0 {
+			b.WriteString("  merges: []\n")
+		} else {
+			b.WriteString("  merges:\n")
+		}
+		for _, merge := range record.Merges {
+			fmt.Fprintf(&b, "    - provider: %s\n", yamlString(merge.Provider))
+			fmt.Fprintf(&b, "      fullName: %s\n", yamlString(merge.FullName))
+			fmt.Fprintf(&b, "      confidence: %s\n", formatScore(merge.Confidence))
+			writeYamlStrings(&b, "      ", "reasons", merge.Reasons)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatScore writes scores between 0 and 1 with at most 3 decimal places
+func formatScore(score float64) string {
+	return strconv.FormatFloat(math.Round(score*1000)/1000, 'f', -1, 64)
+}
+
+// yamlString quotes s as YAML double-quoted scalar, JSON string escaping is a valid subset of it
+func yamlString(s string) string {
+	quoted, err := json.Marshal(s)
+	if err != nil {
+		return strconv.Quote(s)
+	}
+	return string(quoted)
+}
@@ -0,0 +1,367 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/rzp"
+	"github.com/fstaffa/czsnoop/internal/search"
+)
+
+var testPersons = []search.Person{
+	{
+		FullName:        "Ing. Jan Novák",
+		FirstName:       "Jan",
+		LastName:        "Novák",
+		TitleBeforeName: "Ing.",
+		BirthDate:       time.Date(1980, 6, 1, 0, 0, 0, 0, time.UTC),
+		Citizenship:     "Česká republika",
+		Address:         "Mazovská 479/8, 181 00, Praha 8 - Troja",
+		Subjects: []search.EconomicSubject{
+			{Name: "Ing. Jan Novák", Address: "Mazovská 479/8, 181 00, Praha 8 - Troja", Ico: "01895541", Role: search.RoleEntrepreneur,
+				LegalForm: "Fyzická osoba podnikající dle živnostenského zákona", VatId: "CZ8006011234", VatPayer: true, NaceCodes: []string{"58110", "62020"},
+				Sources: []string{search.ProviderRzp, search.ProviderAres}},
+			{Name: "Novák & syn, s.r.o.", Address: "Praha 1", Ico: "12345678", Role: search.RoleStatutoryBodyMember, Period: search.Period{
+				From: time.Date(2008, 4, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2012, 6, 30, 0, 0, 0, 0, time.UTC),
+			}, Sources: []string{search.ProviderRzp, search.ProviderJustice}},
+		},
+		Sources: []search.PersonSource{
+			{Provider: search.ProviderRzp, FullName: "Ing. Jan Novák", BirthDate: time.Date(1980, 6, 1, 0, 0, 0, 0, time.UTC)},
+			{Provider: search.ProviderJustice, FullName: "JAN NOVÁK", BirthDate: time.Date(1980, 6, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		Merges: []search.MergeDecision{
+			{Provider: search.ProviderJustice, FullName: "JAN NOVÁK", Confidence: 1, Reasons: []string{"same name", "same birth date 1980-06-01", "shared subject 12345678"}},
+		},
+	},
+	{
+		FullName:  "Eva Nováková",
+		FirstName: "Eva",
+		LastName:  "Nováková",
+		Score:     scoreOf(0.91372),
+	},
+}
+
+func scoreOf(score float64) *float64 {
+	return &score
+}
+
+func Test_ParseFormat(t *testing.T) {
+	t.Parallel()
+	for _, format := range Formats {
+		parsed, err := ParseFormat(string(format))
+		if err != nil {
+			t.Errorf("Received unexpected error %v", err)
+		}
+		if parsed != format {
+			t.Errorf("Expected %s, got %s", format, parsed)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("Expected error for unknown format, got nil")
+	}
+}
+
+func Test_WritePersons_JSON(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if err := WritePersons(&b, JSON, testPersons); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	var decoded []Person
+	if err := json.Unmarshal(b.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unable to decode output %v", err)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("Expected 2 persons, got %d", len(decoded))
+	}
+	if decoded[0].BirthDate != "1980-06-01" {
+		t.Errorf("Expected birth date 1980-06-01, got %s", decoded[0].BirthDate)
+	}
+	if decoded[0].Score != nil || decoded[1].Score == nil || *decoded[1].Score != 0.91372 {
+		t.Errorf("Expected score only of the second person, got %v and %v", decoded[0].Score, decoded[1].Score)
+	}
+	if decoded[1].BirthDate != "" {
+		t.Errorf("Expected empty birth date, got %s", decoded[1].BirthDate)
+	}
+	if len(decoded[0].Subjects) != 2 || decoded[0].Subjects[1].Name != "Novák & syn, s.r.o." {
+		t.Errorf("Expected subjects to be preserved, got %v", decoded[0].Subjects)
+	} else if subject := decoded[0].Subjects[0]; subject.VatId != "CZ8006011234" || !subject.VatPayer || subject.LegalForm == "" || len(subject.NaceCodes) != 2 {
+		t.Errorf("Expected ARES data of the subject, got %+v", subject)
+	} else if decoded[0].Subjects[1].NaceCodes == nil {
+		t.Errorf("Expected NACE codes to be empty array, not null")
+	}
+	if decoded[1].Subjects == nil || decoded[1].Sources == nil || decoded[1].Merges == nil {
+		t.Errorf("Expected subjects, sources and merges to be empty arrays, not null")
+	}
+	if len(decoded[0].Sources) != 2 || decoded[0].Sources[1].FullName != "JAN NOVÁK" {
+		t.Errorf("Expected sources to be preserved, got %v", decoded[0].Sources)
+	}
+	if len(decoded[0].Merges) != 1 || decoded[0].Merges[0].Confidence != 1 || len(decoded[0].Merges[0].Reasons) != 3 {
+		t.Errorf("Expected merge decision to be preserved, got %v", decoded[0].Merges)
+	}
+}
+
+func Test_WritePersons_NDJSON(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if err := WritePersons(&b, NDJSON, testPersons); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		var person Person
+		if err := json.Unmarshal([]byte(line), &person); err != nil {
+			t.Errorf("Unable to decode line %q: %v", line, err)
+		}
+	}
+}
+
+func Test_WritePersons_CSV(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if err := WritePersons(&b, CSV, testPersons); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	expected := `full_name,first_name,last_name,title_before_name,title_after_name,birth_date,citizenship,address,subject_name,subject_ico,subject_address,subject_role,subject_valid_from,subject_valid_to,subject_legal_form,subject_vat_id,subject_vat_payer,subject_nace_codes,sources,subject_sources,score
+Ing. Jan Novák,Jan,Novák,Ing.,,1980-06-01,Česká republika,"Mazovská 479/8, 181 00, Praha 8 - Troja",Ing. Jan Novák,01895541,"Mazovská 479/8, 181 00, Praha 8 - Troja",entrepreneur,,,Fyzická osoba podnikající dle živnostenského zákona,CZ8006011234,true,58110; 62020,rzp; justice,rzp; ares,
+Ing. Jan Novák,Jan,Novák,Ing.,,1980-06-01,Česká republika,"Mazovská 479/8, 181 00, Praha 8 - Troja","Novák & syn, s.r.o.",12345678,Praha 1,statutory body member,2008-04-01,2012-06-30,,,false,,rzp; justice,rzp; justice,
+Eva Nováková,Eva,Nováková,,,,,,,,,,,,,,,,,,0.914
+`
+	if b.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
+
+func Test_WritePersons_Table(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if err := WritePersons(&b, Table, testPersons); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "NAME") {
+		t.Errorf("Expected header, got %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "01895541, 12345678 (until 2012-06-30)") || !strings.HasSuffix(lines[1], "rzp, justice") {
+		t.Errorf("Expected subject ICOs and sources in row, got %s", lines[1])
+	}
+}
+
+func Test_WritePersons_YAML(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if err := WritePersons(&b, YAML, testPersons[1:]); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	expected := `- fullName: "Eva Nováková"
+  firstName: "Eva"
+  lastName: "Nováková"
+  titleBeforeName: ""
+  titleAfterName: ""
+  birthDate: ""
+  citizenship: ""
+  address: ""
+  subjects: []
+  score: 0.914
+  sources: []
+  merges: []
+`
+	if b.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, b.String())
+	}
+
+	b.Reset()
+	if err := WritePersons(&b, YAML, testPersons[:1]); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if !strings.Contains(b.String(), "      legalForm: \"Fyzická osoba podnikající dle živnostenského zákona\"\n      vatId: \"CZ8006011234\"\n      vatPayer: true\n      naceCodes:\n        - \"58110\"\n") {
+		t.Errorf("Expected ARES data of the subject, got %s", b.String())
+	}
+	if !strings.Contains(b.String(), "  merges:\n    - provider: \"justice\"\n      fullName: \"JAN NOVÁK\"\n      confidence: 1\n      reasons:\n        - \"same name\"\n") {
+		t.Errorf("Expected merge decision, got %s", b.String())
+	}
+
+	b.Reset()
+	if err := WritePersons(&b, YAML, nil); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if b.String() != "[]\n" {
+		t.Errorf("Expected empty list, got %s", b.String())
+	}
+}
+
+func Test_WritePersons_ZeroScore(t *testing.T) {
+	t.Parallel()
+	tests := map[Format]string{
+		JSON: `"score": 0,`,
+		CSV:  ",0\n",
+		YAML: "  score: 0\n",
+	}
+	persons := []search.Person{{FullName: "Eva Nováková", FirstName: "Eva", LastName: "Nováková", Score: scoreOf(0)}}
+	for format, expected := range tests {
+		t.Run(string(format), func(t *testing.T) {
+			t.Parallel()
+			var b bytes.Buffer
+			if err := WritePersons(&b, format, persons); err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if !strings.Contains(b.String(), expected) {
+				t.Errorf("Expected score 0 in output, got\n%s", b.String())
+			}
+		})
+	}
+}
+
+func Test_WriteCompany_CSV(t *testing.T) {
+	t.Parallel()
+	company := search.Company{
+		Name:              "Ing. Jan Novák",
+		Ico:               "01895541",
+		Address:           "Praha 1",
+		RegisteringOffice: "Úřad městské části Praha 1",
+		LegalForm:         "Fyzická osoba podnikající dle živnostenského zákona",
+		VatId:             "CZ8006011234",
+		VatPayer:          true,
+		NaceCodes:         []string{"58110", "62020"},
+		EstablishedOn:     time.Date(2010, 3, 12, 0, 0, 0, 0, time.UTC),
+		Trades: []search.Trade{
+			{
+				Number:            "1",
+				TradeType:         "Výroba, obchod a služby",
+				Fields:            []string{"Vydavatelské činnosti", "Poskytování software"},
+				Kind:              rzp.TradeKindFree,
+				DateOfOrigin:      time.Date(2010, 3, 12, 0, 0, 0, 0, time.UTC),
+				ValidityOfLicense: "na dobu neurčitou",
+			},
+			{
+				Number:            "2",
+				TradeType:         "Hostinská činnost",
+				Kind:              rzp.TradeKindCraft,
+				DateOfOrigin:      time.Date(2012, 5, 1, 0, 0, 0, 0, time.UTC),
+				ValidityOfLicense: "na dobu neurčitou",
+				DateOfTermination: time.Date(2018, 6, 30, 0, 0, 0, 0, time.UTC),
+				Suspensions:       []search.Period{{From: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)}},
+				Establishments:    []search.Establishment{{Number: "1012345678", Address: "Praha 9"}},
+				ResponsibleRepresentatives: []search.AssociatedPerson{
+					{Role: search.RoleResponsibleRepresentative, FullName: "Marie Dvořáková"},
+				},
+			},
+		},
+	}
+	var b bytes.Buffer
+	if err := WriteCompany(&b, CSV, company); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	expected := `name,ico,address,registering_office,trade_number,trade_type,trade_kind,trade_fields,trade_date_of_origin,trade_validity_of_license,trade_date_of_termination,trade_suspensions,trade_establishments,trade_responsible_representatives,file_number,registered_capital,legal_form,vat_id,vat_payer,nace_codes,established_on,terminated_on
+Ing. Jan Novák,01895541,Praha 1,Úřad městské části Praha 1,1,"Výroba, obchod a služby",free,Vydavatelské činnosti; Poskytování software,2010-03-12,na dobu neurčitou,,,,,,,Fyzická osoba podnikající dle živnostenského zákona,CZ8006011234,true,58110; 62020,2010-03-12,
+Ing. Jan Novák,01895541,Praha 1,Úřad městské části Praha 1,2,Hostinská činnost,craft,,2012-05-01,na dobu neurčitou,2018-06-30,2014-01-01..,Praha 9,Marie Dvořáková,,,Fyzická osoba podnikající dle živnostenského zákona,CZ8006011234,true,58110; 62020,2010-03-12,
+`
+	if b.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
+
+var testGraph = search.Graph{
+	Nodes: []search.Node{
+		{Id: "subject:45678910", Kind: search.NodeSubject, Name: "NOVÁK & PARTNEŘI a.s.", Ico: "45678910", Expanded: true},
+		{Id: "person:jan novak|1980-06-01", Kind: search.NodePerson, Name: "Ing. Jan Novák", FirstName: "Jan", LastName: "Novák",
+			BirthDate: time.Date(1980, 6, 1, 0, 0, 0, 0, time.UTC), Depth: 1},
+		{Id: "subject:01895541", Kind: search.NodeSubject, Name: "THOMAS SILVERTONNI s.r.o.", Ico: "01895541", Depth: 1},
+	},
+	Edges: []search.Edge{
+		{Source: "person:jan novak|1980-06-01", Target: "subject:45678910", Role: search.RoleShareholder,
+			Period: search.Period{From: time.Date(2015, 1, 5, 0, 0, 0, 0, time.UTC)}},
+	},
+	Requests:  2,
+	Truncated: true,
+}
+
+func Test_WriteGraph_JSON(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if err := WriteGraph(&b, JSON, testGraph); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	var decoded Graph
+	if err := json.Unmarshal(b.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unable to decode output %v", err)
+	}
+	if len(decoded.Nodes) != 3 || decoded.Nodes[1].BirthDate != "1980-06-01" || decoded.Nodes[1].Kind != "person" {
+		t.Errorf("Expected nodes to be preserved, got %+v", decoded.Nodes)
+	}
+	if len(decoded.Edges) != 1 || decoded.Edges[0].Source != "person:jan novak|1980-06-01" || decoded.Edges[0].From != "2015-01-05" {
+		t.Errorf("Expected edges to be preserved, got %+v", decoded.Edges)
+	}
+	if decoded.Requests != 2 || !decoded.Truncated {
+		t.Errorf("Expected truncated graph after 2 requests, got %+v", decoded)
+	}
+}
+
+func Test_WriteGraph_CSV(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if err := WriteGraph(&b, CSV, testGraph); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	expected := `source_id,source_kind,source_name,role,target_id,target_name,target_ico,valid_from,valid_to
+person:jan novak|1980-06-01,person,Ing. Jan Novák,shareholder,subject:45678910,NOVÁK & PARTNEŘI a.s.,45678910,2015-01-05,
+subject:01895541,subject,THOMAS SILVERTONNI s.r.o.,,,,,,
+`
+	if b.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
+
+func Test_WriteGraph_Table(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if err := WriteGraph(&b, Table, testGraph); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 9 {
+		t.Fatalf("Expected nodes, edges and truncation note, got %s", b.String())
+	}
+	if !strings.HasPrefix(lines[5], "SOURCE") || !strings.Contains(lines[6], "Ing. Jan Novák  shareholder  NOVÁK & PARTNEŘI a.s.") {
+		t.Errorf("Expected edge with names of nodes, got %s", b.String())
+	}
+	if !strings.HasPrefix(lines[8], "Request budget exhausted after 2 requests") {
+		t.Errorf("Expected truncation note, got %s", lines[8])
+	}
+}
+
+func Test_WriteGraph_YAML(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if err := WriteGraph(&b, YAML, search.Graph{Nodes: testGraph.Nodes[2:]}); err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	expected := `nodes:
+  - id: "subject:01895541"
+    kind: "subject"
+    name: "THOMAS SILVERTONNI s.r.o."
+    ico: "01895541"
+    firstName: ""
+    lastName: ""
+    birthDate: ""
+    address: ""
+    depth: 1
+    expanded: false
+edges: []
+requests: 0
+truncated: false
+`
+	if b.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, b.String())
+	}
+}
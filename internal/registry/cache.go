@@ -0,0 +1,54 @@
+// Package registry provides plumbing shared by clients of the public registers: limiting concurrency
+// and rate of requests, retrying temporary failures and caching decoded responses.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+)
+
+// Cache keeps decoded registry responses between runs, see internal/cache for the on-disk implementation
+type Cache interface {
+	// Get returns data stored for the endpoint and key, false if missing or expired
+	Get(endpoint string, key string) ([]byte, bool)
+	Put(endpoint string, key string, data []byte) error
+}
+
+// ResponseCache stores responses of a client as JSON in Cache, nil Cache disables caching
+type ResponseCache struct {
+	Cache  Cache
+	Logger *slog.Logger
+}
+
+// Get decodes response cached for the endpoint and key into v, false if there is none. Empty key
+// marks responses which can not be cached.
+func (c ResponseCache) Get(ctx context.Context, endpoint string, key string, v any) bool {
+	if c.Cache == nil || key == "" {
+		return false
+	}
+	data, ok := c.Cache.Get(endpoint, key)
+	if !ok {
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		c.Logger.DebugContext(ctx, "Ignoring unreadable cache entry", slog.String("endpoint", endpoint), slog.Any("error", err))
+		return false
+	}
+	c.Logger.DebugContext(ctx, "Cache hit", slog.String("endpoint", endpoint), slog.String("key", key))
+	return true
+}
+
+// Put stores the response, failures are only logged as the response was already received
+func (c ResponseCache) Put(ctx context.Context, endpoint string, key string, v any) {
+	if c.Cache == nil || key == "" {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = c.Cache.Put(endpoint, key, data)
+	}
+	if err != nil {
+		c.Logger.WarnContext(ctx, "Unable to store response in cache", slog.String("endpoint", endpoint), slog.Any("error", err))
+	}
+}
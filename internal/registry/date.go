@@ -0,0 +1,34 @@
+package registry
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Iso8601Date is a date in YYYY-MM-DD format, empty string and null are zero date
+type Iso8601Date time.Time
+
+func (d *Iso8601Date) UnmarshalJSON(data []byte) error {
+	var date string
+	err := json.Unmarshal(data, &date)
+	if err != nil {
+		return err
+	}
+	if date == "" {
+		*d = Iso8601Date{}
+		return nil
+	}
+	parsed, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return err
+	}
+	*d = Iso8601Date(parsed)
+	return nil
+}
+
+func (d Iso8601Date) MarshalJSON() ([]byte, error) {
+	if time.Time(d).IsZero() {
+		return json.Marshal("")
+	}
+	return json.Marshal(time.Time(d).Format("2006-01-02"))
+}
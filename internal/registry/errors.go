@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var (
+	// ErrNotFound is returned when the registry does not know the requested subject or document
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited is returned when the registry keeps rejecting requests with 429 Too Many Requests
+	ErrRateLimited = errors.New("rate limited by the registry")
+)
+
+// maxErrorBody limits how much of error response body is kept in HTTPStatusError
+const maxErrorBody = 4096
+
+// HTTPStatusError is returned when the registry responds with unexpected status code
+type HTTPStatusError struct {
+	Url        string
+	StatusCode int
+	Status     string
+	// Code, SubCode and Description are taken from structured error response of registries which send one,
+	// e.g. NENALEZENO from ARES
+	Code        string
+	SubCode     string
+	Description string
+	Body        string
+	// Err is what the status means for the particular registry, e.g. expired session, nil if nothing more is known
+	Err error
+}
+
+func (e *HTTPStatusError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("unexpected status code: %d and status %s, %s: %s", e.StatusCode, e.Status, e.Code, e.Description)
+	}
+	if e.Body == "" {
+		return fmt.Sprintf("unexpected status code: %d and status %s", e.StatusCode, e.Status)
+	}
+	return fmt.Sprintf("unexpected status code: %d and status %s, with response %s", e.StatusCode, e.Status, e.Body)
+}
+
+// Is allows matching HTTPStatusError with ErrNotFound and ErrRateLimited
+func (e *HTTPStatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+func (e *HTTPStatusError) Unwrap() error {
+	return e.Err
+}
+
+// NewHTTPStatusError creates error from the response keeping beginning of its body, the body is not closed
+func NewHTTPStatusError(resp *http.Response) *HTTPStatusError {
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	body := string(content)
+	if err != nil {
+		body = "[unable to read error response]"
+	}
+	return &HTTPStatusError{
+		Url:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       body,
+	}
+}
+
+// SchemaError is returned when response of the registry does not match the expected structure,
+// which usually means that the registry changed its API or web
+type SchemaError struct {
+	// Path to the element that could not be parsed, e.g. listiny/verweb/PodnikatelDetail
+	Path string
+	Err  error
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("unexpected response structure at %s: %v", e.Path, e.Err)
+}
+
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
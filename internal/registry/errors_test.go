@@ -0,0 +1,32 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func Test_HTTPStatusError_Is(t *testing.T) {
+	t.Parallel()
+	errSessionExpired := errors.New("session expired")
+	tests := map[string]struct {
+		err      *HTTPStatusError
+		target   error
+		expected bool
+	}{
+		"not found":           {err: &HTTPStatusError{StatusCode: http.StatusNotFound}, target: ErrNotFound, expected: true},
+		"rate limited":        {err: &HTTPStatusError{StatusCode: http.StatusTooManyRequests}, target: ErrRateLimited, expected: true},
+		"server error":        {err: &HTTPStatusError{StatusCode: http.StatusInternalServerError}, target: ErrNotFound, expected: false},
+		"registry specific":   {err: &HTTPStatusError{StatusCode: http.StatusUnauthorized, Err: errSessionExpired}, target: errSessionExpired, expected: true},
+		"without explanation": {err: &HTTPStatusError{StatusCode: http.StatusUnauthorized}, target: errSessionExpired, expected: false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := fmt.Errorf("wrapped: %w", test.err)
+			if errors.Is(err, test.target) != test.expected {
+				t.Errorf("Expected errors.Is to be %v", test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency is the default maximum number of requests in flight to a registry
+const DefaultConcurrency = 10
+
+// LimitedTransport bounds concurrency and rate of requests passed to the wrapped transport.
+// Request is in flight until its response body is closed.
+type LimitedTransport struct {
+	next      http.RoundTripper
+	semaphore chan struct{}
+	limiter   *rateLimiter
+}
+
+// NewLimitedTransport allows at most concurrency requests in flight, 0 means DefaultConcurrency, and starts
+// at most requestsPerSecond requests per second using token bucket, 0 means unlimited
+func NewLimitedTransport(next http.RoundTripper, concurrency int, requestsPerSecond float64) *LimitedTransport {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	var limiter *rateLimiter
+	if requestsPerSecond > 0 {
+		limiter = newRateLimiter(requestsPerSecond, int(math.Ceil(requestsPerSecond)))
+	}
+	return &LimitedTransport{
+		next:      next,
+		semaphore: make(chan struct{}, concurrency),
+		limiter:   limiter,
+	}
+}
+
+func (t *LimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	select {
+	case t.semaphore <- struct{}{}:
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+	if t.limiter != nil {
+		if err := t.limiter.Wait(ctx); err != nil {
+			<-t.semaphore
+			return nil, err
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		<-t.semaphore
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: func() { <-t.semaphore }}
+	return resp, nil
+}
+
+// InFlight returns number of requests whose response body was not closed yet
+func (t *LimitedTransport) InFlight() int {
+	return len(t.semaphore)
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// rateLimiter is a token bucket refilled with rate tokens per second up to burst tokens
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// reserve takes a token if available, otherwise returns how long to wait for the next one
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		wait := l.reserve()
+		if wait == 0 {
+			return nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return context.Cause(ctx)
+		}
+	}
+}
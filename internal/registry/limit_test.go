@@ -0,0 +1,98 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_rateLimiter_reserve(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(2, 2)
+	limiter.now = func() time.Time { return now }
+	limiter.last = now
+
+	if wait := limiter.reserve(); wait != 0 {
+		t.Errorf("Expected first token from burst, got wait %s", wait)
+	}
+	if wait := limiter.reserve(); wait != 0 {
+		t.Errorf("Expected second token from burst, got wait %s", wait)
+	}
+	if wait := limiter.reserve(); wait != 500*time.Millisecond {
+		t.Errorf("Expected to wait 500ms for next token, got %s", wait)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if wait := limiter.reserve(); wait != 0 {
+		t.Errorf("Expected token to be refilled, got wait %s", wait)
+	}
+	now = now.Add(10 * time.Second)
+	limiter.reserve()
+	limiter.reserve()
+	if wait := limiter.reserve(); wait == 0 {
+		t.Errorf("Expected tokens to be capped by burst")
+	}
+}
+
+func Test_rateLimiter_WaitCanceled(t *testing.T) {
+	t.Parallel()
+	limiter := newRateLimiter(0.001, 1)
+	limiter.reserve()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); err == nil {
+		t.Errorf("Expected error for canceled context, got nil")
+	}
+}
+
+type countingTransport struct {
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	current := c.inFlight.Add(1)
+	for {
+		max := c.maxInFlight.Load()
+		if current <= max || c.maxInFlight.CompareAndSwap(max, current) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	c.inFlight.Add(-1)
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+}
+
+func Test_LimitedTransport_BoundsConcurrency(t *testing.T) {
+	t.Parallel()
+	counting := &countingTransport{}
+	transport := NewLimitedTransport(counting, 3, 0)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Errorf("Received unexpected error %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if counting.maxInFlight.Load() > 3 {
+		t.Errorf("Expected at most 3 requests in flight, got %d", counting.maxInFlight.Load())
+	}
+	if transport.InFlight() != 0 {
+		t.Errorf("Expected all requests to be released, %d still held", transport.InFlight())
+	}
+}
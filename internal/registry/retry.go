@@ -0,0 +1,140 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy sets how many times are failed requests retried, the delay starts at BaseDelay
+// and doubles with each retry up to MaxDelay
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 4, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+// MaxRetryAfter caps delay requested by server in Retry-After header
+const MaxRetryAfter = 2 * time.Minute
+
+// IsRetryable checks whether the status code means that the registry is temporarily unavailable
+func IsRetryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// Backoff returns delay before next attempt, at least retryAfter if server requested it,
+// otherwise random delay up to exponentially growing limit
+func (p RetryPolicy) Backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, MaxRetryAfter)
+	}
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	limit := p.BaseDelay << min(attempt, 16)
+	if limit <= 0 || limit > p.MaxDelay {
+		limit = p.MaxDelay
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return limit/2 + rand.N(limit/2+1)
+}
+
+// ParseRetryAfter parses Retry-After header in seconds or HTTP date format, zero means not present
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
+}
+
+// Discard reads the rest of the response body and closes it, so that the connection can be reused
+func Discard(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// Sleep waits for d unless the context is done first
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// RetryingTransport retries network errors, 429 and 5xx responses with jittered exponential backoff
+// honoring Retry-After. Requests with body are retried only when the body can be read again, see
+// http.Request.GetBody.
+type RetryingTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+	logger *slog.Logger
+}
+
+func NewRetryingTransport(next http.RoundTripper, policy RetryPolicy, logger *slog.Logger) *RetryingTransport {
+	return &RetryingTransport{next: next, policy: policy, logger: logger}
+}
+
+func (t *RetryingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		attemptReq, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.next.RoundTrip(attemptReq)
+		retryable := attempt < t.policy.MaxRetries && (req.Body == nil || req.GetBody != nil)
+		var retryAfter time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil || !retryable {
+				return nil, err
+			}
+			t.logger.DebugContext(ctx, "Request failed, retrying", slog.String("url", req.URL.String()), slog.Any("error", err))
+		case IsRetryable(resp.StatusCode) && retryable:
+			retryAfter = ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			Discard(resp)
+			t.logger.DebugContext(ctx, "Server unavailable, retrying", slog.String("url", req.URL.String()), slog.Int("status", resp.StatusCode), slog.Duration("retryAfter", retryAfter))
+		default:
+			return resp, nil
+		}
+
+		if err := Sleep(ctx, t.policy.Backoff(attempt, retryAfter)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// rewind returns the request with fresh body for repeated attempts, the first attempt uses the request as is
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
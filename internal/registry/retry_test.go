@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_ParseRetryAfter(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		value    string
+		expected time.Duration
+	}{
+		"missing":     {value: "", expected: 0},
+		"seconds":     {value: "3", expected: 3 * time.Second},
+		"negative":    {value: "-3", expected: 0},
+		"http date":   {value: "Mon, 01 Jan 2024 12:00:10 GMT", expected: 10 * time.Second},
+		"date passed": {value: "Mon, 01 Jan 2024 11:00:00 GMT", expected: 0},
+		"invalid":     {value: "soon", expected: 0},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := ParseRetryAfter(test.value, now)
+			if actual != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_RetryPolicy_Backoff(t *testing.T) {
+	t.Parallel()
+	policy := RetryPolicy{MaxRetries: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt := 0; attempt < 10; attempt++ {
+		limit := min(policy.BaseDelay<<attempt, policy.MaxDelay)
+		delay := policy.Backoff(attempt, 0)
+		if delay < limit/2 || delay > limit {
+			t.Errorf("Expected delay of attempt %d between %s and %s, got %s", attempt, limit/2, limit, delay)
+		}
+	}
+	if delay := policy.Backoff(0, 5*time.Second); delay != 5*time.Second {
+		t.Errorf("Expected Retry-After to be honored, got %s", delay)
+	}
+	if delay := policy.Backoff(0, time.Hour); delay != MaxRetryAfter {
+		t.Errorf("Expected Retry-After to be capped, got %s", delay)
+	}
+	if delay := (RetryPolicy{MaxRetries: 2, MaxDelay: time.Second}).Backoff(1, 0); delay != 0 {
+		t.Errorf("Expected no delay without base delay, got %s", delay)
+	}
+}
+
+func Test_RetryingTransport(t *testing.T) {
+	t.Parallel()
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"name":"novak"}` {
+			http.Error(w, "unexpected body "+string(body), http.StatusBadRequest)
+			return
+		}
+		if attempts.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	transport := NewRetryingTransport(http.DefaultTransport, RetryPolicy{MaxRetries: 2}, slog.Default())
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"name":"novak"}`))
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 after retries, got %d", resp.StatusCode)
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts.Load())
+	}
+}
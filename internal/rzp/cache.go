@@ -0,0 +1,54 @@
+package rzp
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/registry"
+)
+
+// Cache keeps decoded RZP responses between runs, see internal/cache for the on-disk implementation
+type Cache = registry.Cache
+
+// Cache endpoints of the client
+const (
+	CacheEndpointPersons        = "rzp/osoby"
+	CacheEndpointSubjects       = "rzp/subjekty"
+	CacheEndpointSubjectDetails = "rzp/subjekty/isvs"
+)
+
+// CacheTTLs is the default time to live of cached responses per endpoint,
+// subject details change rarely compared to search results
+var CacheTTLs = map[string]time.Duration{
+	CacheEndpointPersons:        24 * time.Hour,
+	CacheEndpointSubjects:       24 * time.Hour,
+	CacheEndpointSubjectDetails: 7 * 24 * time.Hour,
+}
+
+// cachedGeneration marks Ssarzp values read from cache, they come from an older session and have to be re-resolved
+const cachedGeneration = -1
+
+// WithCache stores responses in the cache and answers repeated queries from it. Subject details are keyed by
+// ICO, name and type of the subject instead of Ssarzp as Ssarzp is bound to the session.
+func WithCache(cache Cache) Option {
+	return func(o *clientOptions) {
+		o.cache = cache
+	}
+}
+
+func (r *Rzp) cached(endpoint string, key string, v any) bool {
+	return r.cache.Get(r.context, endpoint, key, v)
+}
+
+func (r *Rzp) store(endpoint string, key string, v any) {
+	r.cache.Put(r.context, endpoint, key, v)
+}
+
+// subjectCacheKey identifies subject by values which survive session renewal, empty if the subject is unknown
+func (r *Rzp) subjectCacheKey(ssarzp Ssarzp) string {
+	origin, ok := r.origin(ssarzp)
+	if !ok || origin.ico == "" {
+		return ""
+	}
+	return url.Values{"ico": {string(origin.ico)}, "nazev": {origin.name}, "typ": {origin.subjectType}}.Encode()
+}
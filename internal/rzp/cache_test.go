@@ -0,0 +1,132 @@
+package rzp
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func (c *memoryCache) Get(endpoint string, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.entries[endpoint+"|"+key]
+	return data, ok
+}
+
+func (c *memoryCache) Put(endpoint string, key string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[endpoint+"|"+key] = data
+	return nil
+}
+
+func (c *memoryCache) drop(endpoint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, endpoint+"|") {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func Test_WithCache_AnswersRepeatedQueries(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	server := flakyServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if !strings.HasSuffix(r.URL.Path, "/session/v1/start") {
+			requests.Add(1)
+		}
+		return false
+	})
+	cache := &memoryCache{entries: map[string][]byte{}}
+	lookup := func() (SearchPersonResponse, SubjectDetail) {
+		client, err := CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL), WithRetry(0, 0), WithCache(cache))
+		if err != nil {
+			t.Fatalf("Unable to create client %v", err)
+		}
+		persons, err := client.SearchPerson(SearchPersonQuery{FirstName: "Karel", Surname: "Novák", DateOfBirth: time.Date(1951, 5, 12, 0, 0, 0, 0, time.UTC)})
+		if err != nil {
+			t.Fatalf("Unable to search person %v", err)
+		}
+		subjects, err := client.SearchSubject(SearchSubjectQuery{Ico: "73452301"})
+		if err != nil {
+			t.Fatalf("Unable to search subject %v", err)
+		}
+		detail, err := client.GetSubjectDetails(subjects.Subjects[0].Ssarzp)
+		if err != nil {
+			t.Fatalf("Received unexpected error %v", err)
+		}
+		return persons, detail
+	}
+
+	persons, detail := lookup()
+	fetched := requests.Load()
+	cachedPersons, cachedDetail := lookup()
+
+	if requests.Load() != fetched {
+		t.Errorf("Expected no requests when answered from cache, got %d", requests.Load()-fetched)
+	}
+	if len(cachedPersons.People) != len(persons.People) || len(persons.People) == 0 {
+		t.Fatalf("Expected %d cached persons, got %d", len(persons.People), len(cachedPersons.People))
+	}
+	if !time.Time(cachedPersons.People[0].DateOfBirth).Equal(time.Time(persons.People[0].DateOfBirth)) {
+		t.Errorf("Expected birth date %v, got %v", time.Time(persons.People[0].DateOfBirth), time.Time(cachedPersons.People[0].DateOfBirth))
+	}
+	if cachedDetail.Ico != detail.Ico || !cachedDetail.BirthDate.Equal(detail.BirthDate) || len(cachedDetail.Trades) != len(detail.Trades) {
+		t.Errorf("Expected cached detail %+v, got %+v", detail, cachedDetail)
+	}
+}
+
+func Test_WithCache_ReResolvesCachedSsarzp(t *testing.T) {
+	t.Parallel()
+	var icoSearches atomic.Int32
+	server := flakyServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Query().Get("s-ico") != "" {
+			icoSearches.Add(1)
+		}
+		return false
+	})
+	cache := &memoryCache{entries: map[string][]byte{}}
+	client, err := CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL), WithRetry(0, 0), WithCache(cache))
+	if err != nil {
+		t.Fatalf("Unable to create client %v", err)
+	}
+	if _, err := client.SearchSubject(SearchSubjectQuery{Ico: "73452301"}); err != nil {
+		t.Fatalf("Unable to search subject %v", err)
+	}
+
+	client, err = CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL), WithRetry(0, 0), WithCache(cache))
+	if err != nil {
+		t.Fatalf("Unable to create client %v", err)
+	}
+	subjects, err := client.SearchSubject(SearchSubjectQuery{Ico: "73452301"})
+	if err != nil {
+		t.Fatalf("Unable to search subject %v", err)
+	}
+	if icoSearches.Load() != 1 {
+		t.Fatalf("Expected second search to be answered from cache, got %d searches", icoSearches.Load())
+	}
+	cache.drop(CacheEndpointSubjectDetails)
+
+	detail, err := client.GetSubjectDetails(subjects.Subjects[0].Ssarzp)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if detail.Ico != "73452301" {
+		t.Errorf("Expected details of 73452301, got %s", detail.Ico)
+	}
+	if icoSearches.Load() != 2 {
+		t.Errorf("Expected cached ssarzp to be re-resolved by searching ICO again, got %d searches", icoSearches.Load())
+	}
+}
@@ -0,0 +1,34 @@
+package rzp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/fstaffa/czsnoop/internal/registry"
+)
+
+var (
+	// ErrNotFound is returned when RZP does not know the requested subject or document
+	ErrNotFound = registry.ErrNotFound
+	// ErrRateLimited is returned when RZP keeps rejecting requests with 429 Too Many Requests
+	ErrRateLimited = registry.ErrRateLimited
+	// ErrSessionExpired is returned when RZP rejects the session even after it was renewed
+	ErrSessionExpired = errors.New("RZP session expired")
+	// ErrTooManyMatches marks searches matching more results than RZP is willing to return, see MorePossibleMatches
+	ErrTooManyMatches = errors.New("too many possible matches, please provide more details")
+)
+
+// HTTPStatusError is returned when RZP responds with unexpected status code
+type HTTPStatusError = registry.HTTPStatusError
+
+// SchemaError is returned when response of RZP does not match the expected structure
+type SchemaError = registry.SchemaError
+
+// newHTTPStatusError marks statuses rejecting the session with ErrSessionExpired
+func newHTTPStatusError(resp *http.Response) *HTTPStatusError {
+	err := registry.NewHTTPStatusError(resp)
+	if isSessionExpired(resp.StatusCode) {
+		err.Err = ErrSessionExpired
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package rzp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_HTTPStatusError_Is(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		statusCode int
+		target     error
+		expected   bool
+	}{
+		"not found":          {statusCode: http.StatusNotFound, target: ErrNotFound, expected: true},
+		"rate limited":       {statusCode: http.StatusTooManyRequests, target: ErrRateLimited, expected: true},
+		"unauthorized":       {statusCode: http.StatusUnauthorized, target: ErrSessionExpired, expected: true},
+		"forbidden":          {statusCode: http.StatusForbidden, target: ErrSessionExpired, expected: true},
+		"server error":       {statusCode: http.StatusInternalServerError, target: ErrNotFound, expected: false},
+		"not too many match": {statusCode: http.StatusNotFound, target: ErrTooManyMatches, expected: false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: test.statusCode, Body: io.NopCloser(strings.NewReader("")), Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+			err := fmt.Errorf("wrapped: %w", newHTTPStatusError(resp))
+			if errors.Is(err, test.target) != test.expected {
+				t.Errorf("Expected errors.Is to be %v", test.expected)
+			}
+		})
+	}
+}
+
+func Test_GetSubjectDetails_NotFound(t *testing.T) {
+	t.Parallel()
+
+	_, err := rzp.GetSubjectDetails("unknown")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Expected HTTPStatusError, got %v", err)
+	}
+	if statusErr.StatusCode != http.StatusNotFound || statusErr.Body == "" {
+		t.Errorf("Expected status code and body to be kept, got %d and '%s'", statusErr.StatusCode, statusErr.Body)
+	}
+}
+
+func Test_SearchPerson_SchemaError(t *testing.T) {
+	t.Parallel()
+	server := flakyServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if !strings.HasSuffix(r.URL.Path, "/osoby") {
+			return false
+		}
+		_, _ = w.Write([]byte(`{"osoby": "changed"}`))
+		return true
+	})
+	client, err := CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL))
+	if err != nil {
+		t.Fatalf("Unable to create client %v", err)
+	}
+
+	_, err = client.SearchPerson(SearchPersonQuery{Surname: "novak"})
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Expected SchemaError, got %v", err)
+	}
+	if schemaErr.Path != "osoby" {
+		t.Errorf("Expected path osoby, got %s", schemaErr.Path)
+	}
+}
+
+func Test_SearchPerson_RateLimited(t *testing.T) {
+	t.Parallel()
+	server := flakyServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if !strings.HasSuffix(r.URL.Path, "/osoby") {
+			return false
+		}
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+		return true
+	})
+	client, err := CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL), WithRetry(1, time.Millisecond))
+	if err != nil {
+		t.Fatalf("Unable to create client %v", err)
+	}
+
+	_, err = client.SearchPerson(SearchPersonQuery{Surname: "novak"})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package rzp
+
+import (
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/rzp/statement"
+	"github.com/fstaffa/czsnoop/internal/types"
+)
+
+// NaturalPerson is a person mentioned in the statement
+type NaturalPerson struct {
+	FullNameWithTitles string
+	FirstName          string
+	LastName           string
+	TitleBeforeName    string
+	TitleAfterName     string
+	// BirthDate is zero when the registry does not state it
+	BirthDate   time.Time
+	Citizenship string
+}
+
+// LegalEntityDetail describes legal entity (právnická osoba), subject with type P
+type LegalEntityDetail struct {
+	Name string
+	// LegalForm as stated by the registry, e.g. "Společnost s ručením omezeným"
+	LegalForm       string
+	Seat            string
+	StatutoryBodies []StatutoryBody
+	// ResponsibleRepresentatives of the entity, representatives of single trades are in Trade
+	ResponsibleRepresentatives []ResponsibleRepresentative
+}
+
+// StatutoryBody (statutární orgán) acts on behalf of the legal entity
+type StatutoryBody struct {
+	// Name of the body, e.g. "Jednatel" or "Představenstvo"
+	Name string
+	// MannerOfActing (způsob jednání) describes who may sign on behalf of the entity
+	MannerOfActing string
+	Members        []StatutoryMember
+}
+
+type StatutoryMember struct {
+	// Function in the body as stated by the registry, e.g. "jednatel" or "předseda představenstva"
+	Function string
+	// NaturalPerson is empty when the member is a legal entity
+	NaturalPerson
+	// EntityName and EntityIco identify member which is a legal entity
+	EntityName string
+	EntityIco  types.Ico
+	Period
+}
+
+const legalEntityPath = "listiny/verweb/PodnikatelDetail"
+
+func parseLegalEntity(detail statement.PodnikatelDetail) (*LegalEntityDetail, error) {
+	bodies := make([]StatutoryBody, 0, len(detail.SeznamStatutarnichOrganu.StatutarniOrgan))
+	for _, organ := range detail.SeznamStatutarnichOrganu.StatutarniOrgan {
+		path := legalEntityPath + "/SeznamStatutarnichOrganu/StatutarniOrgan/Clen"
+		members := make([]StatutoryMember, 0, len(organ.Clen))
+		for _, clen := range organ.Clen {
+			period, err := parsePeriod(path, clen.Od, clen.Do)
+			if err != nil {
+				return nil, err
+			}
+			person, err := parseNaturalPerson(path+"/ZucastnenaOsobaDetail", clen.ZucastnenaOsobaDetail)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, StatutoryMember{
+				Function:      clen.Funkce,
+				NaturalPerson: person,
+				EntityName:    clen.ZucastnenaPravnickaOsoba.ObchodniJmeno,
+				EntityIco:     types.Ico(clen.ZucastnenaPravnickaOsoba.IdentifikacniCislo),
+				Period:        period,
+			})
+		}
+		bodies = append(bodies, StatutoryBody{
+			Name:           organ.Nazev,
+			MannerOfActing: organ.ZpusobJednani,
+			Members:        members,
+		})
+	}
+
+	representatives, err := parseResponsibleRepresentatives(legalEntityPath+"/SeznamOdpovednychZastupcu/OdpovednyZastupce", detail.SeznamOdpovednychZastupcu)
+	if err != nil {
+		return nil, err
+	}
+	return &LegalEntityDetail{
+		Name:                       detail.ObchodniJmeno,
+		LegalForm:                  detail.PravniForma.Hodnota,
+		Seat:                       detail.AdresaPodnikani.PlatnostAdresy.ZmenaAdresy.TextAdresy,
+		StatutoryBodies:            bodies,
+		ResponsibleRepresentatives: representatives,
+	}, nil
+}
+
+func parseNaturalPerson(path string, osoba statement.ZucastnenaOsobaDetail) (NaturalPerson, error) {
+	birthDate, err := parseDate(path+"/DatumNarozeni", osoba.DatumNarozeni.Hodnota)
+	if err != nil {
+		return NaturalPerson{}, err
+	}
+	return NaturalPerson{
+		FullNameWithTitles: osoba.JmenoPrijmeni.Hodnota,
+		FirstName:          osoba.Jmeno.Hodnota,
+		LastName:           osoba.Prijmeni.Hodnota,
+		TitleBeforeName:    osoba.TitulPredJmenem.Hodnota,
+		TitleAfterName:     osoba.TitulZaJmenem.Hodnota,
+		BirthDate:          birthDate,
+		Citizenship:        osoba.Obcanstvi.Hodnota,
+	}, nil
+}
+
+// isLegalEntity decides by subject type from search, statements of legal entities have no PodnikatelOsoba
+// which is used when the subject type is not known
+func isLegalEntity(subjectType string, detail statement.PodnikatelDetail) bool {
+	if subjectType != "" {
+		return subjectType == "P"
+	}
+	return detail.PodnikatelOsoba.ZucastnenaOsobaDetail.JmenoPrijmeni.Hodnota == ""
+}
@@ -0,0 +1,20 @@
+package rzp
+
+import "github.com/fstaffa/czsnoop/internal/registry"
+
+// DefaultConcurrency is the default maximum number of requests in flight to RZP
+const DefaultConcurrency = registry.DefaultConcurrency
+
+// WithConcurrency limits number of requests in flight, request is in flight until its response body is closed
+func WithConcurrency(concurrency int) Option {
+	return func(o *clientOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+// WithRateLimit limits number of requests started per second using token bucket, 0 means unlimited
+func WithRateLimit(requestsPerSecond float64) Option {
+	return func(o *clientOptions) {
+		o.requestsPerSecond = requestsPerSecond
+	}
+}
@@ -0,0 +1,35 @@
+package rzp
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/registry"
+)
+
+func Test_GetSubjectDetails_SingleConcurrencySlot(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := CreateClient(ctx, slog.Default(), append(testOptions, WithConcurrency(1))...)
+	if err != nil {
+		t.Fatalf("Unable to create client %v", err)
+	}
+
+	result, err := client.SearchSubject(SearchSubjectQuery{Name: "ing phd novak", Role: SubjectRoleEntrepreneur})
+	if err != nil {
+		t.Fatalf("Unable to search subject %v", err)
+	}
+	detail, err := client.GetSubjectDetails(result.Subjects[0].Ssarzp)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if detail.Ico != result.Subjects[0].Ico {
+		t.Errorf("Expected ICO to be %s, got %s", result.Subjects[0].Ico, detail.Ico)
+	}
+	if held := client.client.Transport.(*registry.LimitedTransport).InFlight(); held != 0 {
+		t.Errorf("Expected all requests to be released, %d still held", held)
+	}
+}
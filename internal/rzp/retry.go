@@ -0,0 +1,165 @@
+package rzp
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/registry"
+	"github.com/fstaffa/czsnoop/internal/types"
+)
+
+// WithRetry sets how many times are failed requests retried and the initial backoff delay,
+// the delay doubles with each retry up to 30 seconds
+func WithRetry(maxRetries int, baseDelay time.Duration) Option {
+	return func(o *clientOptions) {
+		o.retry.MaxRetries = maxRetries
+		o.retry.BaseDelay = baseDelay
+	}
+}
+
+type session struct {
+	id         string
+	generation int
+}
+
+func (r *Rzp) session() session {
+	r.sessionMu.RLock()
+	defer r.sessionMu.RUnlock()
+	return session{id: r.sessionId, generation: r.generation}
+}
+
+// renewSession starts new session unless it was already renewed since stale session was used
+func (r *Rzp) renewSession(stale session) error {
+	r.renewMu.Lock()
+	defer r.renewMu.Unlock()
+	if r.session().generation != stale.generation {
+		return nil
+	}
+	sessionId, err := r.getSessionId()
+	if err != nil {
+		return fmt.Errorf("unable to renew session: %w", err)
+	}
+	r.sessionMu.Lock()
+	r.sessionId = sessionId
+	r.generation++
+	r.sessionMu.Unlock()
+	r.logger.DebugContext(r.context, "Renewed RZP session", slog.String("rzpSessionId", sessionId))
+	return nil
+}
+
+func isSessionExpired(statusCode int) bool {
+	return statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
+}
+
+// do sends request created by newRequest with the current session. Network errors, 429 and 5xx responses
+// are retried with jittered exponential backoff honoring Retry-After. When the server rejects the session,
+// new session is started and the request is created again. All requests of the client are idempotent GETs.
+func (r *Rzp) do(newRequest func(session) (*http.Request, error), withSession bool) (*http.Response, error) {
+	renewed := false
+	for attempt := 0; ; attempt++ {
+		current := r.session()
+		req, err := newRequest(current)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create request: %w", err)
+		}
+
+		resp, err := r.client.Do(req)
+		var retryAfter time.Duration
+		switch {
+		case err != nil:
+			if r.context.Err() != nil || attempt >= r.retry.MaxRetries {
+				return nil, err
+			}
+			r.logger.DebugContext(r.context, "Request failed, retrying", slog.String("url", req.URL.String()), slog.Any("error", err))
+		case withSession && !renewed && isSessionExpired(resp.StatusCode):
+			registry.Discard(resp)
+			r.logger.DebugContext(r.context, "Session rejected, renewing", slog.String("url", req.URL.String()), slog.Int("status", resp.StatusCode))
+			if err := r.renewSession(current); err != nil {
+				return nil, err
+			}
+			renewed = true
+			continue
+		case registry.IsRetryable(resp.StatusCode) && attempt < r.retry.MaxRetries:
+			retryAfter = registry.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			registry.Discard(resp)
+			r.logger.DebugContext(r.context, "Server unavailable, retrying", slog.String("url", req.URL.String()), slog.Int("status", resp.StatusCode), slog.Duration("retryAfter", retryAfter))
+		default:
+			return resp, nil
+		}
+
+		if err := registry.Sleep(r.context, r.retry.Backoff(attempt, retryAfter)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type ssarzpOrigin struct {
+	generation  int
+	ico         types.Ico
+	name        string
+	subjectType string
+	// includeHistorical is set when the Ssarzp was found among expired records too
+	includeHistorical bool
+	// current is Ssarzp valid in the session generation
+	current Ssarzp
+}
+
+func (r *Rzp) rememberSsarzps(subjects []Subject, generation int, includeHistorical bool) {
+	r.ssarzpMu.Lock()
+	defer r.ssarzpMu.Unlock()
+	for _, subject := range subjects {
+		r.ssarzps[subject.Ssarzp] = ssarzpOrigin{generation: generation, ico: subject.Ico, name: subject.Name, subjectType: subject.Type,
+			includeHistorical: includeHistorical, current: subject.Ssarzp}
+	}
+}
+
+// origin returns subject the Ssarzp was obtained for, false if it did not come from SearchSubject
+func (r *Rzp) origin(ssarzp Ssarzp) (ssarzpOrigin, bool) {
+	r.ssarzpMu.Lock()
+	defer r.ssarzpMu.Unlock()
+	origin, ok := r.ssarzps[ssarzp]
+	return origin, ok
+}
+
+// currentSsarzp returns Ssarzp valid in the current session. Ssarzp obtained in an older session
+// is re-resolved by searching the subject by its ICO again, among expired records too if it was found so.
+func (r *Rzp) currentSsarzp(ssarzp Ssarzp, current session) (Ssarzp, error) {
+	origin, ok := r.origin(ssarzp)
+	if !ok || origin.ico == "" {
+		return ssarzp, nil
+	}
+	if origin.generation == current.generation {
+		return origin.current, nil
+	}
+
+	r.logger.DebugContext(r.context, "Re-resolving ssarzp from expired session", slog.String("ico", string(origin.ico)))
+	result, err := r.searchSubject(SearchSubjectQuery{Ico: origin.ico, IncludeHistorical: origin.includeHistorical}, false)
+	if err != nil {
+		return "", fmt.Errorf("unable to re-resolve subject %s after session renewal: %w", origin.ico, err)
+	}
+	var fresh Ssarzp
+	for _, subject := range result.Subjects {
+		if subject.Ico != origin.ico {
+			continue
+		}
+		if subject.Name == origin.name && subject.Type == origin.subjectType {
+			fresh = subject.Ssarzp
+			break
+		}
+		if fresh == "" {
+			fresh = subject.Ssarzp
+		}
+	}
+	if fresh == "" {
+		return "", fmt.Errorf("subject %s %w after session renewal", origin.ico, ErrNotFound)
+	}
+
+	r.ssarzpMu.Lock()
+	defer r.ssarzpMu.Unlock()
+	origin.generation = r.ssarzps[fresh].generation
+	origin.current = fresh
+	r.ssarzps[ssarzp] = origin
+	return fresh, nil
+}
@@ -0,0 +1,203 @@
+package rzp
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/rzp/rzptest"
+)
+
+// flakyServer serves fixtures but lets middleware decide to answer requests itself
+func flakyServer(t *testing.T, middleware func(w http.ResponseWriter, r *http.Request) bool) *httptest.Server {
+	t.Helper()
+	return flakyServerWithFixtures(t, rzptest.Fixtures, middleware)
+}
+
+func flakyServerWithFixtures(t *testing.T, fixtures []rzptest.Fixture, middleware func(w http.ResponseWriter, r *http.Request) bool) *httptest.Server {
+	t.Helper()
+	handler, err := rzptest.NewHandler(fixtures)
+	if err != nil {
+		t.Fatalf("Unable to create fake RZP %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if middleware(w, r) {
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_do_RetriesTransientFailures(t *testing.T) {
+	t.Parallel()
+	var attempts atomic.Int32
+	server := flakyServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if !strings.HasSuffix(r.URL.Path, "/osoby") {
+			return false
+		}
+		switch attempts.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return true
+		case 2:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return true
+		}
+		return false
+	})
+	client, err := CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL), WithRetry(3, time.Millisecond))
+	if err != nil {
+		t.Fatalf("Unable to create client %v", err)
+	}
+
+	res, err := client.SearchPerson(SearchPersonQuery{Surname: "novak"})
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if len(res.People) == 0 {
+		t.Errorf("Expected at least one person")
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts.Load())
+	}
+}
+
+func Test_do_GivesUpAfterMaxRetries(t *testing.T) {
+	t.Parallel()
+	var attempts atomic.Int32
+	server := flakyServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if !strings.HasSuffix(r.URL.Path, "/osoby") {
+			return false
+		}
+		attempts.Add(1)
+		http.Error(w, "unavailable", http.StatusBadGateway)
+		return true
+	})
+	client, err := CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL), WithRetry(2, time.Millisecond))
+	if err != nil {
+		t.Fatalf("Unable to create client %v", err)
+	}
+
+	_, err = client.SearchPerson(SearchPersonQuery{Surname: "novak"})
+	if err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("Expected initial attempt and 2 retries, got %d", attempts.Load())
+	}
+}
+
+func Test_do_RenewsExpiredSession(t *testing.T) {
+	t.Parallel()
+	var expired atomic.Bool
+	var sessionStarts atomic.Int32
+	var icoSearches atomic.Int32
+	server := flakyServer(t, func(w http.ResponseWriter, r *http.Request) bool {
+		if strings.HasSuffix(r.URL.Path, "/session/v1/start") {
+			sessionStarts.Add(1)
+			expired.Store(false)
+			return false
+		}
+		if expired.Load() {
+			http.Error(w, "session expired", http.StatusUnauthorized)
+			return true
+		}
+		if r.URL.Query().Get("s-ico") != "" {
+			icoSearches.Add(1)
+		}
+		return false
+	})
+	client, err := CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL), WithRetry(0, 0))
+	if err != nil {
+		t.Fatalf("Unable to create client %v", err)
+	}
+
+	subjects, err := client.SearchSubject(SearchSubjectQuery{Ico: "73452301"})
+	if err != nil {
+		t.Fatalf("Unable to search subject %v", err)
+	}
+	if len(subjects.Subjects) != 1 {
+		t.Fatalf("Expected exactly one subject, got %d", len(subjects.Subjects))
+	}
+	expired.Store(true)
+
+	detail, err := client.GetSubjectDetails(subjects.Subjects[0].Ssarzp)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if detail.Ico != "73452301" {
+		t.Errorf("Expected details of 73452301, got %s", detail.Ico)
+	}
+	if sessionStarts.Load() != 2 {
+		t.Errorf("Expected session to be started twice, got %d", sessionStarts.Load())
+	}
+	if icoSearches.Load() != 2 {
+		t.Errorf("Expected ssarzp to be re-resolved by searching ICO again, got %d searches", icoSearches.Load())
+	}
+	if client.session().generation != 1 {
+		t.Errorf("Expected session generation 1, got %d", client.session().generation)
+	}
+}
+
+func Test_do_RenewsExpiredSessionOfHistoricalSubject(t *testing.T) {
+	t.Parallel()
+	fixtures := slices.Clone(rzptest.Fixtures)
+	for i, fixture := range fixtures {
+		if fixture.Query == "s-ico=24681351&s-presvyber=true" {
+			fixtures[i].File = "subjekty_24681351_historicke.json"
+		}
+	}
+	var expired atomic.Bool
+	var icoSearches []string
+	var mu sync.Mutex
+	server := flakyServerWithFixtures(t, fixtures, func(w http.ResponseWriter, r *http.Request) bool {
+		if strings.HasSuffix(r.URL.Path, "/session/v1/start") {
+			expired.Store(false)
+			return false
+		}
+		if expired.Load() {
+			http.Error(w, "session expired", http.StatusUnauthorized)
+			return true
+		}
+		if r.URL.Query().Get("s-ico") != "" {
+			mu.Lock()
+			icoSearches = append(icoSearches, r.URL.RawQuery)
+			mu.Unlock()
+		}
+		return false
+	})
+	client, err := CreateClient(context.Background(), slog.Default(), WithBaseUrl(server.URL), WithRetry(0, 0))
+	if err != nil {
+		t.Fatalf("Unable to create client %v", err)
+	}
+
+	subjects, err := client.SearchSubject(SearchSubjectQuery{PersonId: "5501001", IncludeHistorical: true})
+	if err != nil {
+		t.Fatalf("Unable to search subject %v", err)
+	}
+	if len(subjects.Subjects) != 2 || time.Time(subjects.Subjects[1].ValidTo).IsZero() {
+		t.Fatalf("Expected expired subject, got %+v", subjects.Subjects)
+	}
+	expired.Store(true)
+
+	detail, err := client.GetSubjectDetails(subjects.Subjects[1].Ssarzp)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if detail.Ico != "24681351" {
+		t.Errorf("Expected details of 24681351, got %s", detail.Ico)
+	}
+	if len(icoSearches) != 1 || strings.Contains(icoSearches[0], "pouzeplatne") {
+		t.Errorf("Expected ssarzp to be re-resolved among expired records, got searches %v", icoSearches)
+	}
+}
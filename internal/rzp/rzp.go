@@ -9,74 +9,131 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/fstaffa/czsnoop/internal/registry"
 	"github.com/fstaffa/czsnoop/internal/rzp/statement"
 	"github.com/fstaffa/czsnoop/internal/rzp/subject-details"
 	"github.com/fstaffa/czsnoop/internal/types"
 	"golang.org/x/text/encoding/ianaindex"
 )
 
-const baseUrl = "https://www.rzp.cz"
+const defaultBaseUrl = "https://www.rzp.cz"
 const dateFormat = "02.01.2006"
 
 type Rzp struct {
+	baseUrl string
+	client  http.Client
+	logger  *slog.Logger
+	context context.Context
+	retry   registry.RetryPolicy
+	cache   registry.ResponseCache
+
+	sessionMu sync.RWMutex
 	sessionId string
-	client    http.Client
-	logger    *slog.Logger
-	context   context.Context
+	// generation is incremented every time the session is renewed
+	generation int
+	// renewMu serializes session renewals
+	renewMu sync.Mutex
+
+	ssarzpMu sync.Mutex
+	ssarzps  map[Ssarzp]ssarzpOrigin
 }
 
-// Ssarzp field seems to be bound to session
-type Ssarzp string
+type clientOptions struct {
+	baseUrl           string
+	transport         http.RoundTripper
+	concurrency       int
+	requestsPerSecond float64
+	retry             registry.RetryPolicy
+	cache             Cache
+}
 
-func CreateClient(ctx context.Context, logger *slog.Logger) (*Rzp, error) {
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		return nil, fmt.Errorf("unable to create cookie jar for client: %v", err)
+type Option func(*clientOptions)
+
+// WithBaseUrl points the client to a different RZP instance, e.g. local fake server in tests
+func WithBaseUrl(baseUrl string) Option {
+	return func(o *clientOptions) {
+		o.baseUrl = strings.TrimSuffix(baseUrl, "/")
 	}
+}
 
+// WithTransport replaces the default HTTP transport of the client
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *clientOptions) {
+		o.transport = transport
+	}
+}
+
+// Ssarzp field seems to be bound to session, the client re-resolves Ssarzp values
+// obtained from SearchSubject when the session is renewed
+type Ssarzp string
+
+// DefaultTransport returns transport used by the client unless WithTransport is given
+func DefaultTransport() *http.Transport {
 	transport := http.DefaultTransport.(*http.Transport).Clone()
 	transport.MaxIdleConns = 100
 	transport.MaxIdleConnsPerHost = 10
 	transport.MaxConnsPerHost = 10
+	return transport
+}
+
+func CreateClient(ctx context.Context, logger *slog.Logger, options ...Option) (*Rzp, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create cookie jar for client: %w", err)
+	}
+
+	opts := clientOptions{baseUrl: defaultBaseUrl, retry: registry.DefaultRetryPolicy}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.transport == nil {
+		opts.transport = DefaultTransport()
+	}
+	transport := registry.NewLimitedTransport(opts.transport, opts.concurrency, opts.requestsPerSecond)
 	client := http.Client{Jar: jar, Timeout: 60 * time.Second, Transport: transport}
-	sessionId, err := getSessionId(&client, ctx)
+	r := &Rzp{
+		baseUrl: opts.baseUrl,
+		client:  client,
+		logger:  logger,
+		context: ctx,
+		retry:   opts.retry,
+		cache:   registry.ResponseCache{Cache: opts.cache, Logger: logger},
+		ssarzps: map[Ssarzp]ssarzpOrigin{},
+	}
+	sessionId, err := r.getSessionId()
 	if err != nil {
-		return nil, fmt.Errorf("unable to get session id: %v", err)
+		return nil, fmt.Errorf("unable to get session id: %w", err)
 	}
+	r.sessionId = sessionId
 	logger.DebugContext(ctx, "Created RZP client", slog.String("rzpSessionId", sessionId))
-	return &Rzp{
-		sessionId: sessionId,
-		client:    client,
-		logger:    logger,
-		context:   ctx,
-	}, nil
+	return r, nil
 }
 
 type sessionResponse struct {
 	SessionId string `json:"sesid"`
 }
 
-func getSessionId(c *http.Client, context context.Context) (string, error) {
-	req, err := http.NewRequestWithContext(context, http.MethodGet, "https://www.rzp.cz/rzp/api-c/srv/session/v1/start", nil)
-
-	if err != nil {
-		return "", fmt.Errorf("unable to create request: %v", err)
-	}
-	resp, err := c.Do(req)
+func (r *Rzp) getSessionId() (string, error) {
+	resp, err := r.do(func(session) (*http.Request, error) {
+		return http.NewRequestWithContext(r.context, http.MethodGet, r.baseUrl+"/rzp/api-c/srv/session/v1/start", nil)
+	}, false)
 	if err != nil {
-		return "", fmt.Errorf("unable to do request: %v", err)
+		return "", fmt.Errorf("unable to do request: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d and status %s", resp.StatusCode, resp.Status)
+		return "", newHTTPStatusError(resp)
 	}
 
 	var sessionResponse sessionResponse
 	err = json.NewDecoder(resp.Body).Decode(&sessionResponse)
 	if err != nil {
-		return "", fmt.Errorf("unable to unmarshal response: %v", err)
+		return "", &SchemaError{Path: "session", Err: err}
 	}
 	return sessionResponse.SessionId, nil
 }
@@ -88,30 +145,70 @@ type Subject struct {
 	Ssarzp  Ssarzp    `json:"ssarzp"`
 	// either P for Legal Entity or F for natural person
 	Type string `json:"typ"`
+	// ValidFrom and ValidTo bound the record of the subject, ValidTo is zero while the record is valid.
+	// Records which are no longer valid are returned only with IncludeHistorical.
+	ValidFrom Iso8601Date `json:"platnostOd"`
+	ValidTo   Iso8601Date `json:"platnostDo"`
 }
 
 type SearchSubjectResponse struct {
+	// MorePossibleMatches is set when RZP returned only some of the matching subjects
 	MorePossibleMatches bool      `json:"seznamNeniKompletni"`
 	Subjects            []Subject `json:"subjekty"`
 }
 
+// SubjectRole restricts subject search to subjects in which the person has the role
+type SubjectRole string
+
+const (
+	// SubjectRoleAny does not restrict the role, zero value behaves the same
+	SubjectRoleAny SubjectRole = "any"
+	// SubjectRoleEntrepreneur finds subjects the person trades as
+	SubjectRoleEntrepreneur SubjectRole = "entrepreneur"
+	// SubjectRoleStatutoryBody finds subjects where the person is a member of the statutory body
+	SubjectRoleStatutoryBody SubjectRole = "statutory"
+)
+
+var SubjectRoles = []SubjectRole{SubjectRoleEntrepreneur, SubjectRoleStatutoryBody, SubjectRoleAny}
+
+func ParseSubjectRole(role string) (SubjectRole, error) {
+	names := make([]string, 0, len(SubjectRoles))
+	for _, r := range SubjectRoles {
+		if string(r) == role {
+			return r, nil
+		}
+		names = append(names, string(r))
+	}
+	return "", fmt.Errorf("unknown role %q, expected one of %s", role, strings.Join(names, ", "))
+}
+
+// queryValue returns value of s-role parameter, empty if the role is not restricted
+func (role SubjectRole) queryValue() string {
+	switch role {
+	case SubjectRoleEntrepreneur:
+		return "P"
+	case SubjectRoleStatutoryBody:
+		return "S"
+	}
+	return ""
+}
+
 type SearchSubjectQuery struct {
 	Name           string
 	StartsWithName bool
 	Ico            types.Ico
-	// either 'enterpreneur' or 'statutory body'
-	SubjectType string
-	PersonId    PersonId
+	Role           SubjectRole
+	PersonId       PersonId
+	// IncludeHistorical returns also expired records, e.g. former roles of the person
+	IncludeHistorical bool
 }
 
 func (r *Rzp) SearchSubject(query SearchSubjectQuery) (SearchSubjectResponse, error) {
-	req, err := http.NewRequestWithContext(r.context, http.MethodGet, "https://www.rzp.cz/rzp/api3-c/srv/vw/v1/subjekty", nil)
-	if err != nil {
-		return SearchSubjectResponse{}, fmt.Errorf("unable to create request: %v", err)
-	}
-	req.Header.Set("Sesid", r.sessionId)
-	req.Header.Set("Accept-Language", "cs")
-	q := req.URL.Query()
+	return r.searchSubject(query, true)
+}
+
+func (r *Rzp) searchSubject(query SearchSubjectQuery, useCache bool) (SearchSubjectResponse, error) {
+	q := url.Values{}
 	if query.PersonId != "" {
 		q.Add("o-id", string(query.PersonId))
 	}
@@ -122,39 +219,49 @@ func (r *Rzp) SearchSubject(query SearchSubjectQuery) (SearchSubjectResponse, er
 	q.Add("s-ico", string(query.Ico))
 	// without true, it throws error that last part of word has to be at least 4 characters
 	q.Add("s-presvyber", "true")
-	q.Add("pouzeplatne", "true")
-	if query.SubjectType == "statutory body" {
-		q.Add("s-role", "S")
-	} else if query.SubjectType == "enterpreneur" {
-		q.Add("s-role", "P")
+	if !query.IncludeHistorical {
+		q.Add("pouzeplatne", "true")
+	}
+	if role := query.Role.queryValue(); role != "" {
+		q.Add("s-role", role)
+	}
+	cacheKey := q.Encode()
+	if useCache {
+		var cached SearchSubjectResponse
+		if r.cached(CacheEndpointSubjects, cacheKey, &cached) {
+			r.rememberSsarzps(cached.Subjects, cachedGeneration, query.IncludeHistorical)
+			return cached, nil
+		}
 	}
 
-	req.URL.RawQuery = q.Encode()
-	r.logger.DebugContext(r.context, "Searching for subject", slog.String("url", req.URL.String()))
-	resp, err := r.client.Do(req)
+	var used session
+	resp, err := r.do(func(s session) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(r.context, http.MethodGet, r.baseUrl+"/rzp/api3-c/srv/vw/v1/subjekty?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Sesid", s.id)
+		req.Header.Set("Accept-Language", "cs")
+		r.logger.DebugContext(r.context, "Searching for subject", slog.String("url", req.URL.String()))
+		used = s
+		return req, nil
+	}, true)
 	if err != nil {
-		return SearchSubjectResponse{}, fmt.Errorf("unable to do request: %v", err)
+		return SearchSubjectResponse{}, fmt.Errorf("unable to do request: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		contentB, err := io.ReadAll(resp.Body)
-		var contentS string
-		if err != nil {
-			contentS = "[unable to read search subject error response string]"
-		} else {
-			contentS = string(contentB)
-		}
-
-		return SearchSubjectResponse{}, fmt.Errorf("unexpected status code: %d and status %s, with response %s", resp.StatusCode, resp.Status, contentS)
+		return SearchSubjectResponse{}, newHTTPStatusError(resp)
 	}
 
 	var searchResult SearchSubjectResponse
 	err = json.NewDecoder(resp.Body).Decode(&searchResult)
 	if err != nil {
-
-		return SearchSubjectResponse{}, fmt.Errorf("unable to unmarshal response: %v", err)
+		return SearchSubjectResponse{}, &SchemaError{Path: "subjekty", Err: err}
 	}
 	r.logger.DebugContext(r.context, "Search result", slog.Any("result", searchResult))
+	r.rememberSsarzps(searchResult.Subjects, used.generation, query.IncludeHistorical)
+	r.store(CacheEndpointSubjects, cacheKey, searchResult)
 
 	return searchResult, nil
 }
@@ -170,59 +277,84 @@ type SubjectDetail struct {
 	TitleAfterName     string
 	BirthDate          time.Time
 	Citizenship        string
-}
-
-type Trade struct {
-	TradeType         string
-	DateOfOrigin      time.Time
-	ValidityOfLicense string
+	// trade licensing office keeping the record of the subject
+	RegisteringOffice string
+	// LegalEntity is set for legal entities (subject type P), personal fields above are empty for them
+	LegalEntity *LegalEntityDetail
 }
 
 func (r *Rzp) GetSubjectDetails(ssarzp Ssarzp) (SubjectDetail, error) {
-	req, err := http.NewRequestWithContext(r.context, http.MethodGet, fmt.Sprintf("%s%s%s%s", baseUrl, `/rzp/api3-c/srv/vw/v1/subjekty/isvs/`, ssarzp, ".xml"), nil)
+	cacheKey := r.subjectCacheKey(ssarzp)
+	var cached SubjectDetail
+	if r.cached(CacheEndpointSubjectDetails, cacheKey, &cached) {
+		return cached, nil
+	}
+
+	v, err := r.getSubjectIsvs(ssarzp)
 	if err != nil {
-		return SubjectDetail{}, fmt.Errorf("unable to create request: %v", err)
+		return SubjectDetail{}, err
 	}
-	req.Header.Set("Accept", "text/xml")
-	req.Header.Set("Sesid", r.sessionId)
-	req.Header.Set("Accept-Language", "cs")
-	resp, err := r.client.Do(req)
+
+	origin, _ := r.origin(ssarzp)
+	deeperDetails, err := r.getSubjectStatement(v.Subjekt.Odkazy.VypisXML, origin.subjectType)
 	if err != nil {
-		return SubjectDetail{}, fmt.Errorf("unable to do request: %v", err)
+		return SubjectDetail{}, fmt.Errorf("unable to get deeper subject details: %w", err)
+	}
+	r.store(CacheEndpointSubjectDetails, cacheKey, deeperDetails)
+
+	return deeperDetails, nil
+}
+
+// getSubjectIsvs reads the subject record linking to its statement. The response body is closed before
+// returning, so that the request does not hold a concurrency slot while the statement is requested.
+func (r *Rzp) getSubjectIsvs(ssarzp Ssarzp) (subjectdetails.Vypis, error) {
+	resp, err := r.do(func(s session) (*http.Request, error) {
+		current, err := r.currentSsarzp(ssarzp, s)
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(r.context, http.MethodGet, fmt.Sprintf("%s%s%s%s", r.baseUrl, `/rzp/api3-c/srv/vw/v1/subjekty/isvs/`, current, ".xml"), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "text/xml")
+		req.Header.Set("Sesid", s.id)
+		req.Header.Set("Accept-Language", "cs")
+		return req, nil
+	}, true)
+	if err != nil {
+		return subjectdetails.Vypis{}, fmt.Errorf("unable to do request: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return SubjectDetail{}, fmt.Errorf("unexpected status code: %d and status %s", resp.StatusCode, resp.Status)
+		return subjectdetails.Vypis{}, newHTTPStatusError(resp)
 	}
 
 	var v subjectdetails.Vypis
 	err = xml.NewDecoder(resp.Body).Decode(&v)
 	if err != nil {
-		return SubjectDetail{}, fmt.Errorf("unable to unmarshal response: %v", err)
+		return subjectdetails.Vypis{}, &SchemaError{Path: "Vypis", Err: err}
 	}
-
-	deeperDetails, err := r.getSubjectStatement(v.Subjekt.Odkazy.VypisXML)
-	if err != nil {
-		return SubjectDetail{}, fmt.Errorf("unable to get deeper subject details: %v", err)
-	}
-
-	return deeperDetails, nil
+	return v, nil
 }
 
-func (r *Rzp) getSubjectStatement(path string) (SubjectDetail, error) {
-	req, err := http.NewRequestWithContext(r.context, http.MethodGet, fmt.Sprintf("%s%s", baseUrl, path), nil)
-	if err != nil {
-		return SubjectDetail{}, fmt.Errorf("unable to create deeper subject details request: %v", err)
-	}
-	req.Header.Set("Sesid", r.sessionId)
-	resp, err := r.client.Do(req)
+// getSubjectStatement reads statement of the subject, subjectType is either P, F or empty if not known
+func (r *Rzp) getSubjectStatement(path string, subjectType string) (SubjectDetail, error) {
+	resp, err := r.do(func(s session) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(r.context, http.MethodGet, fmt.Sprintf("%s%s", r.baseUrl, path), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Sesid", s.id)
+		return req, nil
+	}, true)
 	if err != nil {
-		return SubjectDetail{}, fmt.Errorf("unable to do deeper subject details request: %v", err)
+		return SubjectDetail{}, fmt.Errorf("unable to do deeper subject details request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return SubjectDetail{}, fmt.Errorf("unexpected status code: %d and status %s", resp.StatusCode, resp.Status)
+		return SubjectDetail{}, newHTTPStatusError(resp)
 	}
 	var l statement.Listiny
 	decoder := xml.NewDecoder(resp.Body)
@@ -235,26 +367,25 @@ func (r *Rzp) getSubjectStatement(path string) (SubjectDetail, error) {
 	}
 	err = decoder.Decode(&l)
 	if err != nil {
-		return SubjectDetail{}, fmt.Errorf("unable to unmarshal deeper subject details response: %v", err)
+		return SubjectDetail{}, &SchemaError{Path: "listiny", Err: err}
 	}
 
+	// legal entities have no PodnikatelOsoba and foreigners may have blank birth date, personal fields stay empty
 	enterpreneuerDetail := l.Verweb.PodnikatelDetail
-	birthDate, err := time.Parse("02.01.2006", enterpreneuerDetail.PodnikatelOsoba.ZucastnenaOsobaDetail.DatumNarozeni.Hodnota)
+	birthDate, err := parseDate("listiny/verweb/PodnikatelDetail/PodnikatelOsoba/ZucastnenaOsobaDetail/DatumNarozeni", enterpreneuerDetail.PodnikatelOsoba.ZucastnenaOsobaDetail.DatumNarozeni.Hodnota)
 	if err != nil {
-		return SubjectDetail{}, fmt.Errorf("unable to parse birth date in statement detail: %v", err)
+		return SubjectDetail{}, err
 	}
 
-	trades := make([]Trade, 0, len(enterpreneuerDetail.SeznamZivnosti.Zivnost[0].Obor.Vycet.Drive))
-	for _, zivnost := range enterpreneuerDetail.SeznamZivnosti.Zivnost {
-		date, err := time.Parse(dateFormat, zivnost.Vznik)
+	trades, err := parseTrades(enterpreneuerDetail.SeznamZivnosti.Zivnost)
+	if err != nil {
+		return SubjectDetail{}, err
+	}
+	var legalEntity *LegalEntityDetail
+	if isLegalEntity(subjectType, enterpreneuerDetail) {
+		legalEntity, err = parseLegalEntity(enterpreneuerDetail)
 		if err != nil {
-			return SubjectDetail{}, fmt.Errorf("unable to parse date: %v", err)
-		}
-		for _, trade := range zivnost.Obor.Vycet.Drive {
-			trades = append(trades, Trade{
-				TradeType:    trade.Hodnota,
-				DateOfOrigin: date,
-			})
+			return SubjectDetail{}, err
 		}
 	}
 	return SubjectDetail{
@@ -268,6 +399,8 @@ func (r *Rzp) getSubjectStatement(path string) (SubjectDetail, error) {
 		BirthDate:          birthDate,
 		Citizenship:        enterpreneuerDetail.PodnikatelOsoba.ZucastnenaOsobaDetail.Obcanstvi.Hodnota,
 		Trades:             trades,
+		RegisteringOffice:  enterpreneuerDetail.EvidujiciUrad,
+		LegalEntity:        legalEntity,
 	}, nil
 }
 
@@ -275,6 +408,8 @@ type SearchPersonQuery struct {
 	FirstName   string
 	Surname     string
 	DateOfBirth time.Time
+	// IncludeHistorical returns also persons whose roles have all expired
+	IncludeHistorical bool
 }
 
 type SearchPersonResponse struct {
@@ -297,31 +432,14 @@ type Person struct {
 	PersonRole string `json:"roleOsoby"`
 }
 
-type Iso8601Date time.Time
-
-func (d *Iso8601Date) UnmarshalJSON(data []byte) error {
-	var date string
-	err := json.Unmarshal(data, &date)
-	if err != nil {
-		return err
-	}
-	parsed, err := time.Parse("2006-01-02", date)
-	if err != nil {
-		return err
-	}
-	*d = Iso8601Date(parsed)
-	return nil
-}
+// Iso8601Date is a date in YYYY-MM-DD format, empty string and null are zero date
+type Iso8601Date = registry.Iso8601Date
 
 func (r *Rzp) SearchPerson(query SearchPersonQuery) (SearchPersonResponse, error) {
-	req, err := http.NewRequestWithContext(r.context, http.MethodGet, "https://www.rzp.cz/rzp/api3-c/srv/vw/v1/osoby", nil)
-	if err != nil {
-		return SearchPersonResponse{}, fmt.Errorf("unable to create request: %v", err)
+	q := url.Values{}
+	if !query.IncludeHistorical {
+		q.Add("pouzeplatne", "true")
 	}
-	req.Header.Set("Sesid", r.sessionId)
-	req.Header.Set("Accept-Language", "cs")
-	q := req.URL.Query()
-	q.Add("pouzeplatne", "true")
 	if query.FirstName != "" {
 		q.Add("o-jmeno", query.FirstName)
 	}
@@ -331,21 +449,34 @@ func (r *Rzp) SearchPerson(query SearchPersonQuery) (SearchPersonResponse, error
 	if !query.DateOfBirth.IsZero() {
 		q.Add("o-datum", query.DateOfBirth.Format(time.DateOnly))
 	}
-	req.URL.RawQuery = q.Encode()
-	resp, err := r.client.Do(req)
+	cacheKey := q.Encode()
+	var cached SearchPersonResponse
+	if r.cached(CacheEndpointPersons, cacheKey, &cached) {
+		return cached, nil
+	}
+	resp, err := r.do(func(s session) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(r.context, http.MethodGet, r.baseUrl+"/rzp/api3-c/srv/vw/v1/osoby?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Sesid", s.id)
+		req.Header.Set("Accept-Language", "cs")
+		return req, nil
+	}, true)
 	if err != nil {
-		return SearchPersonResponse{}, fmt.Errorf("unable to do search person request: %v", err)
+		return SearchPersonResponse{}, fmt.Errorf("unable to do search person request: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return SearchPersonResponse{}, fmt.Errorf("unexpected status code: %d and status %s", resp.StatusCode, resp.Status)
+		return SearchPersonResponse{}, newHTTPStatusError(resp)
 	}
 
 	var searchResult SearchPersonResponse
 	err = json.NewDecoder(resp.Body).Decode(&searchResult)
 	if err != nil {
-		return SearchPersonResponse{}, fmt.Errorf("unable to unmarshal response: %v", err)
+		return SearchPersonResponse{}, &SchemaError{Path: "osoby", Err: err}
 	}
+	r.store(CacheEndpointPersons, cacheKey, searchResult)
 
 	return searchResult, nil
 }
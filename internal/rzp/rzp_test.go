@@ -2,30 +2,52 @@ package rzp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"testing"
 	"time"
+
+	"github.com/fstaffa/czsnoop/internal/rzp/rzptest"
+	"github.com/fstaffa/czsnoop/internal/types"
 )
 
 var rzp *Rzp
 
+// testOptions point clients to fake RZP unless CZSNOOP_LIVE_TESTS is set
+var testOptions []Option
+
 func TestMain(m *testing.M) {
+	if os.Getenv("CZSNOOP_LIVE_TESTS") == "" {
+		handler, err := rzptest.NewHandler(rzptest.Fixtures)
+		if err != nil {
+			fmt.Printf("Unable to create fake RZP %v", err)
+			os.Exit(1)
+		}
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		testOptions = []Option{WithBaseUrl(server.URL)}
+	}
+
 	var err error
-	rzp, err = CreateClient(context.Background(), slog.Default())
+	rzp, err = CreateClient(context.Background(), slog.Default(), testOptions...)
 	if err != nil {
 		fmt.Printf("Unable to create client %v", err)
 		os.Exit(1)
 	}
 
-	os.Exit(m.Run())
+	code := m.Run()
+	if code != 0 {
+		os.Exit(code)
+	}
 }
 
 func Test_CreateClient(t *testing.T) {
 	t.Parallel()
-	rzp, err := CreateClient(context.Background(), slog.Default())
+	rzp, err := CreateClient(context.Background(), slog.Default(), testOptions...)
 	if err != nil {
 		t.Errorf("Received unexpected error %v", err)
 	}
@@ -35,7 +57,7 @@ func Test_CreateClient(t *testing.T) {
 	if rzp.sessionId == "" {
 		t.Errorf("Expected session id to be non-zero")
 	}
-	u, _ := url.Parse(baseUrl)
+	u, _ := url.Parse(rzp.baseUrl)
 	cookies := rzp.client.Jar.Cookies(u)
 	if len(cookies) != 1 {
 		t.Errorf("Expected exactly one cookie")
@@ -55,9 +77,9 @@ func Test_SearchSubject_ByName_Count(t *testing.T) {
 		resultLength                int
 		resultHasMorePosibleMatches bool
 	}{
-		"too many matches": {query: SearchSubjectQuery{Name: "novak", SubjectType: "enterpreneur"}, resultLength: 50, resultHasMorePosibleMatches: true},
-		"no matches":       {query: SearchSubjectQuery{Name: "asdfeeija", SubjectType: "enterpreneur"}, resultLength: 0, resultHasMorePosibleMatches: false},
-		"few matches":      {query: SearchSubjectQuery{Name: "02930366", SubjectType: "enterpreneur"}, resultLength: 0, resultHasMorePosibleMatches: false},
+		"too many matches": {query: SearchSubjectQuery{Name: "novak", Role: SubjectRoleEntrepreneur}, resultLength: 50, resultHasMorePosibleMatches: true},
+		"no matches":       {query: SearchSubjectQuery{Name: "asdfeeija", Role: SubjectRoleEntrepreneur}, resultLength: 0, resultHasMorePosibleMatches: false},
+		"few matches":      {query: SearchSubjectQuery{Name: "02930366", Role: SubjectRoleEntrepreneur}, resultLength: 0, resultHasMorePosibleMatches: false},
 	}
 
 	for name, test := range tests {
@@ -81,7 +103,7 @@ func Test_SearchSubject_ByName_Count(t *testing.T) {
 func Test_SearchSubject_DetailedResult(t *testing.T) {
 	t.Parallel()
 
-	res, err := rzp.SearchSubject(SearchSubjectQuery{Ico: "01895541", SubjectType: "enterpreneur"})
+	res, err := rzp.SearchSubject(SearchSubjectQuery{Ico: "01895541", Role: SubjectRoleEntrepreneur})
 
 	if err != nil {
 		t.Fatalf("Unable to search subject %v", err)
@@ -111,7 +133,7 @@ func Test_SearchSubject_DetailedResult(t *testing.T) {
 func Test_GetSubjectDetails(t *testing.T) {
 	t.Parallel()
 
-	result, err := rzp.SearchSubject(SearchSubjectQuery{Name: "ing phd novak", SubjectType: "enterpreneur"})
+	result, err := rzp.SearchSubject(SearchSubjectQuery{Name: "ing phd novak", Role: SubjectRoleEntrepreneur})
 	if err != nil {
 		t.Fatalf("Unable to search subject %v", err)
 	}
@@ -205,7 +227,7 @@ func Test_SearchPerson_ByName(t *testing.T) {
 func Test_SearchPerson_ParseTitle(t *testing.T) {
 	t.Parallel()
 
-	initialSearch, err := rzp.SearchSubject(SearchSubjectQuery{Name: "novak csc", SubjectType: "enterpreneur"})
+	initialSearch, err := rzp.SearchSubject(SearchSubjectQuery{Name: "novak csc", Role: SubjectRoleEntrepreneur})
 	if err != nil {
 		t.Fatalf("Unable to search subject %v", err)
 	}
@@ -256,3 +278,298 @@ func Test_SearchSubject_ByPersonId(t *testing.T) {
 		t.Fatalf("Expected at least one subject")
 	}
 }
+
+func Test_SearchSubject_IncludeHistorical(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		includeHistorical bool
+		expectedCount     int
+	}{
+		"only valid":      {includeHistorical: false, expectedCount: 1},
+		"with historical": {includeHistorical: true, expectedCount: 2},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			subjects, err := rzp.SearchSubject(SearchSubjectQuery{PersonId: "5501001", IncludeHistorical: test.includeHistorical})
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if len(subjects.Subjects) != test.expectedCount {
+				t.Fatalf("Expected %d subjects, got %d", test.expectedCount, len(subjects.Subjects))
+			}
+			if !test.includeHistorical {
+				return
+			}
+			if current := subjects.Subjects[0]; !time.Time(current.ValidTo).IsZero() {
+				t.Errorf("Expected valid record without end date, got %v", time.Time(current.ValidTo))
+			}
+			expired := subjects.Subjects[1]
+			if expected := time.Date(2012, 6, 30, 0, 0, 0, 0, time.UTC); !time.Time(expired.ValidTo).Equal(expected) {
+				t.Errorf("Expected record valid to %v, got %v", expected, time.Time(expired.ValidTo))
+			}
+		})
+	}
+}
+
+func Test_ParseSubjectRole(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		role          string
+		expected      SubjectRole
+		expectedQuery string
+		expectError   bool
+	}{
+		"entrepreneur":   {role: "entrepreneur", expected: SubjectRoleEntrepreneur, expectedQuery: "P"},
+		"statutory body": {role: "statutory", expected: SubjectRoleStatutoryBody, expectedQuery: "S"},
+		"any":            {role: "any", expected: SubjectRoleAny, expectedQuery: ""},
+		"unknown":        {role: "director", expectError: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			role, err := ParseSubjectRole(test.role)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("Expected error for role %s, got nil", test.role)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if role != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, role)
+			}
+			if role.queryValue() != test.expectedQuery {
+				t.Errorf("Expected s-role %q, got %q", test.expectedQuery, role.queryValue())
+			}
+		})
+	}
+}
+
+func Test_GetSubjectDetails_TradeLicences(t *testing.T) {
+	t.Parallel()
+	result, err := rzp.SearchSubject(SearchSubjectQuery{Name: "ing phd novak", Role: SubjectRoleEntrepreneur})
+	if err != nil {
+		t.Fatalf("Unable to search subject %v", err)
+	}
+	detail, err := rzp.GetSubjectDetails(result.Subjects[0].Ssarzp)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if len(detail.Trades) != 3 {
+		t.Fatalf("Expected 3 trade licences, got %d", len(detail.Trades))
+	}
+
+	free := detail.Trades[0]
+	if free.Number != "1" || free.Kind != TradeKindFree || len(free.Fields) != 2 {
+		t.Errorf("Expected free trade with 2 fields, got %+v", free)
+	}
+	if free.ValidityOfLicense != "na dobu neurčitou" {
+		t.Errorf("Expected validity 'na dobu neurčitou', got '%s'", free.ValidityOfLicense)
+	}
+	if !free.DateOfTermination.IsZero() {
+		t.Errorf("Expected valid trade, got termination %v", free.DateOfTermination)
+	}
+
+	regulated := detail.Trades[1]
+	if regulated.Kind != TradeKindRegulated || regulated.TradeType != "Projektová činnost ve výstavbě" {
+		t.Errorf("Expected regulated trade Projektová činnost ve výstavbě, got %s %s", regulated.Kind, regulated.TradeType)
+	}
+	if len(regulated.Suspensions) != 2 || !regulated.Suspensions[1].To.IsZero() {
+		t.Errorf("Expected finished and ongoing suspension, got %+v", regulated.Suspensions)
+	}
+	if len(regulated.Establishments) != 1 || regulated.Establishments[0].Number != "1012345678" {
+		t.Errorf("Expected establishment 1012345678, got %+v", regulated.Establishments)
+	}
+	if len(regulated.ResponsibleRepresentatives) != 1 {
+		t.Fatalf("Expected 1 responsible representative, got %d", len(regulated.ResponsibleRepresentatives))
+	}
+	representative := regulated.ResponsibleRepresentatives[0]
+	if representative.LastName != "Dvořáková" || representative.BirthDate != time.Date(1970, 2, 2, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("Expected Dvořáková born 1970-02-02, got %s %v", representative.LastName, representative.BirthDate)
+	}
+
+	terminated := detail.Trades[2]
+	if terminated.Kind != TradeKindCraft || terminated.DateOfTermination != time.Date(2018, 6, 30, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("Expected craft trade terminated 2018-06-30, got %s %v", terminated.Kind, terminated.DateOfTermination)
+	}
+}
+
+func Test_parseTradeKind(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		druh     string
+		expected TradeKind
+	}{
+		"free":       {druh: "Volná", expected: TradeKindFree},
+		"craft":      {druh: "Řemeslná", expected: TradeKindCraft},
+		"regulated":  {druh: "Vázaná", expected: TradeKindRegulated},
+		"concession": {druh: " Koncesovaná ", expected: TradeKindConcession},
+		"unknown":    {druh: "Jiná", expected: TradeKind("Jiná")},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := parseTradeKind(test.druh)
+			if actual != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_GetSubjectDetails_StatementShapes(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		ico               types.Ico
+		lastName          string
+		birthDate         time.Time
+		trades            int
+		address           string
+		registeringOffice string
+	}{
+		"legal entity": {
+			ico:               "01895541",
+			trades:            1,
+			address:           "Mazovská 479/8, 181 00, Praha 8 - Troja",
+			registeringOffice: "Úřad městské části Praha 8",
+		},
+		"missing birth date and no trades": {
+			ico:               "27345009",
+			lastName:          "Müller",
+			address:           "Karlova 148/25, 110 00, Praha 1 - Staré Město",
+			registeringOffice: "Úřad městské části Praha 1",
+		},
+		"natural person": {
+			ico:               "73452301",
+			lastName:          "Novák",
+			birthDate:         time.Date(1983, 9, 27, 0, 0, 0, 0, time.UTC),
+			trades:            3,
+			address:           "Sokolovská 352/215, 190 00, Praha 9 - Vysočany",
+			registeringOffice: "Úřad městské části Praha 9",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			result, err := rzp.SearchSubject(SearchSubjectQuery{Ico: test.ico})
+			if err != nil {
+				t.Fatalf("Unable to search subject %v", err)
+			}
+			if len(result.Subjects) != 1 {
+				t.Fatalf("Expected exactly one subject, got %d", len(result.Subjects))
+			}
+			detail, err := rzp.GetSubjectDetails(result.Subjects[0].Ssarzp)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if detail.Ico != test.ico {
+				t.Errorf("Expected ICO %s, got %s", test.ico, detail.Ico)
+			}
+			if detail.LastName != test.lastName {
+				t.Errorf("Expected last name '%s', got '%s'", test.lastName, detail.LastName)
+			}
+			if !detail.BirthDate.Equal(test.birthDate) {
+				t.Errorf("Expected birth date %v, got %v", test.birthDate, detail.BirthDate)
+			}
+			if len(detail.Trades) != test.trades {
+				t.Errorf("Expected %d trades, got %d", test.trades, len(detail.Trades))
+			}
+			if detail.Address != test.address {
+				t.Errorf("Expected address %s, got %s", test.address, detail.Address)
+			}
+			if detail.RegisteringOffice != test.registeringOffice {
+				t.Errorf("Expected registering office %s, got %s", test.registeringOffice, detail.RegisteringOffice)
+			}
+		})
+	}
+}
+
+func Test_parseDate(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		value    string
+		expected time.Time
+		invalid  bool
+	}{
+		"date":    {value: "12.03.2010", expected: time.Date(2010, 3, 12, 0, 0, 0, 0, time.UTC)},
+		"spaces":  {value: " 12.03.2010\n", expected: time.Date(2010, 3, 12, 0, 0, 0, 0, time.UTC)},
+		"blank":   {value: "", expected: time.Time{}},
+		"invalid": {value: "2010-03-12", invalid: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := parseDate("Vznik", test.value)
+			var schemaErr *SchemaError
+			if test.invalid {
+				if !errors.As(err, &schemaErr) || schemaErr.Path != "Vznik" {
+					t.Errorf("Expected schema error of Vznik, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if !actual.Equal(test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_GetSubjectDetails_LegalEntity(t *testing.T) {
+	t.Parallel()
+	result, err := rzp.SearchSubject(SearchSubjectQuery{Ico: "45678910"})
+	if err != nil {
+		t.Fatalf("Unable to search subject %v", err)
+	}
+	detail, err := rzp.GetSubjectDetails(result.Subjects[0].Ssarzp)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	entity := detail.LegalEntity
+	if entity == nil {
+		t.Fatalf("Expected legal entity detail for subject of type P")
+	}
+	if entity.Name != "NOVÁK & PARTNEŘI a.s." || entity.LegalForm != "Akciová společnost" {
+		t.Errorf("Expected NOVÁK & PARTNEŘI a.s. Akciová společnost, got %s %s", entity.Name, entity.LegalForm)
+	}
+	if entity.Seat != "Na Příkopě 1, 110 00, Praha 1 - Staré Město" {
+		t.Errorf("Expected seat Na Příkopě 1, got %s", entity.Seat)
+	}
+	if len(entity.StatutoryBodies) != 1 || entity.StatutoryBodies[0].Name != "Představenstvo" {
+		t.Fatalf("Expected Představenstvo, got %+v", entity.StatutoryBodies)
+	}
+	members := entity.StatutoryBodies[0].Members
+	if len(members) != 3 {
+		t.Fatalf("Expected 3 members, got %d", len(members))
+	}
+	if members[0].Function != "předseda představenstva" || members[0].LastName != "Novák" || members[0].BirthDate != time.Date(1980, 6, 1, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("Expected Jan Novák as chairman, got %+v", members[0])
+	}
+	if members[1].To != time.Date(2014, 12, 31, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("Expected former member until 2014-12-31, got %v", members[1].To)
+	}
+	if members[2].EntityIco != "01895541" || members[2].LastName != "" {
+		t.Errorf("Expected member which is legal entity 01895541, got %+v", members[2])
+	}
+	if detail.LastName != "" {
+		t.Errorf("Expected no entrepreneur name for legal entity, got %s", detail.LastName)
+	}
+}
+
+func Test_GetSubjectDetails_NaturalPersonHasNoLegalEntity(t *testing.T) {
+	t.Parallel()
+	result, err := rzp.SearchSubject(SearchSubjectQuery{Ico: "73452301"})
+	if err != nil {
+		t.Fatalf("Unable to search subject %v", err)
+	}
+	detail, err := rzp.GetSubjectDetails(result.Subjects[0].Ssarzp)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if detail.LegalEntity != nil {
+		t.Errorf("Expected no legal entity detail for natural person, got %+v", detail.LegalEntity)
+	}
+}
@@ -0,0 +1,171 @@
+// Package rzptest provides a local stand-in for www.rzp.cz serving recorded responses,
+// so that RZP client and everything built on top of it can be tested without network.
+package rzptest
+
+import (
+	"embed"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"slices"
+	"testing"
+
+	"github.com/fstaffa/czsnoop/internal/cassette"
+)
+
+//go:embed testdata
+var testdata embed.FS
+
+const SessionId = "0f7c2d2e-5a8e-4b8e-9c3e-2d1f5e7a9b10"
+
+const (
+	jsonContentType = "application/json;charset=UTF-8"
+	xmlContentType  = "text/xml;charset=UTF-8"
+)
+
+// Fixture maps a request to a recorded response file in testdata
+type Fixture struct {
+	Path string
+	// Query is matched after normalization, see cassette.NormalizeQuery
+	Query       string
+	File        string
+	ContentType string
+}
+
+const (
+	sessionPath  = "/rzp/api-c/srv/session/v1/start"
+	personsPath  = "/rzp/api3-c/srv/vw/v1/osoby"
+	subjectsPath = "/rzp/api3-c/srv/vw/v1/subjekty"
+	isvsPath     = "/rzp/api3-c/srv/vw/v1/subjekty/isvs/"
+)
+
+func subjectDetailFixtures(ssarzp string) []Fixture {
+	return []Fixture{
+		{Path: isvsPath + ssarzp + ".xml", File: "isvs_" + ssarzp + ".xml", ContentType: xmlContentType},
+		{Path: isvsPath + ssarzp + "/vypis.xml", File: "listiny_" + ssarzp + ".xml", ContentType: "text/xml;charset=windows-1250"},
+	}
+}
+
+// Fixtures are the recorded responses served by NewServer
+var Fixtures = slices.Concat([]Fixture{
+	{Path: sessionPath, File: "session.json", ContentType: jsonContentType},
+
+	{Path: subjectsPath, Query: "pouzeplatne=true&s-obchjm=novak&s-presvyber=true&s-role=P", File: "subjekty_novak.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "pouzeplatne=true&s-obchjm=asdfeeija&s-presvyber=true&s-role=P", File: "subjekty_empty.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "pouzeplatne=true&s-obchjm=02930366&s-presvyber=true&s-role=P", File: "subjekty_empty.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "pouzeplatne=true&s-ico=01895541&s-presvyber=true&s-role=P", File: "subjekty_01895541.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "pouzeplatne=true&s-obchjm=ing+phd+novak&s-presvyber=true&s-role=P", File: "subjekty_ing_phd_novak.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "pouzeplatne=true&s-obchjm=novak+csc&s-presvyber=true&s-role=P", File: "subjekty_novak_csc.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "pouzeplatne=true&s-ico=73452301&s-presvyber=true", File: "subjekty_ing_phd_novak.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "pouzeplatne=true&s-ico=01895541&s-presvyber=true", File: "subjekty_01895541.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "pouzeplatne=true&s-ico=27345009&s-presvyber=true", File: "subjekty_27345009.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "pouzeplatne=true&s-ico=45678910&s-presvyber=true", File: "subjekty_osoba_5501002.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "pouzeplatne=true&s-ico=24681351&s-presvyber=true", File: "subjekty_empty.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "s-ico=24681351&s-presvyber=true", File: "subjekty_empty.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "pouzeplatne=true&s-ico=27074358&s-presvyber=true", File: "subjekty_empty.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "pouzeplatne=true&s-ico=87654326&s-presvyber=true", File: "subjekty_osoba_5501001.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "o-id=4410217&pouzeplatne=true&s-presvyber=true", File: "subjekty_osoba_4410217.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "o-id=5501001&pouzeplatne=true&s-presvyber=true", File: "subjekty_osoba_5501001.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "o-id=5501002&pouzeplatne=true&s-presvyber=true", File: "subjekty_osoba_5501002.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "o-id=5501001&pouzeplatne=true&s-presvyber=true&s-role=P", File: "subjekty_osoba_5501001.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "o-id=5501001&pouzeplatne=true&s-presvyber=true&s-role=S", File: "subjekty_empty.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "o-id=5501002&pouzeplatne=true&s-presvyber=true&s-role=P", File: "subjekty_empty.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "o-id=5501002&pouzeplatne=true&s-presvyber=true&s-role=S", File: "subjekty_osoba_5501002.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "s-ico=73452301&s-presvyber=true", File: "subjekty_ing_phd_novak.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "s-ico=01895541&s-presvyber=true", File: "subjekty_01895541.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "o-id=5501001&s-presvyber=true", File: "subjekty_osoba_5501001_historicke.json", ContentType: jsonContentType},
+	{Path: subjectsPath, Query: "o-id=5501002&s-presvyber=true", File: "subjekty_osoba_5501002.json", ContentType: jsonContentType},
+
+	{Path: personsPath, Query: "o-prijmeni=novak&pouzeplatne=true", File: "osoby_novak.json", ContentType: jsonContentType},
+	{Path: personsPath, Query: "o-datum=1951-05-12&o-jmeno=Karel&o-prijmeni=Novák&pouzeplatne=true", File: "osoby_karel_novak_1951-05-12.json", ContentType: jsonContentType},
+	{Path: personsPath, Query: "o-jmeno=Jan&o-prijmeni=Novák&pouzeplatne=true", File: "osoby_jan_novak.json", ContentType: jsonContentType},
+	{Path: personsPath, Query: "o-jmeno=Jan&o-prijmeni=Novák", File: "osoby_jan_novak.json", ContentType: jsonContentType},
+	{Path: personsPath, Query: "o-jmeno=Jan&o-prijmeni=Novak&pouzeplatne=true", File: "osoby_empty.json", ContentType: jsonContentType},
+	{Path: personsPath, Query: "o-jmeno=Jan&o-prijmeni=Nováková&pouzeplatne=true", File: "osoby_empty.json", ContentType: jsonContentType},
+	{Path: personsPath, Query: "o-jmeno=Jan&o-prijmeni=Novakova&pouzeplatne=true", File: "osoby_empty.json", ContentType: jsonContentType},
+	{Path: personsPath, Query: "o-datum=1975-03-14&o-jmeno=Jan&o-prijmeni=Novák&pouzeplatne=true", File: "osoby_jan_novak_1975-03-14.json", ContentType: jsonContentType},
+	{Path: personsPath, Query: "o-datum=1980-06-01&o-jmeno=Jan&o-prijmeni=Novák&pouzeplatne=true", File: "osoby_jan_novak_1980-06-01.json", ContentType: jsonContentType},
+	{Path: personsPath, Query: "o-datum=1979-08-01&o-jmeno=Thomas&o-prijmeni=Silvertonni&pouzeplatne=true", File: "osoby_empty.json", ContentType: jsonContentType},
+},
+	subjectDetailFixtures("F4410"),
+	subjectDetailFixtures("F5521"),
+	subjectDetailFixtures("F7701"),
+	subjectDetailFixtures("F8802"),
+	subjectDetailFixtures("P7781"),
+	subjectDetailFixtures("P7702"),
+	subjectDetailFixtures("P7703"),
+)
+
+// PersonFixture serves the recorded file in testdata as response to person search with given query
+func PersonFixture(query url.Values, file string) Fixture {
+	return Fixture{Path: personsPath, Query: query.Encode(), File: file, ContentType: jsonContentType}
+}
+
+// NewServer starts fake RZP serving Fixtures, the server is closed when the test finishes
+func NewServer(t testing.TB) *httptest.Server {
+	t.Helper()
+	return NewServerWithFixtures(t, Fixtures)
+}
+
+// NewServerWithFixtures starts fake RZP serving only given fixtures, the server is closed when the test finishes
+func NewServerWithFixtures(t testing.TB, fixtures []Fixture) *httptest.Server {
+	t.Helper()
+	handler, err := NewHandler(fixtures)
+	if err != nil {
+		t.Fatalf("Unable to create fake RZP: %v", err)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Without returns Fixtures except those served from given files
+func Without(files ...string) []Fixture {
+	result := make([]Fixture, 0, len(Fixtures))
+	for _, fixture := range Fixtures {
+		if !slices.Contains(files, fixture.File) {
+			result = append(result, fixture)
+		}
+	}
+	return result
+}
+
+// NewHandler creates handler of fake RZP serving given fixtures
+func NewHandler(fixtures []Fixture) (http.Handler, error) {
+	index := make(map[string]Fixture, len(fixtures))
+	for _, fixture := range fixtures {
+		query, err := url.ParseQuery(fixture.Query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query in fixture %s: %v", fixture.File, err)
+		}
+		index[fixture.Path+"?"+cassette.NormalizeQuery(query)] = fixture
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.URL.Path != sessionPath && r.Header.Get("Sesid") != SessionId {
+			http.Error(w, "invalid session", http.StatusUnauthorized)
+			return
+		}
+		fixture, ok := index[r.URL.Path+"?"+cassette.NormalizeQuery(r.URL.Query())]
+		if !ok {
+			http.Error(w, "no fixture recorded for "+r.URL.String(), http.StatusNotFound)
+			return
+		}
+		content, err := testdata.ReadFile(path.Join("testdata", fixture.File))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Path == sessionPath {
+			http.SetCookie(w, &http.Cookie{Name: "mseidf", Value: "c5a1e0f2", Path: "/"})
+		}
+		w.Header().Set("Content-Type", fixture.ContentType)
+		_, _ = w.Write(content)
+	}), nil
+}
@@ -27,12 +27,42 @@ type CasVytvoreni struct {
 }
 
 type PodnikatelDetail struct {
+	// PodnikatelOsoba is empty for legal entities
 	PodnikatelOsoba    PodnikatelOsoba    `xml:"PodnikatelOsoba"`
 	ObchodniJmeno      string             `xml:"ObchodniJmeno"`
+	PravniForma        HodnotaWithDesc    `xml:"PravniForma"`
 	AdresaPodnikani    AdresaPodnikani    `xml:"AdresaPodnikani"`
 	IdentifikacniCislo IdentifikacniCislo `xml:"IdentifikacniCislo"`
-	SeznamZivnosti     SeznamZivnosti     `xml:"SeznamZivnosti"`
-	EvidujiciUrad      string             `xml:"EvidujiciUrad"`
+	// SeznamStatutarnichOrganu and SeznamOdpovednychZastupcu are present only for legal entities
+	SeznamStatutarnichOrganu  SeznamStatutarnichOrganu  `xml:"SeznamStatutarnichOrganu"`
+	SeznamOdpovednychZastupcu SeznamOdpovednychZastupcu `xml:"SeznamOdpovednychZastupcu"`
+	SeznamZivnosti            SeznamZivnosti            `xml:"SeznamZivnosti"`
+	EvidujiciUrad             string                    `xml:"EvidujiciUrad"`
+}
+
+type SeznamStatutarnichOrganu struct {
+	Popis           string            `xml:"Popis,attr"`
+	StatutarniOrgan []StatutarniOrgan `xml:"StatutarniOrgan"`
+}
+
+type StatutarniOrgan struct {
+	Nazev         string                   `xml:"Nazev"`
+	ZpusobJednani string                   `xml:"ZpusobJednani"`
+	Clen          []ClenStatutarnihoOrganu `xml:"Clen"`
+}
+
+type ClenStatutarnihoOrganu struct {
+	Funkce                string                `xml:"Funkce"`
+	ZucastnenaOsobaDetail ZucastnenaOsobaDetail `xml:"ZucastnenaOsobaDetail"`
+	// ZucastnenaPravnickaOsoba is filled when the member is a legal entity
+	ZucastnenaPravnickaOsoba ZucastnenaPravnickaOsoba `xml:"ZucastnenaPravnickaOsoba"`
+	Od                       string                   `xml:"Od"`
+	Do                       string                   `xml:"Do"`
+}
+
+type ZucastnenaPravnickaOsoba struct {
+	ObchodniJmeno      string `xml:"ObchodniJmeno"`
+	IdentifikacniCislo string `xml:"IdentifikacniCislo"`
 }
 
 type PodnikatelOsoba struct {
@@ -78,13 +108,50 @@ type SeznamZivnosti struct {
 }
 
 type Zivnost struct {
-	Popis                string          `xml:"Popis,attr"`
-	Predmet              HodnotaWithDesc `xml:"Predmet"`
-	Obor                 Obor            `xml:"Obor"`
-	Druh                 HodnotaWithDesc `xml:"Druh"`
-	Vznik                string          `xml:"Vznik"`
-	PlatnostOpravneni    HodnotaWithDesc `xml:"PlatnostOpravneni"`
-	ZivnostPoradoveCislo string          `xml:"ZivnostPoradoveCislo"`
+	Popis                     string                    `xml:"Popis,attr"`
+	Predmet                   HodnotaWithDesc           `xml:"Predmet"`
+	Obor                      Obor                      `xml:"Obor"`
+	Druh                      HodnotaWithDesc           `xml:"Druh"`
+	Vznik                     string                    `xml:"Vznik"`
+	Zanik                     string                    `xml:"Zanik"`
+	PlatnostOpravneni         HodnotaWithDesc           `xml:"PlatnostOpravneni"`
+	ZivnostPoradoveCislo      string                    `xml:"ZivnostPoradoveCislo"`
+	SeznamPreruseni           SeznamPreruseni           `xml:"SeznamPreruseni"`
+	SeznamProvozoven          SeznamProvozoven          `xml:"SeznamProvozoven"`
+	SeznamOdpovednychZastupcu SeznamOdpovednychZastupcu `xml:"SeznamOdpovednychZastupcu"`
+}
+
+type SeznamPreruseni struct {
+	Popis     string      `xml:"Popis,attr"`
+	Preruseni []Preruseni `xml:"Preruseni"`
+}
+
+type Preruseni struct {
+	Od string `xml:"Od"`
+	Do string `xml:"Do"`
+}
+
+type SeznamProvozoven struct {
+	Popis      string       `xml:"Popis,attr"`
+	Provozovna []Provozovna `xml:"Provozovna"`
+}
+
+type Provozovna struct {
+	IdentifikacniCisloProvozovny string      `xml:"IdentifikacniCisloProvozovny"`
+	Adresa                       ZmenaAdresy `xml:"Adresa"`
+	Zahajeni                     string      `xml:"Zahajeni"`
+	Ukonceni                     string      `xml:"Ukonceni"`
+}
+
+type SeznamOdpovednychZastupcu struct {
+	Popis             string              `xml:"Popis,attr"`
+	OdpovednyZastupce []OdpovednyZastupce `xml:"OdpovednyZastupce"`
+}
+
+type OdpovednyZastupce struct {
+	ZucastnenaOsobaDetail ZucastnenaOsobaDetail `xml:"ZucastnenaOsobaDetail"`
+	Od                    string                `xml:"Od"`
+	Do                    string                `xml:"Do"`
 }
 
 type Obor struct {
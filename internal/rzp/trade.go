@@ -0,0 +1,186 @@
+package rzp
+
+import (
+	"strings"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/rzp/statement"
+)
+
+// Trade is a single trade licence (živnostenské oprávnění) of the subject
+type Trade struct {
+	// Number is order number of the licence within the subject
+	Number string
+	// TradeType is subject of business (předmět podnikání)
+	TradeType string
+	// Fields are fields of activity (obory činnosti), only free trades have them
+	Fields            []string
+	Kind              TradeKind
+	DateOfOrigin      time.Time
+	ValidityOfLicense string
+	// DateOfTermination is zero while the licence is valid
+	DateOfTermination          time.Time
+	Suspensions                []Period
+	Establishments             []Establishment
+	ResponsibleRepresentatives []ResponsibleRepresentative
+}
+
+// TradeKind is kind of trade (druh živnosti) as defined by the Trade Licensing Act
+type TradeKind string
+
+const (
+	TradeKindFree       TradeKind = "free"
+	TradeKindCraft      TradeKind = "craft"
+	TradeKindRegulated  TradeKind = "regulated"
+	TradeKindConcession TradeKind = "concession"
+)
+
+var tradeKinds = map[string]TradeKind{
+	"volná":       TradeKindFree,
+	"řemeslná":    TradeKindCraft,
+	"vázaná":      TradeKindRegulated,
+	"koncesovaná": TradeKindConcession,
+}
+
+// parseTradeKind maps Czech kind of trade, unknown kinds are kept as stated by the registry
+func parseTradeKind(druh string) TradeKind {
+	druh = strings.TrimSpace(druh)
+	if kind, ok := tradeKinds[strings.ToLower(druh)]; ok {
+		return kind
+	}
+	return TradeKind(druh)
+}
+
+// Period is a time interval, zero To means the period has not ended
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// Establishment is a premises (provozovna) where the trade is operated
+type Establishment struct {
+	// Number is identification number of the establishment
+	Number  string
+	Address string
+	Period
+}
+
+// ResponsibleRepresentative (odpovědný zástupce) guarantees proper operation of the trade
+// when the entrepreneur does not meet the professional qualification
+type ResponsibleRepresentative struct {
+	NaturalPerson
+	Period
+}
+
+const tradePath = "listiny/verweb/PodnikatelDetail/SeznamZivnosti/Zivnost"
+
+func parseTrades(zivnosti []statement.Zivnost) ([]Trade, error) {
+	trades := make([]Trade, 0, len(zivnosti))
+	for _, zivnost := range zivnosti {
+		trade, err := parseTrade(zivnost)
+		if err != nil {
+			return nil, err
+		}
+		trades = append(trades, trade)
+	}
+	return trades, nil
+}
+
+func parseTrade(zivnost statement.Zivnost) (Trade, error) {
+	dateOfOrigin, err := parseDate(tradePath+"/Vznik", zivnost.Vznik)
+	if err != nil {
+		return Trade{}, err
+	}
+	dateOfTermination, err := parseDate(tradePath+"/Zanik", zivnost.Zanik)
+	if err != nil {
+		return Trade{}, err
+	}
+
+	fields := make([]string, 0, len(zivnost.Obor.Vycet.Drive))
+	for _, field := range zivnost.Obor.Vycet.Drive {
+		fields = append(fields, field.Hodnota)
+	}
+
+	suspensions := make([]Period, 0, len(zivnost.SeznamPreruseni.Preruseni))
+	for _, preruseni := range zivnost.SeznamPreruseni.Preruseni {
+		period, err := parsePeriod(tradePath+"/SeznamPreruseni/Preruseni", preruseni.Od, preruseni.Do)
+		if err != nil {
+			return Trade{}, err
+		}
+		suspensions = append(suspensions, period)
+	}
+
+	establishments := make([]Establishment, 0, len(zivnost.SeznamProvozoven.Provozovna))
+	for _, provozovna := range zivnost.SeznamProvozoven.Provozovna {
+		period, err := parsePeriod(tradePath+"/SeznamProvozoven/Provozovna", provozovna.Zahajeni, provozovna.Ukonceni)
+		if err != nil {
+			return Trade{}, err
+		}
+		establishments = append(establishments, Establishment{
+			Number:  provozovna.IdentifikacniCisloProvozovny,
+			Address: provozovna.Adresa.TextAdresy,
+			Period:  period,
+		})
+	}
+
+	representatives, err := parseResponsibleRepresentatives(tradePath+"/SeznamOdpovednychZastupcu/OdpovednyZastupce", zivnost.SeznamOdpovednychZastupcu)
+	if err != nil {
+		return Trade{}, err
+	}
+
+	return Trade{
+		Number:                     zivnost.ZivnostPoradoveCislo,
+		TradeType:                  zivnost.Predmet.Hodnota,
+		Fields:                     fields,
+		Kind:                       parseTradeKind(zivnost.Druh.Hodnota),
+		DateOfOrigin:               dateOfOrigin,
+		ValidityOfLicense:          zivnost.PlatnostOpravneni.Hodnota,
+		DateOfTermination:          dateOfTermination,
+		Suspensions:                suspensions,
+		Establishments:             establishments,
+		ResponsibleRepresentatives: representatives,
+	}, nil
+}
+
+// parseResponsibleRepresentatives parses responsible representatives of the trade listed at path
+func parseResponsibleRepresentatives(path string, seznam statement.SeznamOdpovednychZastupcu) ([]ResponsibleRepresentative, error) {
+	representatives := make([]ResponsibleRepresentative, 0, len(seznam.OdpovednyZastupce))
+	for _, zastupce := range seznam.OdpovednyZastupce {
+		period, err := parsePeriod(path, zastupce.Od, zastupce.Do)
+		if err != nil {
+			return nil, err
+		}
+		person, err := parseNaturalPerson(path+"/ZucastnenaOsobaDetail", zastupce.ZucastnenaOsobaDetail)
+		if err != nil {
+			return nil, err
+		}
+		representatives = append(representatives, ResponsibleRepresentative{NaturalPerson: person, Period: period})
+	}
+	return representatives, nil
+}
+
+// parsePeriod parses start and end dates of the element at path
+func parsePeriod(path string, from string, to string) (Period, error) {
+	fromDate, err := parseDate(path, from)
+	if err != nil {
+		return Period{}, err
+	}
+	toDate, err := parseDate(path, to)
+	if err != nil {
+		return Period{}, err
+	}
+	return Period{From: fromDate, To: toDate}, nil
+}
+
+// parseDate parses date in statement format, blank value is zero time
+func parseDate(path string, value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(dateFormat, value)
+	if err != nil {
+		return time.Time{}, &SchemaError{Path: path, Err: err}
+	}
+	return date, nil
+}
@@ -0,0 +1,147 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/ares"
+	"github.com/fstaffa/czsnoop/internal/names"
+	"github.com/fstaffa/czsnoop/internal/rzp"
+	"github.com/fstaffa/czsnoop/internal/types"
+)
+
+// aresPageSize is number of subjects requested from ARES at once
+const aresPageSize = 100
+
+// AresSubject looks up basic data of the subject in ARES, Period of the subject is its establishment and termination
+func AresSubject(ctx context.Context, ico types.Ico, logger *slog.Logger, options ...ares.Option) (EconomicSubject, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	logger = logger.With("search", "ares", slog.String("ico", string(ico)))
+	client := ares.CreateClient(ctx, logger.With("client", "ares"), options...)
+
+	subject, err := client.GetSubject(ico)
+	if err != nil {
+		return EconomicSubject{}, fmt.Errorf("unable to get subject %s from ARES: %w", ico, err)
+	}
+	return fromAresSubject(subject), nil
+}
+
+// AresSearch finds subjects whose business name matches the name in ARES
+func AresSearch(ctx context.Context, name string, logger *slog.Logger, options ...ares.Option) ([]EconomicSubject, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	logger = logger.With("search", "ares", slog.String("name", name))
+	client := ares.CreateClient(ctx, logger.With("client", "ares"), options...)
+
+	found, err := searchAresSubjects(client, name)
+	if err != nil {
+		return nil, err
+	}
+	subjects := make([]EconomicSubject, 0, len(found))
+	for _, subject := range found {
+		subjects = append(subjects, fromAresSubject(subject))
+	}
+	return subjects, nil
+}
+
+// Ares searches persons trading under their own name in ARES, e.g. sole traders, together with the subject they
+// trade as. ARES knows no birth dates nor statutory bodies, so persons are matched only by their name.
+func Ares(ctx context.Context, input PersonSearchInput, logger *slog.Logger, options ...ares.Option) ([]Person, error) {
+	name := input.name()
+	if name.Surname == "" || input.Role == rzp.SubjectRoleStatutoryBody {
+		return nil, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	logger = logger.With("search", "ares")
+	client := ares.CreateClient(ctx, logger.With("client", "ares"), options...)
+
+	subjects, err := searchAresSubjects(client, strings.TrimSpace(name.FirstName+" "+name.Surname))
+	if err != nil {
+		return nil, err
+	}
+	var persons []Person
+	for _, subject := range subjects {
+		if !subject.NaturalPerson() {
+			continue
+		}
+		economicSubject := fromAresSubject(subject)
+		economicSubject.Role = RoleEntrepreneur
+		if !input.IncludeHistorical && economicSubject.Ended(time.Now()) {
+			continue
+		}
+		parsed := names.Parse(subject.Name)
+		person := Person{
+			FirstName:       parsed.FirstName,
+			LastName:        parsed.Surname,
+			TitleBeforeName: parsed.TitleBeforeName,
+			TitleAfterName:  parsed.TitleAfterName,
+			FullName:        subject.Name,
+			Address:         economicSubject.Address,
+			Subjects:        []EconomicSubject{economicSubject},
+		}
+		associated := AssociatedPerson{FirstName: person.FirstName, LastName: person.LastName,
+			TitleBeforeName: person.TitleBeforeName, TitleAfterName: person.TitleAfterName}
+		if matchesQuery(associated, name, input.Fuzzy) {
+			persons = append(persons, person)
+		}
+	}
+	logger.Debug("Found persons", slog.Int("subjects", len(subjects)), slog.Int("matching", len(persons)))
+	return persons, nil
+}
+
+// searchAresSubjects reads all pages of subjects whose business name matches the name
+func searchAresSubjects(client *ares.Ares, name string) ([]ares.EconomicSubject, error) {
+	var subjects []ares.EconomicSubject
+	for {
+		response, err := client.Search(ares.SearchQuery{Name: name, Start: len(subjects), Count: aresPageSize})
+		if err != nil {
+			return nil, fmt.Errorf("unable to search subjects in ARES: %w", err)
+		}
+		subjects = append(subjects, response.Subjects...)
+		// ARES may return fewer subjects than it reported, an empty page means there is nothing more
+		if len(response.Subjects) == 0 || len(subjects) >= response.Total {
+			return subjects, nil
+		}
+	}
+}
+
+// AresCompany looks up basic profile of the subject in ARES, ARES knows no persons nor trades of the subject.
+// Subjects which no longer exist are found only with input.IncludeHistorical.
+func AresCompany(ctx context.Context, input CompanySearchInput, logger *slog.Logger, options ...ares.Option) (Company, error) {
+	subject, err := AresSubject(ctx, input.Ico, logger, options...)
+	if err != nil {
+		return Company{}, err
+	}
+	if !input.IncludeHistorical && subject.Ended(time.Now()) {
+		return Company{}, fmt.Errorf("subject with ICO %s was terminated on %s, %w in ARES", input.Ico, subject.To.Format(time.DateOnly), ares.ErrNotFound)
+	}
+	return Company{
+		Name:          subject.Name,
+		Ico:           subject.Ico,
+		Address:       subject.Address,
+		LegalForm:     subject.LegalForm,
+		VatId:         subject.VatId,
+		VatPayer:      subject.VatPayer,
+		NaceCodes:     subject.NaceCodes,
+		EstablishedOn: subject.From,
+		TerminatedOn:  subject.To,
+	}, nil
+}
+
+func fromAresSubject(subject ares.EconomicSubject) EconomicSubject {
+	return EconomicSubject{
+		Name:      subject.Name,
+		Address:   subject.Address.Text,
+		Ico:       subject.Ico,
+		Period:    Period{From: time.Time(subject.EstablishedOn), To: time.Time(subject.TerminatedOn)},
+		LegalForm: subject.LegalForm(),
+		VatId:     subject.VatId,
+		VatPayer:  subject.VatPayer(),
+		NaceCodes: subject.NaceCodes,
+	}
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/ares"
+	"github.com/fstaffa/czsnoop/internal/ares/arestest"
+	"github.com/fstaffa/czsnoop/internal/rzp"
+	"github.com/fstaffa/czsnoop/internal/types"
+)
+
+func Test_AresSubject(t *testing.T) {
+	t.Parallel()
+	server := arestest.NewServer(t)
+
+	subject, err := AresSubject(context.Background(), "01895541", slog.Default(), ares.WithBaseUrl(server.URL))
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if subject.Name != "THOMAS SILVERTONNI s.r.o." || subject.Address != "Mazovská 479/8, Troja, 18100 Praha 8" {
+		t.Errorf("Expected THOMAS SILVERTONNI s.r.o. in Troja, got %+v", subject)
+	}
+	if subject.LegalForm != "Společnost s ručením omezeným" || subject.VatId != "CZ01895541" || !subject.VatPayer {
+		t.Errorf("Expected VAT paying s.r.o., got %+v", subject)
+	}
+	if !subject.From.Equal(time.Date(2013, time.March, 28, 0, 0, 0, 0, time.UTC)) || !subject.To.IsZero() {
+		t.Errorf("Expected subject established on 2013-03-28, got %+v", subject.Period)
+	}
+	if len(subject.NaceCodes) != 3 {
+		t.Errorf("Expected 3 NACE codes, got %v", subject.NaceCodes)
+	}
+
+	_, err = AresSubject(context.Background(), "27074358", slog.Default(), ares.WithBaseUrl(server.URL))
+	if !errors.Is(err, ares.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func Test_AresSearch(t *testing.T) {
+	t.Parallel()
+	server := arestest.NewServer(t)
+
+	subjects, err := AresSearch(context.Background(), "Novák", slog.Default(), ares.WithBaseUrl(server.URL))
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	var icos []types.Ico
+	for _, subject := range subjects {
+		icos = append(icos, subject.Ico)
+	}
+	if expected := []types.Ico{"45678910", "24681351", "73452301"}; !slices.Equal(icos, expected) {
+		t.Errorf("Expected subjects %v, got %v", expected, icos)
+	}
+	if subjects[1].VatPayer || subjects[1].VatId == "" {
+		t.Errorf("Expected subject with VAT id which is no longer VAT payer, got %+v", subjects[1])
+	}
+}
+
+func Test_Ares(t *testing.T) {
+	t.Parallel()
+	server := arestest.NewServer(t)
+	tests := map[string]struct {
+		input       PersonSearchInput
+		expectedIco []types.Ico
+	}{
+		"sole trader":       {input: PersonSearchInput{Query: "Petr Novák"}, expectedIco: []types.Ico{"73452301"}},
+		"companies skipped": {input: PersonSearchInput{Query: "Novák"}, expectedIco: []types.Ico{"73452301"}},
+		"statutory body":    {input: PersonSearchInput{Query: "Petr Novák", Role: rzp.SubjectRoleStatutoryBody}},
+		"titles nobody has": {input: PersonSearchInput{Query: "doc. Petr Novák"}},
+		"other first name":  {input: PersonSearchInput{FirstName: "Jan", Surname: "Novák"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			persons, err := Ares(context.Background(), test.input, slog.Default(), ares.WithBaseUrl(server.URL))
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			var icos []types.Ico
+			for _, person := range persons {
+				for _, subject := range person.Subjects {
+					icos = append(icos, subject.Ico)
+				}
+			}
+			if !slices.Equal(icos, test.expectedIco) {
+				t.Errorf("Expected persons trading as %v, got %+v", test.expectedIco, persons)
+			}
+		})
+	}
+
+	persons, err := Ares(context.Background(), PersonSearchInput{Query: "Petr Novák"}, slog.Default(), ares.WithBaseUrl(server.URL))
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	person := persons[0]
+	if person.FirstName != "Petr" || person.LastName != "Novák" || person.TitleBeforeName != "Ing." || person.TitleAfterName != "Ph.D." {
+		t.Errorf("Expected Ing. Petr Novák, Ph.D. parsed from business name, got %+v", person)
+	}
+	if subject := person.Subjects[0]; subject.Role != RoleEntrepreneur || subject.LegalForm == "" || len(subject.NaceCodes) != 3 {
+		t.Errorf("Expected entrepreneur subject with ARES data, got %+v", subject)
+	}
+}
@@ -0,0 +1,338 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/rzp"
+	"github.com/fstaffa/czsnoop/internal/types"
+)
+
+type Company struct {
+	Name    string
+	Ico     types.Ico
+	Address string
+	// LegalForm is empty for natural persons
+	LegalForm string
+	// trade licensing office keeping the record of the subject
+	RegisteringOffice string
+	// FileNumber (spisová značka) and RegisteredCapital are known only for subjects in the commercial register
+	FileNumber        string
+	RegisteredCapital string
+	Trades            []Trade
+	Persons           []AssociatedPerson
+	// History lists former names, seats, legal forms and registered capitals from the commercial register
+	History []Record
+	// VatId, VatPayer, NaceCodes and dates of establishment and termination are known only from ARES
+	VatId         string
+	VatPayer      bool
+	NaceCodes     []string
+	EstablishedOn time.Time
+	TerminatedOn  time.Time
+}
+
+// Record is a former value of company data, Label describes it as stated by the registry, e.g. "Sídlo"
+type Record struct {
+	Label string
+	Value string
+	Period
+}
+
+// Trade is a trade licence of the subject, see rzp.Trade
+type Trade struct {
+	Number                     string
+	TradeType                  string
+	Fields                     []string
+	Kind                       rzp.TradeKind
+	DateOfOrigin               time.Time
+	ValidityOfLicense          string
+	DateOfTermination          time.Time
+	Suspensions                []Period
+	Establishments             []Establishment
+	ResponsibleRepresentatives []AssociatedPerson
+}
+
+// Period is a time interval, zero To means the period has not ended
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// Ended checks whether the period ended before the given time
+func (p Period) Ended(at time.Time) bool {
+	return !p.To.IsZero() && p.To.Before(dateOnly(at))
+}
+
+type CompanySearchInput struct {
+	Ico types.Ico
+	// IncludeHistorical keeps terminated trades, ended roles and subjects which are no longer registered
+	IncludeHistorical bool
+}
+
+type Establishment struct {
+	Number  string
+	Address string
+	Period
+}
+
+// AssociatedPerson is a person related to an economic subject, Role describes the relation
+// and Period how long it lasted if the registry states it
+type AssociatedPerson struct {
+	Role string
+	// Function as stated by the registry, e.g. "jednatel" for statutory body members
+	Function        string
+	FullName        string
+	FirstName       string
+	LastName        string
+	TitleBeforeName string
+	TitleAfterName  string
+	BirthDate       time.Time
+	Citizenship     string
+	// Ico is set when the associated person is a legal entity
+	Ico types.Ico
+	// Share describes deposit and ownership interest of shareholders
+	Share string
+	Period
+}
+
+const (
+	RoleEntrepreneur              = "entrepreneur"
+	RoleResponsibleRepresentative = "responsible representative"
+	RoleStatutoryBodyMember       = "statutory body member"
+	RoleShareholder               = "shareholder"
+)
+
+// RzpCompany looks up the subject and its details in RZP
+func RzpCompany(ctx context.Context, input CompanySearchInput, logger *slog.Logger, options ...rzp.Option) (Company, error) {
+	ico := input.Ico
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	logger = logger.With("search", "rzp", slog.String("ico", string(ico)))
+	client, err := rzp.CreateClient(ctx, logger.With("client", "rzp"), options...)
+	if err != nil {
+		return Company{}, fmt.Errorf("unable to create RZP client: %w", err)
+	}
+
+	subjects, err := client.SearchSubject(rzp.SearchSubjectQuery{Ico: ico, IncludeHistorical: input.IncludeHistorical})
+	if err != nil {
+		return Company{}, fmt.Errorf("unable to search subject in RZP: %w", err)
+	}
+	if len(subjects.Subjects) == 0 {
+		return Company{}, fmt.Errorf("subject with ICO %s %w in RZP", ico, rzp.ErrNotFound)
+	}
+	subject := currentSubject(subjects.Subjects)
+	logger.Debug("Found subject", slog.String("name", subject.Name), slog.String("type", subject.Type))
+
+	detail, err := client.GetSubjectDetails(subject.Ssarzp)
+	if err != nil {
+		return Company{}, fmt.Errorf("unable to get details of subject %s: %w", ico, err)
+	}
+
+	var persons []AssociatedPerson
+	if detail.LastName != "" {
+		persons = append(persons, AssociatedPerson{
+			Role:            RoleEntrepreneur,
+			FullName:        detail.FullNameWithTitles,
+			FirstName:       detail.FirstName,
+			LastName:        detail.LastName,
+			TitleBeforeName: detail.TitleBeforeName,
+			TitleAfterName:  detail.TitleAfterName,
+			BirthDate:       detail.BirthDate,
+			Citizenship:     detail.Citizenship,
+		})
+	}
+	var legalForm string
+	if detail.LegalEntity != nil {
+		legalForm = detail.LegalEntity.LegalForm
+		for _, person := range legalEntityPersons(*detail.LegalEntity) {
+			if !containsPerson(persons, person) {
+				persons = append(persons, person)
+			}
+		}
+	}
+	trades := make([]Trade, 0, len(detail.Trades))
+	for _, rzpTrade := range detail.Trades {
+		trade := fromRzpTrade(rzpTrade)
+		trades = append(trades, trade)
+		for _, representative := range trade.ResponsibleRepresentatives {
+			if !containsPerson(persons, representative) {
+				persons = append(persons, representative)
+			}
+		}
+	}
+
+	company := Company{
+		Name:              subject.Name,
+		Ico:               subject.Ico,
+		Address:           subject.Address,
+		LegalForm:         legalForm,
+		RegisteringOffice: detail.RegisteringOffice,
+		Trades:            trades,
+		Persons:           persons,
+	}
+	if !input.IncludeHistorical {
+		company = company.current(time.Now())
+	}
+	return company, nil
+}
+
+// currentSubject prefers the valid record of the subject, expired records are returned with IncludeHistorical
+func currentSubject(subjects []rzp.Subject) rzp.Subject {
+	for _, subject := range subjects {
+		if time.Time(subject.ValidTo).IsZero() {
+			return subject
+		}
+	}
+	return subjects[0]
+}
+
+func fromRzpTrade(trade rzp.Trade) Trade {
+	suspensions := make([]Period, 0, len(trade.Suspensions))
+	for _, suspension := range trade.Suspensions {
+		suspensions = append(suspensions, Period(suspension))
+	}
+	establishments := make([]Establishment, 0, len(trade.Establishments))
+	for _, establishment := range trade.Establishments {
+		establishments = append(establishments, Establishment{
+			Number:  establishment.Number,
+			Address: establishment.Address,
+			Period:  Period(establishment.Period),
+		})
+	}
+	representatives := make([]AssociatedPerson, 0, len(trade.ResponsibleRepresentatives))
+	for _, representative := range trade.ResponsibleRepresentatives {
+		representatives = append(representatives, fromNaturalPerson(RoleResponsibleRepresentative, representative.NaturalPerson, representative.Period))
+	}
+	return Trade{
+		Number:                     trade.Number,
+		TradeType:                  trade.TradeType,
+		Fields:                     trade.Fields,
+		Kind:                       trade.Kind,
+		DateOfOrigin:               trade.DateOfOrigin,
+		ValidityOfLicense:          trade.ValidityOfLicense,
+		DateOfTermination:          trade.DateOfTermination,
+		Suspensions:                suspensions,
+		Establishments:             establishments,
+		ResponsibleRepresentatives: representatives,
+	}
+}
+
+func legalEntityPersons(entity rzp.LegalEntityDetail) []AssociatedPerson {
+	var persons []AssociatedPerson
+	for _, body := range entity.StatutoryBodies {
+		for _, member := range body.Members {
+			person := fromNaturalPerson(RoleStatutoryBodyMember, member.NaturalPerson, member.Period)
+			person.Function = member.Function
+			if member.EntityName != "" {
+				person.FullName = member.EntityName
+				person.Ico = member.EntityIco
+			}
+			persons = append(persons, person)
+		}
+	}
+	for _, representative := range entity.ResponsibleRepresentatives {
+		persons = append(persons, fromNaturalPerson(RoleResponsibleRepresentative, representative.NaturalPerson, representative.Period))
+	}
+	return persons
+}
+
+func fromNaturalPerson(role string, person rzp.NaturalPerson, period rzp.Period) AssociatedPerson {
+	return AssociatedPerson{
+		Role:            role,
+		FullName:        person.FullNameWithTitles,
+		FirstName:       person.FirstName,
+		LastName:        person.LastName,
+		TitleBeforeName: person.TitleBeforeName,
+		TitleAfterName:  person.TitleAfterName,
+		BirthDate:       person.BirthDate,
+		Citizenship:     person.Citizenship,
+		Period:          Period(period),
+	}
+}
+
+// containsPerson checks whether the same person has the same role, e.g. representative of several trades
+func containsPerson(persons []AssociatedPerson, person AssociatedPerson) bool {
+	for _, p := range persons {
+		if p.Role == person.Role && p.Function == person.Function && p.FullName == person.FullName && p.BirthDate.Equal(person.BirthDate) {
+			return true
+		}
+	}
+	return false
+}
+
+// current drops terminated trades, ended suspensions and establishments and persons whose role ended
+func (c Company) current(at time.Time) Company {
+	trades := make([]Trade, 0, len(c.Trades))
+	for _, trade := range c.Trades {
+		if !trade.DateOfTermination.IsZero() && trade.DateOfTermination.Before(dateOnly(at)) {
+			continue
+		}
+		trade.Suspensions = slices.DeleteFunc(slices.Clone(trade.Suspensions), func(p Period) bool { return p.Ended(at) })
+		trade.Establishments = slices.DeleteFunc(slices.Clone(trade.Establishments), func(e Establishment) bool { return e.Ended(at) })
+		trade.ResponsibleRepresentatives = currentPersons(trade.ResponsibleRepresentatives, at)
+		trades = append(trades, trade)
+	}
+	c.Trades = trades
+	c.Persons = currentPersons(c.Persons, at)
+	c.History = slices.DeleteFunc(slices.Clone(c.History), func(r Record) bool { return r.Ended(at) })
+	return c
+}
+
+func currentPersons(persons []AssociatedPerson, at time.Time) []AssociatedPerson {
+	return slices.DeleteFunc(slices.Clone(persons), func(p AssociatedPerson) bool { return p.Ended(at) })
+}
+
+// merge completes the profile with data of the same subject from another provider, values already known are kept
+func (c Company) merge(other Company) Company {
+	if c.Ico == "" {
+		return other
+	}
+	fill(&c.Name, other.Name)
+	fill(&c.Address, other.Address)
+	fill(&c.LegalForm, other.LegalForm)
+	fill(&c.RegisteringOffice, other.RegisteringOffice)
+	fill(&c.FileNumber, other.FileNumber)
+	fill(&c.RegisteredCapital, other.RegisteredCapital)
+	fill(&c.VatId, other.VatId)
+	c.VatPayer = c.VatPayer || other.VatPayer
+	if len(c.NaceCodes) == 0 {
+		c.NaceCodes = other.NaceCodes
+	}
+	if c.EstablishedOn.IsZero() {
+		c.EstablishedOn = other.EstablishedOn
+	}
+	if c.TerminatedOn.IsZero() {
+		c.TerminatedOn = other.TerminatedOn
+	}
+	c.Trades = slices.Concat(c.Trades, other.Trades)
+	c.History = slices.Concat(c.History, other.History)
+	persons := slices.Clone(c.Persons)
+	for _, person := range other.Persons {
+		if !containsRole(persons, person) {
+			persons = append(persons, person)
+		}
+	}
+	c.Persons = persons
+	return c
+}
+
+// fill sets the value unless it is already known
+func fill(value *string, other string) {
+	if *value == "" {
+		*value = other
+	}
+}
+
+// containsRole checks whether the same person has the same role, registries state functions differently so they are ignored
+func containsRole(persons []AssociatedPerson, person AssociatedPerson) bool {
+	for _, p := range persons {
+		if p.Role == person.Role && strings.EqualFold(p.FullName, person.FullName) && p.BirthDate.Equal(person.BirthDate) {
+			return true
+		}
+	}
+	return false
+}
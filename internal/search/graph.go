@@ -0,0 +1,323 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/names"
+	"github.com/fstaffa/czsnoop/internal/types"
+)
+
+// DefaultGraphMaxRequests is the default number of person searches and subject lookups of a single traversal
+const DefaultGraphMaxRequests = 50
+
+type GraphInput struct {
+	// Ico of the subject to start from, Query is used when it is empty
+	Ico types.Ico
+	// Query is the name of persons to start from, see PersonSearchInput.Query
+	Query string
+	// Depth is the number of relations followed from the seed, 0 finds only the seed
+	Depth int
+	// MaxRequests limits number of person searches and subject lookups, 0 means DefaultGraphMaxRequests.
+	// Each of them is a request to every provider, which may in turn make several requests to its registry.
+	MaxRequests int
+	// IncludeHistorical follows also ended roles and subjects which are no longer registered
+	IncludeHistorical bool
+}
+
+type NodeKind string
+
+const (
+	NodePerson  NodeKind = "person"
+	NodeSubject NodeKind = "subject"
+)
+
+// Node is a natural person or an economic subject, legal entities associated with subjects are subjects too
+type Node struct {
+	// Id identifies the node within the graph, subjects by ICO and persons by name and birth date. Persons without
+	// birth date are identified also by the node they were reached from, as namesakes can not be told apart.
+	Id   string
+	Kind NodeKind
+	Name string
+	// Ico is set for subjects
+	Ico types.Ico
+	// FirstName, LastName and BirthDate are set for persons, BirthDate is zero if the registry does not state it
+	FirstName string
+	LastName  string
+	BirthDate time.Time
+	Address   string
+	// Depth is the number of relations between the node and the seed
+	Depth int
+	// Expanded is set when relations of the node were searched, nodes at the maximum depth and persons without
+	// birth date are not expanded
+	Expanded bool
+}
+
+// Edge is a role of a person or a legal entity Source in the subject Target, Period is validity of the role
+type Edge struct {
+	Source string
+	Target string
+	// Role of Source in Target, empty if it could not be determined
+	Role string
+	Period
+}
+
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+	// Requests is the number of person searches and subject lookups made
+	Requests int
+	// Truncated is set when MaxRequests ran out before all nodes up to Depth were expanded
+	Truncated bool
+}
+
+// Node finds node by its id
+func (g *Graph) Node(id string) (Node, bool) {
+	for _, node := range g.Nodes {
+		if node.Id == id {
+			return node, true
+		}
+	}
+	return Node{}, false
+}
+
+// graphBuilder adds nodes and edges to the graph, each of them only once
+type graphBuilder struct {
+	graph     Graph
+	nodes     map[string]int
+	edges     map[edgeKey]bool
+	providers []Provider
+	input     GraphInput
+	logger    *slog.Logger
+}
+
+// addNode adds the node unless it is already in the graph. It returns true for new nodes whose relations
+// should be followed, nodes which were already visited close a cycle and are not followed again.
+func (b *graphBuilder) addNode(node Node) bool {
+	if i, ok := b.nodes[node.Id]; ok {
+		existing := &b.graph.Nodes[i]
+		fill(&existing.Name, node.Name)
+		fill(&existing.Address, node.Address)
+		b.logger.Debug("Node already visited", slog.String("node", node.Id), slog.Int("depth", existing.Depth))
+		return false
+	}
+	b.nodes[node.Id] = len(b.graph.Nodes)
+	b.graph.Nodes = append(b.graph.Nodes, node)
+	return node.Depth < b.input.Depth
+}
+
+// edgeKey identifies edges, providers state periods of the same role differently so the first one is kept
+type edgeKey struct {
+	source string
+	target string
+	role   string
+}
+
+func (b *graphBuilder) addEdge(edge Edge) {
+	key := edgeKey{source: edge.Source, target: edge.Target, role: edge.Role}
+	if b.edges[key] {
+		return
+	}
+	b.edges[key] = true
+	b.graph.Edges = append(b.graph.Edges, edge)
+}
+
+// node returns the node by its id, the pointer is valid only until another node is added
+func (b *graphBuilder) node(id string) *Node {
+	return &b.graph.Nodes[b.nodes[id]]
+}
+
+// Traverse builds graph of persons and economic subjects starting from the seed subject or persons and following
+// their relations breadth first up to input.Depth. Nodes reached again through other relations are not expanded
+// again, so cycles of ownership and shared boards end the traversal. When some searches fail, the graph built
+// from the others is returned with the errors. When input.MaxRequests runs out, the graph built so far is returned
+// with ErrRequestBudgetExhausted.
+func Traverse(ctx context.Context, providers []Provider, input GraphInput, logger *slog.Logger) (Graph, error) {
+	maxRequests := input.MaxRequests
+	if maxRequests <= 0 {
+		maxRequests = DefaultGraphMaxRequests
+	}
+	b := &graphBuilder{
+		nodes:     make(map[string]int),
+		edges:     make(map[edgeKey]bool),
+		providers: providers,
+		input:     input,
+		logger:    logger.With("search", "graph"),
+	}
+
+	frontier, err := b.seed(ctx)
+	if len(b.graph.Nodes) == 0 {
+		return Graph{}, err
+	}
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for len(frontier) > 0 {
+		var next []string
+		for _, id := range frontier {
+			if err := ctx.Err(); err != nil {
+				return b.graph, err
+			}
+			node := b.node(id)
+			if node.Kind == NodePerson && node.BirthDate.IsZero() {
+				// searching by name alone would merge subjects of all namesakes into the person
+				b.logger.Debug("Person without birth date not expanded", slog.String("node", id))
+				continue
+			}
+			if b.graph.Requests >= maxRequests {
+				b.logger.Warn("Request budget exhausted", slog.Int("maxRequests", maxRequests), slog.String("node", id))
+				b.graph.Truncated = true
+				errs = append(errs, fmt.Errorf("%w: more than %d requests needed", ErrRequestBudgetExhausted, maxRequests))
+				return b.graph, errors.Join(errs...)
+			}
+			var reached []string
+			var err error
+			switch node.Kind {
+			case NodeSubject:
+				reached, err = b.expandSubject(ctx, id)
+			case NodePerson:
+				reached, err = b.expandPerson(ctx, id)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("unable to expand %s: %w", id, err))
+			}
+			next = append(next, reached...)
+		}
+		frontier = next
+	}
+	return b.graph, errors.Join(errs...)
+}
+
+// seed adds the seed subject or persons found by the query, returning ids of nodes to follow. The graph stays
+// empty when the seed was not found.
+func (b *graphBuilder) seed(ctx context.Context) ([]string, error) {
+	if b.input.Ico != "" {
+		b.graph.Requests++
+		company, _, err := LookupSubject(ctx, b.providers, CompanySearchInput{Ico: b.input.Ico, IncludeHistorical: b.input.IncludeHistorical}, b.logger)
+		// profile without ICO means that no provider found the subject
+		if company.Ico == "" {
+			return nil, err
+		}
+		seed := subjectNode(company.Ico, company.Name, company.Address, 0)
+		b.addNode(seed)
+		return b.addPersons(seed.Id, company), err
+	}
+
+	b.graph.Requests++
+	persons, _, err := SearchPersons(ctx, b.providers, PersonSearchInput{Query: b.input.Query, IncludeHistorical: b.input.IncludeHistorical}, b.logger)
+	var reached []string
+	for i, person := range persons {
+		seed := personNode(person.FirstName, person.LastName, person.BirthDate, person.FullName, person.Address, fmt.Sprintf("seed:%d", i), 0)
+		b.addNode(seed)
+		reached = append(reached, b.addSubjects(seed.Id, person.Subjects)...)
+	}
+	return reached, err
+}
+
+// expandSubject looks up persons associated with the subject, returning ids of the newly reached nodes to follow
+func (b *graphBuilder) expandSubject(ctx context.Context, id string) ([]string, error) {
+	b.graph.Requests++
+	company, _, err := LookupSubject(ctx, b.providers, CompanySearchInput{Ico: b.node(id).Ico, IncludeHistorical: b.input.IncludeHistorical}, b.logger)
+	if company.Ico == "" {
+		return nil, err
+	}
+	node := b.node(id)
+	fill(&node.Name, company.Name)
+	fill(&node.Address, company.Address)
+	return b.addPersons(id, company), err
+}
+
+// expandPerson searches subjects of the person with known birth date, returning ids of the newly reached nodes to follow
+func (b *graphBuilder) expandPerson(ctx context.Context, id string) ([]string, error) {
+	b.graph.Requests++
+	node := *b.node(id)
+	input := PersonSearchInput{
+		FirstName:         node.FirstName,
+		Surname:           node.LastName,
+		BornAfter:         node.BirthDate,
+		BornBefore:        node.BirthDate,
+		IncludeHistorical: b.input.IncludeHistorical,
+	}
+	persons, _, err := SearchPersons(ctx, b.providers, input, b.logger)
+	if err == nil {
+		b.node(id).Expanded = true
+	}
+	var reached []string
+	for _, person := range persons {
+		if !names.Equal(person.FirstName, node.FirstName) || !names.Equal(person.LastName, node.LastName) ||
+			!dateOnly(person.BirthDate).Equal(dateOnly(node.BirthDate)) {
+			continue
+		}
+		fill(&b.node(id).Address, person.Address)
+		reached = append(reached, b.addSubjects(id, person.Subjects)...)
+	}
+	return reached, err
+}
+
+// addPersons adds persons and legal entities associated with the subject when the subject is to be followed
+func (b *graphBuilder) addPersons(id string, company Company) []string {
+	depth := b.node(id).Depth
+	if depth >= b.input.Depth {
+		return nil
+	}
+	b.node(id).Expanded = true
+	var reached []string
+	for _, person := range company.Persons {
+		var target Node
+		if person.Ico != "" {
+			target = subjectNode(person.Ico, person.FullName, "", depth+1)
+		} else {
+			target = personNode(person.FirstName, person.LastName, person.BirthDate, person.FullName, "", id, depth+1)
+		}
+		if b.addNode(target) {
+			reached = append(reached, target.Id)
+		}
+		b.addEdge(Edge{Source: target.Id, Target: id, Role: person.Role, Period: person.Period})
+	}
+	return reached
+}
+
+// addSubjects adds subjects of the person when the person is to be followed
+func (b *graphBuilder) addSubjects(id string, subjects []EconomicSubject) []string {
+	depth := b.node(id).Depth
+	if depth >= b.input.Depth {
+		return nil
+	}
+	b.node(id).Expanded = true
+	var reached []string
+	for _, subject := range subjects {
+		if subject.Ico == "" {
+			continue
+		}
+		target := subjectNode(subject.Ico, subject.Name, subject.Address, depth+1)
+		if b.addNode(target) {
+			reached = append(reached, target.Id)
+		}
+		b.addEdge(Edge{Source: id, Target: target.Id, Role: subject.Role, Period: subject.Period})
+	}
+	return reached
+}
+
+func subjectNode(ico types.Ico, name string, address string, depth int) Node {
+	return Node{Id: "subject:" + string(ico), Kind: NodeSubject, Name: name, Ico: ico, Address: address, Depth: depth}
+}
+
+// personNode identifies persons by their normalized name and birth date, persons whose birth date is not known
+// are kept apart from namesakes by scope, the id of the node they were reached from
+func personNode(firstName string, lastName string, birthDate time.Time, fullName string, address string, scope string, depth int) Node {
+	id := "person:" + names.Normalize(firstName+" "+lastName)
+	if birthDate.IsZero() {
+		id += "@" + scope
+	} else {
+		id += "|" + birthDate.Format(time.DateOnly)
+	}
+	if fullName == "" {
+		fullName = strings.TrimSpace(firstName + " " + lastName)
+	}
+	return Node{Id: id, Kind: NodePerson, Name: fullName, FirstName: firstName, LastName: lastName, BirthDate: birthDate, Address: address, Depth: depth}
+}
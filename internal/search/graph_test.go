@@ -0,0 +1,198 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fstaffa/czsnoop/internal/rzp/rzptest"
+	"github.com/fstaffa/czsnoop/internal/types"
+)
+
+func Test_Traverse(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		input             GraphInput
+		expectedNodes     []string
+		expectedEdges     int
+		expectedRequests  int
+		expectedTruncated bool
+		expectedErr       error
+	}{
+		"seed only": {
+			input:            GraphInput{Ico: "45678910"},
+			expectedNodes:    []string{"subject:45678910"},
+			expectedRequests: 1,
+		},
+		"persons of the subject": {
+			input:            GraphInput{Ico: "45678910", Depth: 1},
+			expectedNodes:    []string{"subject:45678910", "person:jan novak|1980-06-01", "subject:01895541"},
+			expectedEdges:    3,
+			expectedRequests: 1,
+		},
+		"persons of shareholder": {
+			input: GraphInput{Ico: "45678910", Depth: 2},
+			expectedNodes: []string{"subject:45678910", "person:jan novak|1980-06-01", "subject:01895541",
+				"person:thomas silvertonni|1979-08-01"},
+			expectedEdges:    5,
+			expectedRequests: 3,
+		},
+		"budget exhausted": {
+			input:             GraphInput{Ico: "45678910", Depth: 2, MaxRequests: 2},
+			expectedNodes:     []string{"subject:45678910", "person:jan novak|1980-06-01", "subject:01895541"},
+			expectedEdges:     3,
+			expectedRequests:  2,
+			expectedTruncated: true,
+			expectedErr:       ErrRequestBudgetExhausted,
+		},
+		"seed persons": {
+			input: GraphInput{Query: "Jan Novák", Depth: 1},
+			expectedNodes: []string{"person:jan novak|1975-03-14", "subject:87654326", "person:jan novak|1980-06-01",
+				"subject:45678910"},
+			expectedEdges:    3,
+			expectedRequests: 1,
+		},
+		"cycle back to the seed": {
+			input: GraphInput{Query: "Jan Novák", Depth: 2},
+			expectedNodes: []string{"person:jan novak|1975-03-14", "subject:87654326", "person:jan novak|1980-06-01",
+				"subject:45678910", "subject:01895541"},
+			expectedEdges:    4,
+			expectedRequests: 3,
+		},
+	}
+	providers, err := testRegistry(t, rzptest.Fixtures).Select(nil)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			graph, err := Traverse(context.Background(), providers, test.input, slog.Default())
+			if test.expectedErr == nil && err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if !errors.Is(err, test.expectedErr) {
+				t.Errorf("Expected error %v, got %v", test.expectedErr, err)
+			}
+			var ids []string
+			for _, node := range graph.Nodes {
+				ids = append(ids, node.Id)
+			}
+			if !slices.Equal(ids, test.expectedNodes) {
+				t.Errorf("Expected nodes %v, got %v", test.expectedNodes, ids)
+			}
+			if len(graph.Edges) != test.expectedEdges {
+				t.Errorf("Expected %d edges, got %+v", test.expectedEdges, graph.Edges)
+			}
+			if graph.Requests != test.expectedRequests || graph.Truncated != test.expectedTruncated {
+				t.Errorf("Expected %d requests and truncated %v, got %d and %v", test.expectedRequests, test.expectedTruncated, graph.Requests, graph.Truncated)
+			}
+		})
+	}
+}
+
+func Test_Traverse_Edges(t *testing.T) {
+	t.Parallel()
+	providers, err := testRegistry(t, rzptest.Fixtures).Select(nil)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	graph, err := Traverse(context.Background(), providers, GraphInput{Ico: "45678910", Depth: 1}, slog.Default())
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	var roles []string
+	for _, edge := range graph.Edges {
+		if edge.Target != "subject:45678910" {
+			t.Errorf("Expected edges to the seed, got %+v", edge)
+		}
+		roles = append(roles, edge.Source+" "+edge.Role)
+	}
+	expected := []string{
+		"person:jan novak|1980-06-01 " + RoleStatutoryBodyMember,
+		"subject:01895541 " + RoleStatutoryBodyMember,
+		"person:jan novak|1980-06-01 " + RoleShareholder,
+	}
+	if !slices.Equal(roles, expected) {
+		t.Errorf("Expected roles %v, got %v", expected, roles)
+	}
+	seed, ok := graph.Node("subject:45678910")
+	if !ok || seed.Name != "NOVÁK & PARTNEŘI a.s." || !seed.Expanded || seed.Depth != 0 {
+		t.Errorf("Expected expanded seed subject, got %+v", seed)
+	}
+	person, ok := graph.Node("person:jan novak|1980-06-01")
+	if !ok || person.Expanded || person.Depth != 1 {
+		t.Errorf("Expected person at the maximum depth not to be expanded, got %+v", person)
+	}
+}
+
+func Test_Traverse_NotFound(t *testing.T) {
+	t.Parallel()
+	providers, err := testRegistry(t, rzptest.Fixtures).Select(nil)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	_, err = Traverse(context.Background(), providers, GraphInput{Ico: "27074358", Depth: 2}, slog.Default())
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+// namesakeProvider knows subjects whose persons have no birth date, searching persons finds a namesake
+type namesakeProvider struct {
+	subjects       map[types.Ico]Company
+	personSearches atomic.Int32
+}
+
+func (p *namesakeProvider) Name() string { return "namesakes" }
+
+func (p *namesakeProvider) Capabilities() []Capability {
+	return []Capability{CapabilityPersonSearch, CapabilitySubjectLookup}
+}
+
+func (p *namesakeProvider) SearchPersons(ctx context.Context, input PersonSearchInput, logger *slog.Logger) ([]Person, error) {
+	p.personSearches.Add(1)
+	return []Person{{FirstName: "Jan", LastName: "Novák", Subjects: []EconomicSubject{{Ico: "24681351", Name: "NOVÁK STAVBY s.r.o."}}}}, nil
+}
+
+func (p *namesakeProvider) LookupSubject(ctx context.Context, input CompanySearchInput, logger *slog.Logger) (Company, error) {
+	company, ok := p.subjects[input.Ico]
+	if !ok {
+		return Company{}, ErrNotFound
+	}
+	return company, nil
+}
+
+func Test_Traverse_PersonsWithoutBirthDate(t *testing.T) {
+	t.Parallel()
+	provider := &namesakeProvider{subjects: map[types.Ico]Company{
+		"45678910": {Ico: "45678910", Name: "NOVÁK & PARTNEŘI a.s.", Persons: []AssociatedPerson{
+			{FirstName: "Jan", LastName: "Novák", Role: RoleStatutoryBodyMember},
+			{FullName: "THOMAS SILVERTONNI s.r.o.", Ico: "01895541", Role: RoleShareholder},
+		}},
+		"01895541": {Ico: "01895541", Name: "THOMAS SILVERTONNI s.r.o.", Persons: []AssociatedPerson{
+			{FirstName: "Jan", LastName: "Novák", Role: RoleStatutoryBodyMember},
+		}},
+	}}
+
+	graph, err := Traverse(context.Background(), []Provider{provider}, GraphInput{Ico: "45678910", Depth: 3}, slog.Default())
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	var ids []string
+	for _, node := range graph.Nodes {
+		ids = append(ids, node.Id)
+	}
+	expected := []string{"subject:45678910", "person:jan novak@subject:45678910", "subject:01895541", "person:jan novak@subject:01895541"}
+	if !slices.Equal(ids, expected) {
+		t.Errorf("Expected namesakes kept apart in nodes %v, got %v", expected, ids)
+	}
+	if provider.personSearches.Load() != 0 || graph.Requests != 2 {
+		t.Errorf("Expected persons without birth date not to be searched, got %d searches and %d requests", provider.personSearches.Load(), graph.Requests)
+	}
+}
@@ -0,0 +1,205 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/justice"
+	"github.com/fstaffa/czsnoop/internal/names"
+	"github.com/fstaffa/czsnoop/internal/rzp"
+)
+
+// Justice searches persons in the commercial register together with the subjects they are registered in.
+// Statutory body members and shareholders of found subjects are matched to the searched name and birth date window.
+// Unless input.FailFast is set, subjects whose extract could not be read are skipped and the errors are returned
+// joined with the persons that were found.
+func Justice(ctx context.Context, input PersonSearchInput, logger *slog.Logger, options ...justice.Option) ([]Person, error) {
+	name := input.name()
+	if name.Surname == "" || input.Role == rzp.SubjectRoleEntrepreneur {
+		return nil, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	logger = logger.With("search", "justice")
+	client := justice.CreateClient(ctx, logger.With("client", "justice"), options...)
+
+	query := justice.SearchPersonQuery{FirstName: name.FirstName, Surname: name.Surname, IncludeHistorical: input.IncludeHistorical}
+	if day, ok := input.singleDay(); ok {
+		query.DateOfBirth = day
+	}
+	results, err := client.SearchPerson(query)
+	if err != nil {
+		return nil, fmt.Errorf("unable to search persons in the commercial register: %w", err)
+	}
+	logger.Debug("Found subjects", slog.Int("count", len(results)))
+
+	var persons []Person
+	var errs []error
+	for _, result := range results {
+		extract, err := client.GetExtract(result.SubjectId, input.IncludeHistorical)
+		if err != nil {
+			err := fmt.Errorf("unable to get extract of subject %s: %w", result.Name, err)
+			if input.FailFast {
+				return nil, err
+			}
+			errs = append(errs, err)
+			continue
+		}
+		for _, associated := range extractPersons(extract) {
+			if !matchesQuery(associated, name, input.Fuzzy) || !input.bornInWindow(associated.BirthDate) {
+				continue
+			}
+			if associated.Role == RoleShareholder && input.Role == rzp.SubjectRoleStatutoryBody {
+				continue
+			}
+			if !input.IncludeHistorical && associated.Ended(time.Now()) {
+				continue
+			}
+			persons = addSubject(persons, associated, EconomicSubject{
+				Name:    extract.Name,
+				Address: extract.Address,
+				Ico:     extract.Ico,
+				Role:    associated.Role,
+				Period:  associated.Period,
+			})
+		}
+	}
+	return persons, errors.Join(errs...)
+}
+
+// JusticeCompany looks up the subject and its extract in the commercial register
+func JusticeCompany(ctx context.Context, input CompanySearchInput, logger *slog.Logger, options ...justice.Option) (Company, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	logger = logger.With("search", "justice", slog.String("ico", string(input.Ico)))
+	client := justice.CreateClient(ctx, logger.With("client", "justice"), options...)
+
+	results, err := client.SearchIco(input.Ico)
+	if err != nil {
+		return Company{}, fmt.Errorf("unable to search subject in the commercial register: %w", err)
+	}
+	if len(results) == 0 {
+		return Company{}, fmt.Errorf("subject with ICO %s %w in the commercial register", input.Ico, justice.ErrNotFound)
+	}
+	extract, err := client.GetExtract(results[0].SubjectId, input.IncludeHistorical)
+	if err != nil {
+		return Company{}, fmt.Errorf("unable to get extract of subject %s: %w", input.Ico, err)
+	}
+
+	history := make([]Record, 0, len(extract.History))
+	for _, record := range extract.History {
+		history = append(history, Record{Label: record.Label, Value: record.Value, Period: Period(record.Period)})
+	}
+	company := Company{
+		Name:              extract.Name,
+		Ico:               extract.Ico,
+		Address:           extract.Address,
+		LegalForm:         extract.LegalForm,
+		FileNumber:        extract.FileNumber,
+		RegisteredCapital: extract.RegisteredCapital,
+		Persons:           extractPersons(extract),
+		History:           history,
+	}
+	if !input.IncludeHistorical {
+		company = company.current(time.Now())
+	}
+	return company, nil
+}
+
+// extractPersons returns statutory body members and shareholders stated in the extract
+func extractPersons(extract justice.Extract) []AssociatedPerson {
+	var persons []AssociatedPerson
+	for _, body := range extract.StatutoryBodies {
+		for _, member := range body.Members {
+			person := fromExtractPerson(RoleStatutoryBodyMember, member.Person, member.Period)
+			person.Function = member.Function
+			persons = append(persons, person)
+		}
+	}
+	for _, shareholder := range extract.Shareholders {
+		person := fromExtractPerson(RoleShareholder, shareholder.Person, shareholder.Period)
+		person.Share = shareholder.Share
+		persons = append(persons, person)
+	}
+	return persons
+}
+
+func fromExtractPerson(role string, person justice.Person, period justice.Period) AssociatedPerson {
+	return AssociatedPerson{
+		Role:            role,
+		FullName:        person.FullName,
+		FirstName:       person.FirstName,
+		LastName:        person.LastName,
+		TitleBeforeName: person.TitleBeforeName,
+		TitleAfterName:  person.TitleAfterName,
+		BirthDate:       person.BirthDate,
+		Ico:             person.EntityIco,
+		Period:          Period(period),
+	}
+}
+
+// matchesQuery compares names of natural persons ignoring case as the register states them in capitals,
+// and diacritics which the query may omit. Fuzzy matching accepts names scoring at least names.Threshold.
+// Titles stated in the query must be among titles of the person.
+func matchesQuery(person AssociatedPerson, name names.Name, fuzzy bool) bool {
+	if person.LastName == "" || !hasTitles(name, person.TitleBeforeName, person.TitleAfterName) {
+		return false
+	}
+	if fuzzy {
+		return names.Score(name.FirstName, name.Surname, person.FirstName, person.LastName) >= names.Threshold
+	}
+	if !names.Equal(person.LastName, name.Surname) {
+		return false
+	}
+	return name.FirstName == "" || names.Equal(person.FirstName, name.FirstName)
+}
+
+// addSubject adds subject to the person with the same name and birth date, creating the person if needed
+func addSubject(persons []Person, associated AssociatedPerson, subject EconomicSubject) []Person {
+	return mergePerson(persons, Person{
+		BirthDate:       associated.BirthDate,
+		FirstName:       associated.FirstName,
+		LastName:        associated.LastName,
+		TitleBeforeName: associated.TitleBeforeName,
+		TitleAfterName:  associated.TitleAfterName,
+		FullName:        associated.FullName,
+		Subjects:        []EconomicSubject{subject},
+	})
+}
+
+// mergePerson adds subjects of the person to the same person in persons or appends the person if it is not there
+func mergePerson(persons []Person, person Person) []Person {
+	for i := range persons {
+		existing := &persons[i]
+		if !strings.EqualFold(existing.FirstName, person.FirstName) || !strings.EqualFold(existing.LastName, person.LastName) ||
+			!dateOnly(existing.BirthDate).Equal(dateOnly(person.BirthDate)) {
+			continue
+		}
+		for _, subject := range person.Subjects {
+			if !containsSubject(existing.Subjects, subject) {
+				existing.Subjects = append(existing.Subjects, subject)
+			}
+		}
+		if existing.Address == "" {
+			existing.Address = person.Address
+		}
+		if existing.Citizenship == "" {
+			existing.Citizenship = person.Citizenship
+		}
+		return persons
+	}
+	return append(persons, person)
+}
+
+func containsSubject(subjects []EconomicSubject, subject EconomicSubject) bool {
+	for _, s := range subjects {
+		if s.Ico == subject.Ico && s.Role == subject.Role {
+			return true
+		}
+	}
+	return false
+}
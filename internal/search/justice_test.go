@@ -0,0 +1,79 @@
+package search
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/justice"
+	"github.com/fstaffa/czsnoop/internal/justice/justicetest"
+	"github.com/fstaffa/czsnoop/internal/names"
+	"github.com/fstaffa/czsnoop/internal/rzp"
+)
+
+func Test_Justice(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		input            PersonSearchInput
+		expectedPersons  int
+		expectedSubjects int
+	}{
+		"current roles":     {input: PersonSearchInput{Query: "Jan Novák"}, expectedPersons: 1, expectedSubjects: 2},
+		"statutory body":    {input: PersonSearchInput{Query: "Jan Novák", Role: rzp.SubjectRoleStatutoryBody}, expectedPersons: 1, expectedSubjects: 1},
+		"entrepreneur":      {input: PersonSearchInput{Query: "Jan Novák", Role: rzp.SubjectRoleEntrepreneur}, expectedPersons: 0},
+		"historical roles":  {input: PersonSearchInput{Query: "Jan Novák", IncludeHistorical: true}, expectedPersons: 2},
+		"outside of window": {input: PersonSearchInput{Query: "Jan Novák", BornAfter: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)}, expectedPersons: 0},
+		"fuzzy":             {input: PersonSearchInput{Query: "Jan Novák", Fuzzy: true}, expectedPersons: 1, expectedSubjects: 2},
+	}
+	server := justicetest.NewServer(t)
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			persons, err := Justice(context.Background(), test.input, slog.Default(), justice.WithBaseUrl(server.URL))
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if len(persons) != test.expectedPersons {
+				t.Fatalf("Expected %d persons, got %v", test.expectedPersons, persons)
+			}
+			if test.expectedSubjects == 0 {
+				return
+			}
+			if subjects := persons[0].Subjects; len(subjects) != test.expectedSubjects || subjects[0].Ico != "45678910" {
+				t.Errorf("Expected %d subjects of 45678910, got %v", test.expectedSubjects, subjects)
+			}
+		})
+	}
+}
+
+func Test_matchesQuery(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		person   AssociatedPerson
+		name     names.Name
+		fuzzy    bool
+		expected bool
+	}{
+		"capitals":                {person: AssociatedPerson{FirstName: "JAN", LastName: "NOVÁK"}, name: names.Name{FirstName: "Jan", Surname: "Novák"}, expected: true},
+		"without diacritics":      {person: AssociatedPerson{FirstName: "JIŘÍ", LastName: "NOVÁK"}, name: names.Name{FirstName: "Jiri", Surname: "Novak"}, expected: true},
+		"surname only":            {person: AssociatedPerson{FirstName: "JAN", LastName: "NOVÁK"}, name: names.Name{Surname: "Novák"}, expected: true},
+		"female form":             {person: AssociatedPerson{FirstName: "JANA", LastName: "NOVÁKOVÁ"}, name: names.Name{Surname: "Novák"}},
+		"female form fuzzy":       {person: AssociatedPerson{FirstName: "JANA", LastName: "NOVÁKOVÁ"}, name: names.Name{Surname: "Novák"}, fuzzy: true, expected: true},
+		"different name fuzzy":    {person: AssociatedPerson{FirstName: "PETR", LastName: "DVOŘÁK"}, name: names.Name{FirstName: "Jan", Surname: "Novák"}, fuzzy: true},
+		"legal entity":            {person: AssociatedPerson{FullName: "NOVÁK STAVBY s.r.o."}, name: names.Name{Surname: "Novák"}},
+		"different first name":    {person: AssociatedPerson{FirstName: "PETR", LastName: "NOVÁK"}, name: names.Name{FirstName: "Jan", Surname: "Novák"}},
+		"typo in surname fuzzy":   {person: AssociatedPerson{FirstName: "JAN", LastName: "NOVÁK"}, name: names.Name{FirstName: "Jan", Surname: "Nowak"}, fuzzy: true, expected: true},
+		"title":                   {person: AssociatedPerson{FirstName: "JAN", LastName: "NOVÁK", TitleBeforeName: "Ing."}, name: names.Parse("Ing. Jan Novák"), expected: true},
+		"missing title":           {person: AssociatedPerson{FirstName: "JAN", LastName: "NOVÁK"}, name: names.Parse("Ing. Jan Novák")},
+		"typo in surname exactly": {person: AssociatedPerson{FirstName: "JAN", LastName: "NOVÁK"}, name: names.Name{FirstName: "Jan", Surname: "Nowak"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if matches := matchesQuery(test.person, test.name, test.fuzzy); matches != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/names"
+	"github.com/fstaffa/czsnoop/internal/rzp"
+)
+
+// DefaultMaxRequests is the default ceiling on person search requests issued for a single query.
+// Searches needing more return the persons found so far with ErrRequestBudgetExhausted.
+const DefaultMaxRequests = 200
+
+// maxPrefixLength limits how long the first name prefix generated by partitioning can get
+const maxPrefixLength = 3
+
+// maxAge closes birth date windows open to the past, nobody older is expected in the registers
+const maxAge = 120
+
+// firstNameAlphabet contains letters used to extend first name prefixes, including Czech letters with diacritics
+var firstNameAlphabet = []rune("aábcčdďeéěfghiíjklmnňoópqrřsštťuúůvwxyýzž")
+
+var ErrRequestBudgetExhausted = errors.New("request budget exhausted")
+var ErrTooManyMatches = rzp.ErrTooManyMatches
+
+// partitioner re-issues person searches with narrower criteria until RZP returns complete result lists.
+// Incomplete searches are narrowed by birth date when the window fits into the remaining request budget,
+// then by extending generated first name prefixes and finally by adding first names RZP returned.
+type partitioner struct {
+	client      personSearcher
+	input       PersonSearchInput
+	maxRequests int
+	requests    int
+	// today closes birth date windows open to the future
+	today  time.Time
+	logger *slog.Logger
+}
+
+// search runs the query and if the result is incomplete splits it into narrower queries.
+// prefixable marks queries where the first name is a prefix generated by partitioning and can be extended.
+// On ErrRequestBudgetExhausted and ErrTooManyMatches the persons found so far are returned with the error.
+func (p *partitioner) search(query rzp.SearchPersonQuery, prefixable bool) ([]rzp.Person, error) {
+	if p.requests >= p.maxRequests {
+		return nil, fmt.Errorf("%w: more than %d requests needed", ErrRequestBudgetExhausted, p.maxRequests)
+	}
+	p.requests++
+	response, err := p.client.SearchPerson(query)
+	if err != nil {
+		return nil, err
+	}
+	if !response.MorePossibleMatches {
+		return response.People, nil
+	}
+
+	if from, to, ok := p.window(query); ok {
+		p.logger.Debug("Too many possible matches, splitting search by birth date",
+			slog.String("firstName", query.FirstName), slog.String("surname", query.Surname),
+			slog.String("from", from.Format(time.DateOnly)), slog.String("to", to.Format(time.DateOnly)))
+		people, err := p.searchWindow(query, from, to, prefixable)
+		return partialResults(response.People, people, err)
+	}
+
+	if prefixable && len([]rune(query.FirstName)) < maxPrefixLength {
+		p.logger.Debug("Too many possible matches, extending first name prefix",
+			slog.String("firstName", query.FirstName), slog.String("surname", query.Surname))
+		var people []rzp.Person
+		for _, letter := range firstNameAlphabet {
+			partition := query
+			partition.FirstName = query.FirstName + string(letter)
+			partitionPeople, err := p.search(partition, true)
+			people = append(people, partitionPeople...)
+			if err != nil {
+				return partialResults(response.People, people, err)
+			}
+		}
+		return deduplicatePersons(people), nil
+	}
+
+	// persons with longer first names than the query can be searched separately, but the remaining ones
+	// cannot be narrowed any further, so the result stays incomplete
+	firstNames := addedFirstNames(query.FirstName, response.People)
+	p.logger.Debug("Too many possible matches, searching added first names",
+		slog.String("firstName", query.FirstName), slog.String("surname", query.Surname), slog.Int("partitions", len(firstNames)))
+	var people []rzp.Person
+	for _, firstName := range firstNames {
+		partition := query
+		partition.FirstName = firstName
+		partitionPeople, err := p.search(partition, false)
+		people = append(people, partitionPeople...)
+		if err != nil && !errors.Is(err, ErrTooManyMatches) {
+			return partialResults(response.People, people, err)
+		}
+	}
+	return partialResults(response.People, people, ErrTooManyMatches)
+}
+
+// window returns birth date window of the input when the query can be split by birth date within the remaining
+// request budget. Bounds the input leaves open are closed by maxAge and today.
+func (p *partitioner) window(query rzp.SearchPersonQuery) (time.Time, time.Time, bool) {
+	if !query.DateOfBirth.IsZero() || p.input.BornAfter.IsZero() && p.input.BornBefore.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+	to := dateOnly(p.today)
+	if !p.input.BornBefore.IsZero() {
+		to = dateOnly(p.input.BornBefore)
+	}
+	from := dateOnly(p.today).AddDate(-maxAge, 0, 0)
+	if !p.input.BornAfter.IsZero() {
+		from = dateOnly(p.input.BornAfter)
+	}
+	days := daysBetween(from, to) + 1
+	return from, to, days > 0 && days <= p.maxRequests-p.requests
+}
+
+// searchWindow bisects the birth date window, both bounds are inclusive. RZP searches only exact birth dates,
+// so the halves are bisected down to single days, which are searched with the date pushed down to RZP.
+func (p *partitioner) searchWindow(query rzp.SearchPersonQuery, from time.Time, to time.Time, prefixable bool) ([]rzp.Person, error) {
+	if !from.Before(to) {
+		partition := query
+		partition.DateOfBirth = from
+		return p.search(partition, prefixable)
+	}
+	middle := from.AddDate(0, 0, (daysBetween(from, to)+1)/2)
+	people, err := p.searchWindow(query, from, middle.AddDate(0, 0, -1), prefixable)
+	if err != nil {
+		return people, err
+	}
+	laterPeople, err := p.searchWindow(query, middle, to, prefixable)
+	return append(people, laterPeople...), err
+}
+
+// addedFirstNames returns first names of returned persons which extend the searched first name, e.g. with
+// a second first name, in order of the response
+func addedFirstNames(firstName string, people []rzp.Person) []string {
+	searched := names.Normalize(firstName)
+	seen := make(map[string]bool)
+	var result []string
+	for _, person := range people {
+		folded := names.Normalize(person.FirstName)
+		if folded == searched || !strings.HasPrefix(folded, searched) || seen[folded] {
+			continue
+		}
+		seen[folded] = true
+		result = append(result, person.FirstName)
+	}
+	return result
+}
+
+// partialResults returns persons found by an incomplete search, including those listed in its incomplete response
+func partialResults(listed []rzp.Person, found []rzp.Person, err error) ([]rzp.Person, error) {
+	if err == nil {
+		return deduplicatePersons(found), nil
+	}
+	return deduplicatePersons(append(found, listed...)), err
+}
+
+func daysBetween(from time.Time, to time.Time) int {
+	return int(to.Sub(from).Round(24*time.Hour) / (24 * time.Hour))
+}
+
+func deduplicatePersons(people []rzp.Person) []rzp.Person {
+	seen := make(map[rzp.PersonId]bool, len(people))
+	result := make([]rzp.Person, 0, len(people))
+	for _, person := range people {
+		if seen[person.PersonId] {
+			continue
+		}
+		seen[person.PersonId] = true
+		result = append(result, person)
+	}
+	return result
+}
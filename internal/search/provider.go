@@ -0,0 +1,273 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Capability declares a kind of search a provider is able to do
+type Capability string
+
+const (
+	CapabilityPersonSearch  Capability = "person search"
+	CapabilitySubjectLookup Capability = "subject lookup"
+)
+
+var (
+	// ErrNotFound is matched by errors of providers which do not know the looked up subject
+	ErrNotFound = errors.New("not found")
+	// ErrUnsupported is returned by providers asked for a search they did not declare in their capabilities
+	ErrUnsupported = errors.New("not supported by the provider")
+)
+
+// Provider is a source of data about persons and economic subjects, e.g. a public register
+type Provider interface {
+	// Name identifies the provider, e.g. in --providers flag
+	Name() string
+	Capabilities() []Capability
+	// SearchPersons finds persons with their subjects, persons found before a failure are returned with the error
+	SearchPersons(ctx context.Context, input PersonSearchInput, logger *slog.Logger) ([]Person, error)
+	// LookupSubject returns profile of the subject, error matches ErrNotFound if the provider does not know it
+	LookupSubject(ctx context.Context, input CompanySearchInput, logger *slog.Logger) (Company, error)
+}
+
+// Supports checks whether the provider declares the capability
+func Supports(provider Provider, capability Capability) bool {
+	return slices.Contains(provider.Capabilities(), capability)
+}
+
+// Registry keeps known providers in order of their registration, which is also the order their results are merged in
+type Registry struct {
+	providers []Provider
+}
+
+func NewRegistry(providers ...Provider) (*Registry, error) {
+	registry := &Registry{}
+	for _, provider := range providers {
+		if err := registry.Register(provider); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// Register adds the provider, names of providers have to be unique
+func (r *Registry) Register(provider Provider) error {
+	if _, ok := r.provider(provider.Name()); ok {
+		return fmt.Errorf("provider %s is already registered", provider.Name())
+	}
+	r.providers = append(r.providers, provider)
+	return nil
+}
+
+// Names lists names of registered providers
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for _, provider := range r.providers {
+		names = append(names, provider.Name())
+	}
+	return names
+}
+
+// Select returns providers with given names in order of their registration, empty names select all providers
+func (r *Registry) Select(names []string) ([]Provider, error) {
+	if len(names) == 0 {
+		return slices.Clone(r.providers), nil
+	}
+	for _, name := range names {
+		if _, ok := r.provider(name); !ok {
+			return nil, fmt.Errorf("unknown provider %q, expected one of %s", name, strings.Join(r.Names(), ", "))
+		}
+	}
+	var selected []Provider
+	for _, provider := range r.providers {
+		if slices.Contains(names, provider.Name()) {
+			selected = append(selected, provider)
+		}
+	}
+	return selected, nil
+}
+
+func (r *Registry) provider(name string) (Provider, bool) {
+	for _, provider := range r.providers {
+		if provider.Name() == name {
+			return provider, true
+		}
+	}
+	return nil, false
+}
+
+// ProviderStatus describes how a single provider did in a search
+type ProviderStatus struct {
+	Provider string
+	// Skipped is set when the provider does not support the search
+	Skipped bool
+	// Results is number of found persons, or 1 when the provider found the looked up subject
+	Results  int
+	Duration time.Duration
+	// Err matches ErrNotFound when the provider does not know the looked up subject
+	Err error
+}
+
+// State summarizes the status as one of ok, skipped, not found and failed
+func (s ProviderStatus) State() string {
+	switch {
+	case s.Skipped:
+		return "skipped"
+	case s.Err == nil:
+		return "ok"
+	case errors.Is(s.Err, ErrNotFound):
+		return "not found"
+	}
+	return "failed"
+}
+
+// providerResult is what a provider returned from a search, kept in order of providers
+type providerResult[T any] struct {
+	result T
+	status ProviderStatus
+}
+
+// fanOut runs search in all providers supporting the capability concurrently. When failFast is set,
+// the first failure cancels searches of other providers.
+func fanOut[T any](ctx context.Context, providers []Provider, capability Capability, failFast bool, logger *slog.Logger,
+	search func(ctx context.Context, provider Provider) (T, int, error)) []providerResult[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]providerResult[T], len(providers))
+	wg := sync.WaitGroup{}
+	for i, provider := range providers {
+		results[i].status.Provider = provider.Name()
+		if !Supports(provider, capability) {
+			results[i].status.Skipped = true
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			result, count, err := search(ctx, provider)
+			results[i] = providerResult[T]{result: result, status: ProviderStatus{
+				Provider: provider.Name(),
+				Results:  count,
+				Duration: time.Since(start),
+				Err:      err,
+			}}
+			logger.Debug("Provider finished", slog.String("provider", provider.Name()), slog.Duration("duration", results[i].status.Duration))
+			if err != nil && failFast && !errors.Is(err, ErrNotFound) {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// SearchPersons searches persons in all providers supporting person search concurrently, person found by more
+// providers is resolved into one with subjects from all of them, see resolvePersons. When some providers fail,
+// persons from the others are returned with their errors, unless input.FailFast is set.
+func SearchPersons(ctx context.Context, providers []Provider, input PersonSearchInput, logger *slog.Logger) ([]Person, []ProviderStatus, error) {
+	if !slices.ContainsFunc(providers, func(p Provider) bool { return Supports(p, CapabilityPersonSearch) }) {
+		return nil, nil, fmt.Errorf("none of the providers supports %s", CapabilityPersonSearch)
+	}
+	results := fanOut(ctx, providers, CapabilityPersonSearch, input.FailFast, logger,
+		func(ctx context.Context, provider Provider) ([]Person, int, error) {
+			persons, err := provider.SearchPersons(ctx, input, logger.With("provider", provider.Name()))
+			return persons, len(persons), err
+		})
+
+	var reported []reportedPersons
+	statuses := make([]ProviderStatus, 0, len(results))
+	var errs []error
+	for _, result := range results {
+		statuses = append(statuses, result.status)
+		if err := result.status.Err; err != nil {
+			errs = append(errs, fmt.Errorf("provider %s: %w", result.status.Provider, err))
+		}
+		reported = append(reported, reportedPersons{provider: result.status.Provider, persons: result.result})
+	}
+	if input.FailFast && len(errs) > 0 {
+		// other providers were canceled by the failure, their errors only say so
+		for _, err := range errs {
+			if !errors.Is(err, context.Canceled) {
+				return nil, statuses, err
+			}
+		}
+		return nil, statuses, errs[0]
+	}
+	persons := resolvePersons(reported, logger)
+	if input.Fuzzy {
+		rankPersons(persons, input.name())
+	}
+	return persons, statuses, errors.Join(errs...)
+}
+
+// LookupSubject looks up the subject in all providers supporting subject lookup concurrently and combines their
+// profiles in order of providers, see Company.merge. Subject unknown to some providers is not an error unless none
+// of them knows it. When some providers fail, profile from the others is returned with their errors.
+func LookupSubject(ctx context.Context, providers []Provider, input CompanySearchInput, logger *slog.Logger) (Company, []ProviderStatus, error) {
+	if !slices.ContainsFunc(providers, func(p Provider) bool { return Supports(p, CapabilitySubjectLookup) }) {
+		return Company{}, nil, fmt.Errorf("none of the providers supports %s", CapabilitySubjectLookup)
+	}
+	results := fanOut(ctx, providers, CapabilitySubjectLookup, false, logger,
+		func(ctx context.Context, provider Provider) (Company, int, error) {
+			company, err := provider.LookupSubject(ctx, input, logger.With("provider", provider.Name()))
+			if err != nil {
+				return Company{}, 0, err
+			}
+			return company, 1, nil
+		})
+
+	var company Company
+	statuses := make([]ProviderStatus, 0, len(results))
+	var errs, notFound []error
+	for _, result := range results {
+		statuses = append(statuses, result.status)
+		switch err := result.status.Err; {
+		case result.status.Skipped:
+		case errors.Is(err, ErrNotFound):
+			notFound = append(notFound, err)
+		case err != nil:
+			errs = append(errs, fmt.Errorf("provider %s: %w", result.status.Provider, err))
+		default:
+			company = company.merge(result.result)
+		}
+	}
+	if company.Ico == "" && len(errs) == 0 {
+		return Company{}, statuses, errors.Join(notFound...)
+	}
+	return company, statuses, errors.Join(errs...)
+}
+
+// notFoundError marks error of a provider meaning that it does not know the subject, it matches ErrNotFound
+// while the original error stays available for errors.Is and errors.As
+type notFoundError struct {
+	err error
+}
+
+func (e *notFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notFoundError) Unwrap() error {
+	return e.err
+}
+
+func (e *notFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// markNotFound wraps err into notFoundError if it matches notFound sentinel of the provider
+func markNotFound(err error, notFound error) error {
+	if errors.Is(err, notFound) {
+		return &notFoundError{err: err}
+	}
+	return err
+}
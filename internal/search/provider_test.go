@@ -0,0 +1,260 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/fstaffa/czsnoop/internal/ares"
+	"github.com/fstaffa/czsnoop/internal/ares/arestest"
+	"github.com/fstaffa/czsnoop/internal/justice"
+	"github.com/fstaffa/czsnoop/internal/justice/justicetest"
+	"github.com/fstaffa/czsnoop/internal/rzp"
+	"github.com/fstaffa/czsnoop/internal/rzp/rzptest"
+	"github.com/fstaffa/czsnoop/internal/types"
+)
+
+// testRegistry creates registry of built-in providers backed by fake registries, RZP serving given fixtures
+func testRegistry(t *testing.T, rzpFixtures []rzptest.Fixture) *Registry {
+	t.Helper()
+	return NewRegistryWith(
+		[]rzp.Option{rzp.WithBaseUrl(rzptest.NewServerWithFixtures(t, rzpFixtures).URL)},
+		[]justice.Option{justice.WithBaseUrl(justicetest.NewServer(t).URL)},
+		[]ares.Option{ares.WithBaseUrl(arestest.NewServer(t).URL)},
+	)
+}
+
+// unavailableServer answers every request with 503 Service Unavailable
+func unavailableServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_Registry_Select(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		names       []string
+		expected    []string
+		expectError bool
+	}{
+		"all by default":      {expected: []string{ProviderRzp, ProviderJustice, ProviderAres}},
+		"in registered order": {names: []string{ProviderAres, ProviderRzp}, expected: []string{ProviderRzp, ProviderAres}},
+		"unknown provider":    {names: []string{ProviderRzp, "isir"}, expectError: true},
+	}
+	registry := NewRegistryWith(nil, nil, nil)
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			providers, err := registry.Select(test.names)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("Expected error, got %v", providers)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			var names []string
+			for _, provider := range providers {
+				names = append(names, provider.Name())
+			}
+			if !slices.Equal(names, test.expected) {
+				t.Errorf("Expected providers %v, got %v", test.expected, names)
+			}
+		})
+	}
+}
+
+func Test_Registry_Register(t *testing.T) {
+	t.Parallel()
+	_, err := NewRegistry(NewRzpProvider(), NewRzpProvider())
+	if err == nil {
+		t.Errorf("Expected error for duplicate provider, got nil")
+	}
+}
+
+func Test_SearchPersons(t *testing.T) {
+	t.Parallel()
+	providers, err := testRegistry(t, rzptest.Fixtures).Select(nil)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	persons, statuses, err := SearchPersons(context.Background(), providers, PersonSearchInput{Query: "Jan Novák"}, slog.Default())
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if len(persons) != 2 {
+		t.Fatalf("Expected 2 persons, got %v", persons)
+	}
+	sort.Slice(persons, func(i, j int) bool { return persons[i].BirthDate.Before(persons[j].BirthDate) })
+	subjects := persons[1].Subjects
+	if len(subjects) != 2 || subjects[0].Role != RoleStatutoryBodyMember || subjects[1].Role != RoleShareholder {
+		t.Errorf("Expected board membership from RZP and shareholding from the commercial register, got %v", subjects)
+	}
+	if len(persons[1].Sources) != 2 || len(persons[1].Merges) != 1 || persons[1].Merges[0].Provider != ProviderJustice {
+		t.Errorf("Expected person from RZP merged with the commercial register, got %+v", persons[1])
+	}
+	if !slices.Equal(subjects[0].Sources, []string{ProviderRzp, ProviderJustice}) || !slices.Equal(subjects[1].Sources, []string{ProviderJustice}) {
+		t.Errorf("Expected subjects with their providers, got %+v", subjects)
+	}
+
+	var states []string
+	for _, status := range statuses {
+		states = append(states, status.Provider+" "+status.State())
+	}
+	if expected := []string{"rzp ok", "justice ok", "ares ok"}; !slices.Equal(states, expected) {
+		t.Errorf("Expected statuses %v, got %v", expected, states)
+	}
+	if statuses[0].Results != 2 || statuses[1].Results != 1 || statuses[0].Duration <= 0 {
+		t.Errorf("Expected counts and durations of providers, got %+v", statuses)
+	}
+}
+
+func Test_SearchPersons_ProviderFailure(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		failFast        bool
+		expectedPersons int
+	}{
+		"best effort": {expectedPersons: 1},
+		"fail fast":   {failFast: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			providers := []Provider{
+				NewRzpProvider(rzp.WithBaseUrl(unavailableServer(t).URL), rzp.WithRetry(0, 0)),
+				NewJusticeProvider(justice.WithBaseUrl(justicetest.NewServer(t).URL)),
+			}
+			persons, statuses, err := SearchPersons(context.Background(), providers, PersonSearchInput{Query: "Jan Novák", FailFast: test.failFast}, slog.Default())
+			if err == nil {
+				t.Fatalf("Expected error, got nil")
+			}
+			if len(persons) != test.expectedPersons {
+				t.Errorf("Expected %d persons, got %v", test.expectedPersons, persons)
+			}
+			if statuses[0].State() != "failed" {
+				t.Errorf("Expected RZP to fail, got %+v", statuses[0])
+			}
+		})
+	}
+}
+
+func Test_SearchPersons_Unsupported(t *testing.T) {
+	t.Parallel()
+	_, _, err := SearchPersons(context.Background(), []Provider{NewAresProvider()}, PersonSearchInput{Query: "Jan Novák"}, slog.Default())
+	if err == nil {
+		t.Errorf("Expected error when no provider searches persons, got nil")
+	}
+}
+
+func Test_LookupSubject(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		ico                string
+		expectedName       string
+		expectedTrades     int
+		expectedPersons    int
+		expectedFileNumber string
+		expectedVatId      string
+		expectedStates     []string
+	}{
+		"all providers": {
+			ico: "01895541", expectedName: "THOMAS SILVERTONNI s.r.o.", expectedTrades: 1, expectedPersons: 2,
+			expectedFileNumber: "C 226710 vedená u Městského soudu v Praze", expectedVatId: "CZ01895541",
+			expectedStates: []string{"ok", "ok", "ok"},
+		},
+		"not in commercial register": {
+			ico: "73452301", expectedName: "Ing. Petr Novák, Ph.D.", expectedTrades: 2, expectedPersons: 2,
+			expectedVatId: "CZ8309271234", expectedStates: []string{"ok", "not found", "ok"},
+		},
+		"not in RZP": {
+			ico: "24681351", expectedName: "NOVÁK STAVBY s.r.o.", expectedPersons: 2,
+			expectedFileNumber: "C 19877 vedená u Krajského soudu v Českých Budějovicích", expectedVatId: "CZ24681351",
+			expectedStates: []string{"not found", "ok", "ok"},
+		},
+	}
+	providers, err := testRegistry(t, rzptest.Fixtures).Select(nil)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			company, statuses, err := LookupSubject(context.Background(), providers, CompanySearchInput{Ico: types.Ico(test.ico)}, slog.Default())
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if company.Name != test.expectedName || company.FileNumber != test.expectedFileNumber || company.VatId != test.expectedVatId {
+				t.Errorf("Expected %s with file number %q and VAT id %s, got %+v", test.expectedName, test.expectedFileNumber, test.expectedVatId, company)
+			}
+			if len(company.Trades) != test.expectedTrades || len(company.Persons) != test.expectedPersons {
+				t.Errorf("Expected %d trades and %d persons, got %+v", test.expectedTrades, test.expectedPersons, company)
+			}
+			var states []string
+			for _, status := range statuses {
+				states = append(states, status.State())
+			}
+			if !slices.Equal(states, test.expectedStates) {
+				t.Errorf("Expected statuses %v, got %v", test.expectedStates, states)
+			}
+		})
+	}
+}
+
+func Test_LookupSubject_PartialResults(t *testing.T) {
+	t.Parallel()
+	providers := []Provider{
+		NewRzpProvider(rzp.WithBaseUrl(unavailableServer(t).URL), rzp.WithRetry(0, 0)),
+		NewJusticeProvider(justice.WithBaseUrl(justicetest.NewServer(t).URL)),
+	}
+
+	company, _, err := LookupSubject(context.Background(), providers, CompanySearchInput{Ico: "01895541"}, slog.Default())
+	if err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+	if company.Name != "THOMAS SILVERTONNI s.r.o." || len(company.Persons) != 2 {
+		t.Errorf("Expected profile from the commercial register, got %+v", company)
+	}
+}
+
+func Test_LookupSubject_NotFound(t *testing.T) {
+	t.Parallel()
+	providers, err := testRegistry(t, rzptest.Fixtures).Select(nil)
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	_, _, err = LookupSubject(context.Background(), providers, CompanySearchInput{Ico: "27074358"}, slog.Default())
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, rzp.ErrNotFound) || !errors.Is(err, justice.ErrNotFound) || !errors.Is(err, ares.ErrNotFound) {
+		t.Errorf("Expected not found in all providers, got %v", err)
+	}
+}
+
+func Test_LookupSubject_TerminatedInAres(t *testing.T) {
+	t.Parallel()
+	providers := []Provider{NewAresProvider(ares.WithBaseUrl(arestest.NewServer(t).URL))}
+
+	_, _, err := LookupSubject(context.Background(), providers, CompanySearchInput{Ico: "27345009"}, slog.Default())
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected terminated subject not to be found, got %v", err)
+	}
+	company, _, err := LookupSubject(context.Background(), providers, CompanySearchInput{Ico: "27345009", IncludeHistorical: true}, slog.Default())
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if company.TerminatedOn.IsZero() {
+		t.Errorf("Expected date of termination, got %+v", company)
+	}
+}
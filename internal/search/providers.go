@@ -0,0 +1,103 @@
+package search
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/fstaffa/czsnoop/internal/ares"
+	"github.com/fstaffa/czsnoop/internal/justice"
+	"github.com/fstaffa/czsnoop/internal/rzp"
+)
+
+const (
+	ProviderRzp     = "rzp"
+	ProviderJustice = "justice"
+	ProviderAres    = "ares"
+)
+
+// NewRegistryWith creates registry of all built-in providers, each of them uses its own client options
+func NewRegistryWith(rzpOptions []rzp.Option, justiceOptions []justice.Option, aresOptions []ares.Option) *Registry {
+	return &Registry{providers: []Provider{
+		NewRzpProvider(rzpOptions...),
+		NewJusticeProvider(justiceOptions...),
+		NewAresProvider(aresOptions...),
+	}}
+}
+
+type rzpProvider struct {
+	options []rzp.Option
+}
+
+// NewRzpProvider searches the trade register (RZP)
+func NewRzpProvider(options ...rzp.Option) Provider {
+	return &rzpProvider{options: options}
+}
+
+func (p *rzpProvider) Name() string {
+	return ProviderRzp
+}
+
+func (p *rzpProvider) Capabilities() []Capability {
+	return []Capability{CapabilityPersonSearch, CapabilitySubjectLookup}
+}
+
+func (p *rzpProvider) SearchPersons(ctx context.Context, input PersonSearchInput, logger *slog.Logger) ([]Person, error) {
+	return Rzp(ctx, input, logger, p.options...)
+}
+
+func (p *rzpProvider) LookupSubject(ctx context.Context, input CompanySearchInput, logger *slog.Logger) (Company, error) {
+	company, err := RzpCompany(ctx, input, logger, p.options...)
+	return company, markNotFound(err, rzp.ErrNotFound)
+}
+
+type justiceProvider struct {
+	options []justice.Option
+}
+
+// NewJusticeProvider searches the commercial register at or.justice.cz
+func NewJusticeProvider(options ...justice.Option) Provider {
+	return &justiceProvider{options: options}
+}
+
+func (p *justiceProvider) Name() string {
+	return ProviderJustice
+}
+
+func (p *justiceProvider) Capabilities() []Capability {
+	return []Capability{CapabilityPersonSearch, CapabilitySubjectLookup}
+}
+
+func (p *justiceProvider) SearchPersons(ctx context.Context, input PersonSearchInput, logger *slog.Logger) ([]Person, error) {
+	return Justice(ctx, input, logger, p.options...)
+}
+
+func (p *justiceProvider) LookupSubject(ctx context.Context, input CompanySearchInput, logger *slog.Logger) (Company, error) {
+	company, err := JusticeCompany(ctx, input, logger, p.options...)
+	return company, markNotFound(err, justice.ErrNotFound)
+}
+
+type aresProvider struct {
+	options []ares.Option
+}
+
+// NewAresProvider looks up subjects in ARES, persons are found only when they trade under their own name
+func NewAresProvider(options ...ares.Option) Provider {
+	return &aresProvider{options: options}
+}
+
+func (p *aresProvider) Name() string {
+	return ProviderAres
+}
+
+func (p *aresProvider) Capabilities() []Capability {
+	return []Capability{CapabilityPersonSearch, CapabilitySubjectLookup}
+}
+
+func (p *aresProvider) SearchPersons(ctx context.Context, input PersonSearchInput, logger *slog.Logger) ([]Person, error) {
+	return Ares(ctx, input, logger, p.options...)
+}
+
+func (p *aresProvider) LookupSubject(ctx context.Context, input CompanySearchInput, logger *slog.Logger) (Company, error) {
+	company, err := AresCompany(ctx, input, logger, p.options...)
+	return company, markNotFound(err, ares.ErrNotFound)
+}
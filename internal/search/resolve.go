@@ -0,0 +1,240 @@
+package search
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/fstaffa/czsnoop/internal/names"
+)
+
+// MergeThreshold is the lowest confidence at which records of different providers are merged into one person,
+// same name with the same birth date, a shared subject or the same address reaches it, same name alone does not
+const MergeThreshold = 0.7
+
+// Weights of evidence that two records describe the same person, confidence is their sum capped at 1
+const (
+	weightSameName          = 0.4
+	weightCompatibleName    = 0.3
+	weightSameBirthDate     = 0.4
+	weightSharedSubject     = 0.3
+	weightAddressSimilarity = 0.3
+)
+
+// PersonSource is the person as a single provider reported it
+type PersonSource struct {
+	Provider  string
+	FullName  string
+	BirthDate time.Time
+	Address   string
+}
+
+// MergeDecision explains why the record of Provider was merged into the person
+type MergeDecision struct {
+	Provider string
+	// FullName of the merged record as the provider reported it
+	FullName   string
+	Confidence float64
+	Reasons    []string
+}
+
+// reportedPersons are persons found by a single provider
+type reportedPersons struct {
+	provider string
+	persons  []Person
+}
+
+// resolvePersons merges persons reported by different providers which describe the same individual. Records of
+// a single provider are never merged together as the provider already tells its persons apart. Each record
+// joins the most similar person reaching MergeThreshold, or becomes a new person.
+func resolvePersons(reported []reportedPersons, logger *slog.Logger) []Person {
+	var persons []Person
+	for _, report := range reported {
+		for _, record := range report.persons {
+			record = withProvenance(record, report.provider)
+			best, bestConfidence, bestReasons := -1, 0.0, []string(nil)
+			for i, person := range persons {
+				if person.reportedBy(report.provider) {
+					continue
+				}
+				confidence, reasons := matchPersons(person, record)
+				if confidence >= MergeThreshold && confidence > bestConfidence {
+					best, bestConfidence, bestReasons = i, confidence, reasons
+				} else if confidence > 0 {
+					logger.Debug("Records not merged", slog.String("person", person.FullName), slog.String("record", record.FullName),
+						slog.String("provider", report.provider), slog.Float64("confidence", confidence))
+				}
+			}
+			if best < 0 {
+				persons = append(persons, record)
+				continue
+			}
+			persons[best] = persons[best].absorb(record, MergeDecision{
+				Provider:   report.provider,
+				FullName:   record.FullName,
+				Confidence: bestConfidence,
+				Reasons:    bestReasons,
+			})
+		}
+	}
+	return persons
+}
+
+// withProvenance marks the person and its subjects as reported by the provider
+func withProvenance(person Person, provider string) Person {
+	person.Sources = []PersonSource{{Provider: provider, FullName: person.FullName, BirthDate: person.BirthDate, Address: person.Address}}
+	subjects := make([]EconomicSubject, 0, len(person.Subjects))
+	for _, subject := range person.Subjects {
+		subject.Sources = []string{provider}
+		subjects = append(subjects, subject)
+	}
+	person.Subjects = subjects
+	return person
+}
+
+func (p Person) reportedBy(provider string) bool {
+	return slices.ContainsFunc(p.Sources, func(s PersonSource) bool { return s.Provider == provider })
+}
+
+// absorb merges the record into the person, values the person already has are kept
+func (p Person) absorb(record Person, decision MergeDecision) Person {
+	if p.TitleBeforeName == "" {
+		p.TitleBeforeName = record.TitleBeforeName
+	}
+	if p.TitleAfterName == "" {
+		p.TitleAfterName = record.TitleAfterName
+	}
+	if p.BirthDate.IsZero() {
+		p.BirthDate = record.BirthDate
+	}
+	if p.Address == "" {
+		p.Address = record.Address
+	}
+	if p.Citizenship == "" {
+		p.Citizenship = record.Citizenship
+	}
+	subjects := slices.Clone(p.Subjects)
+	for _, subject := range record.Subjects {
+		i := slices.IndexFunc(subjects, func(s EconomicSubject) bool { return s.Ico == subject.Ico && s.Role == subject.Role })
+		if i < 0 {
+			subjects = append(subjects, subject)
+			continue
+		}
+		subjects[i].Sources = slices.Concat(subjects[i].Sources, subject.Sources)
+		if subjects[i].LegalForm == "" {
+			// known only to ARES, which may have reported the record
+			subjects[i].LegalForm, subjects[i].VatId, subjects[i].VatPayer, subjects[i].NaceCodes = subject.LegalForm, subject.VatId, subject.VatPayer, subject.NaceCodes
+		}
+	}
+	p.Subjects = subjects
+	p.Sources = slices.Concat(p.Sources, record.Sources)
+	p.Merges = append(slices.Clone(p.Merges), decision)
+	return p
+}
+
+// matchPersons scores how likely the records describe the same person and explains the score,
+// records with different names or birth dates score 0, missing first name adds nothing to the score
+func matchPersons(a Person, b Person) (float64, []string) {
+	if names.Normalize(a.LastName) == "" || !names.Equal(a.LastName, b.LastName) {
+		return 0, nil
+	}
+	var confidence float64
+	var reasons []string
+	firstA, firstB := names.Normalize(a.FirstName), names.Normalize(b.FirstName)
+	switch {
+	case firstA == "" || firstB == "":
+		// surname alone is shared by too many people, the records need other evidence to be merged
+		reasons = append(reasons, "missing first name")
+	case firstA == firstB:
+		confidence += weightSameName
+		reasons = append(reasons, "same name")
+	case compatibleFirstNames(firstA, firstB):
+		confidence += weightCompatibleName
+		reasons = append(reasons, fmt.Sprintf("compatible first names %s and %s", a.FirstName, b.FirstName))
+	default:
+		return 0, nil
+	}
+
+	if !a.BirthDate.IsZero() && !b.BirthDate.IsZero() {
+		if !dateOnly(a.BirthDate).Equal(dateOnly(b.BirthDate)) {
+			return 0, nil
+		}
+		confidence += weightSameBirthDate
+		reasons = append(reasons, "same birth date "+a.BirthDate.Format(time.DateOnly))
+	}
+	if ico, ok := sharedIco(a.Subjects, b.Subjects); ok {
+		confidence += weightSharedSubject
+		reasons = append(reasons, "shared subject "+string(ico))
+	}
+	if a.Address != "" && b.Address != "" {
+		similarity := addressSimilarity(a.Address, b.Address)
+		confidence += weightAddressSimilarity * similarity
+		reasons = append(reasons, fmt.Sprintf("address similarity %.2f", similarity))
+	}
+	return min(confidence, 1), reasons
+}
+
+// compatibleFirstNames accepts first names where one lists only some of the names of the other, e.g. Jan and Jan Petr
+func compatibleFirstNames(a string, b string) bool {
+	namesA, namesB := strings.Fields(a), strings.Fields(b)
+	if len(namesA) > len(namesB) {
+		namesA, namesB = namesB, namesA
+	}
+	for _, name := range namesA {
+		if !slices.Contains(namesB, name) {
+			return false
+		}
+	}
+	return true
+}
+
+func sharedIco(a []EconomicSubject, b []EconomicSubject) (string, bool) {
+	for _, subjectA := range a {
+		for _, subjectB := range b {
+			if subjectA.Ico != "" && subjectA.Ico == subjectB.Ico {
+				return string(subjectA.Ico), true
+			}
+		}
+	}
+	return "", false
+}
+
+// addressSimilarity is Jaccard index of address tokens, registries format the same address differently,
+// e.g. "Sokolovská 352/215, 190 00, Praha 9 - Vysočany" and "Sokolovská 352/215, Vysočany, 19000 Praha 9"
+func addressSimilarity(a string, b string) float64 {
+	tokensA, tokensB := addressTokens(a), addressTokens(b)
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		return 0
+	}
+	shared := 0
+	for token := range tokensA {
+		if tokensB[token] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(tokensA)+len(tokensB)-shared)
+}
+
+func addressTokens(address string) map[string]bool {
+	fields := strings.FieldsFunc(names.Fold(strings.ToLower(address)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '/'
+	})
+	tokens := make(map[string]bool, len(fields))
+	for i := 0; i < len(fields); i++ {
+		token := fields[i]
+		// postal code is written both as "190 00" and "19000"
+		if len(token) == 3 && i+1 < len(fields) && len(fields[i+1]) == 2 && isDigits(token) && isDigits(fields[i+1]) {
+			token += fields[i+1]
+			i++
+		}
+		tokens[token] = true
+	}
+	return tokens
+}
+
+func isDigits(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+}
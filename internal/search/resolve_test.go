@@ -0,0 +1,154 @@
+package search
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func Test_resolvePersons(t *testing.T) {
+	t.Parallel()
+	birthDate := time.Date(1980, time.June, 1, 0, 0, 0, 0, time.UTC)
+	subject := EconomicSubject{Ico: "45678910", Role: RoleStatutoryBodyMember}
+	tests := map[string]struct {
+		rzp             Person
+		justice         Person
+		expectedPersons int
+	}{
+		"titles and diacritics differ": {
+			rzp:             Person{FirstName: "Jan", LastName: "Novák", TitleBeforeName: "Ing.", BirthDate: birthDate},
+			justice:         Person{FirstName: "JAN", LastName: "NOVAK", BirthDate: birthDate},
+			expectedPersons: 1,
+		},
+		"different birth dates": {
+			rzp:             Person{FirstName: "Jan", LastName: "Novák", BirthDate: birthDate},
+			justice:         Person{FirstName: "Jan", LastName: "Novák", BirthDate: birthDate.AddDate(0, 0, 1), Subjects: []EconomicSubject{subject}},
+			expectedPersons: 2,
+		},
+		"shared subject without birth date": {
+			rzp:             Person{FirstName: "Jan", LastName: "Novák", Subjects: []EconomicSubject{subject}},
+			justice:         Person{FirstName: "Jan", LastName: "Novák", BirthDate: birthDate, Subjects: []EconomicSubject{subject}},
+			expectedPersons: 1,
+		},
+		"same name only": {
+			rzp:             Person{FirstName: "Jan", LastName: "Novák"},
+			justice:         Person{FirstName: "Jan", LastName: "Novák", BirthDate: birthDate},
+			expectedPersons: 2,
+		},
+		"same name with the same address": {
+			rzp:             Person{FirstName: "Jan", LastName: "Novák", Address: "Sokolovská 352/215, 190 00, Praha 9 - Vysočany"},
+			justice:         Person{FirstName: "Jan", LastName: "Novák", Address: "Sokolovská 352/215, Vysočany, 19000 Praha 9"},
+			expectedPersons: 1,
+		},
+		"another first name": {
+			rzp:             Person{FirstName: "Jan Petr", LastName: "Novák", BirthDate: birthDate},
+			justice:         Person{FirstName: "Jan", LastName: "Novák", BirthDate: birthDate},
+			expectedPersons: 1,
+		},
+		"missing first name with the same birth date": {
+			rzp:             Person{FirstName: "Jan", LastName: "Novák", BirthDate: birthDate},
+			justice:         Person{LastName: "Novák", BirthDate: birthDate},
+			expectedPersons: 2,
+		},
+		"missing first name with shared subject": {
+			rzp:             Person{FirstName: "Jan", LastName: "Novák", BirthDate: birthDate, Subjects: []EconomicSubject{subject}},
+			justice:         Person{LastName: "Novák", BirthDate: birthDate, Subjects: []EconomicSubject{subject}},
+			expectedPersons: 1,
+		},
+		"different first names": {
+			rzp:             Person{FirstName: "Jan", LastName: "Novák", BirthDate: birthDate},
+			justice:         Person{FirstName: "Petr", LastName: "Novák", BirthDate: birthDate},
+			expectedPersons: 2,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			persons := resolvePersons([]reportedPersons{
+				{provider: ProviderRzp, persons: []Person{test.rzp}},
+				{provider: ProviderJustice, persons: []Person{test.justice}},
+			}, slog.Default())
+			if len(persons) != test.expectedPersons {
+				t.Fatalf("Expected %d persons, got %+v", test.expectedPersons, persons)
+			}
+			if test.expectedPersons != 1 {
+				return
+			}
+			person := persons[0]
+			if len(person.Sources) != 2 || person.Sources[0].Provider != ProviderRzp || person.Sources[1].Provider != ProviderJustice {
+				t.Errorf("Expected sources from both providers, got %+v", person.Sources)
+			}
+			if len(person.Merges) != 1 || person.Merges[0].Confidence < MergeThreshold || len(person.Merges[0].Reasons) == 0 {
+				t.Errorf("Expected explained merge, got %+v", person.Merges)
+			}
+		})
+	}
+}
+
+func Test_resolvePersons_SameProvider(t *testing.T) {
+	t.Parallel()
+	birthDate := time.Date(1980, time.June, 1, 0, 0, 0, 0, time.UTC)
+	person := Person{FirstName: "Jan", LastName: "Novák", BirthDate: birthDate}
+
+	persons := resolvePersons([]reportedPersons{{provider: ProviderRzp, persons: []Person{person, person}}}, slog.Default())
+	if len(persons) != 2 {
+		t.Errorf("Expected persons of a single provider not to be merged, got %+v", persons)
+	}
+}
+
+func Test_resolvePersons_Fills(t *testing.T) {
+	t.Parallel()
+	birthDate := time.Date(1980, time.June, 1, 0, 0, 0, 0, time.UTC)
+	persons := resolvePersons([]reportedPersons{
+		{provider: ProviderRzp, persons: []Person{{FirstName: "Jan", LastName: "Novák", BirthDate: birthDate,
+			Subjects: []EconomicSubject{{Ico: "45678910", Role: RoleStatutoryBodyMember}}}}},
+		{provider: ProviderJustice, persons: []Person{{FirstName: "JAN", LastName: "NOVÁK", TitleBeforeName: "Ing.", BirthDate: birthDate,
+			Address: "Sokolovská 352/215, Praha 9", Subjects: []EconomicSubject{
+				{Ico: "45678910", Role: RoleStatutoryBodyMember},
+				{Ico: "45678910", Role: RoleShareholder},
+			}}}},
+	}, slog.Default())
+	if len(persons) != 1 {
+		t.Fatalf("Expected 1 person, got %+v", persons)
+	}
+	person := persons[0]
+	if person.FirstName != "Jan" || person.TitleBeforeName != "Ing." || person.Address != "Sokolovská 352/215, Praha 9" {
+		t.Errorf("Expected name from RZP with title and address from the commercial register, got %+v", person)
+	}
+	if len(person.Subjects) != 2 || len(person.Subjects[0].Sources) != 2 || len(person.Subjects[1].Sources) != 1 {
+		t.Errorf("Expected subjects with their providers, got %+v", person.Subjects)
+	}
+
+	persons = resolvePersons([]reportedPersons{
+		{provider: ProviderRzp, persons: []Person{{FirstName: "Petr", LastName: "Novák",
+			Subjects: []EconomicSubject{{Ico: "73452301", Role: RoleEntrepreneur}}}}},
+		{provider: ProviderAres, persons: []Person{{FirstName: "Petr", LastName: "Novák",
+			Subjects: []EconomicSubject{{Ico: "73452301", Role: RoleEntrepreneur, LegalForm: "Fyzická osoba podnikající dle živnostenského zákona", VatId: "CZ8309271234"}}}}},
+	}, slog.Default())
+	if len(persons) != 1 || len(persons[0].Subjects) != 1 || persons[0].Subjects[0].VatId != "CZ8309271234" {
+		t.Errorf("Expected subject from RZP completed with data from ARES, got %+v", persons)
+	}
+}
+
+func Test_addressSimilarity(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		a        string
+		b        string
+		expected float64
+	}{
+		"same":              {a: "Na Příkopě 1, Praha 1", b: "Na Příkopě 1, Praha 1", expected: 1},
+		"formatting":        {a: "Na Příkopě 1, 110 00, Praha 1 - Staré Město", b: "NA PRIKOPE 1, Staré Město, 11000 Praha 1", expected: 1},
+		"different address": {a: "Na Příkopě 1, Praha 1", b: "Sokolovská 352/215, Brno", expected: 0},
+		"empty":             {a: "", b: "Na Příkopě 1, Praha 1", expected: 0},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			similarity := addressSimilarity(test.a, test.b)
+			if similarity != test.expected {
+				t.Errorf("Expected similarity %v, got %v", test.expected, similarity)
+			}
+		})
+	}
+}
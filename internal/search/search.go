@@ -2,21 +2,43 @@ package search
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/fstaffa/czsnoop/internal/names"
 	"github.com/fstaffa/czsnoop/internal/rzp"
 	"github.com/fstaffa/czsnoop/internal/types"
 )
 
 type PersonSearchInput struct {
-	Ico        types.Ico
-	Query      string
+	Ico types.Ico
+	// Query is the searched name, it is parsed by names.Parse unless Surname is set
+	Query string
+	// FirstName and Surname are explicitly given parts of the searched name, they take precedence over Query
+	FirstName  string
+	Surname    string
 	BornAfter  time.Time
 	BornBefore time.Time
+	// FailFast stops the search on the first error instead of returning partial results
+	FailFast bool
+	// Concurrency limits number of persons searched at once, 0 means rzp.DefaultConcurrency
+	Concurrency int
+	// MaxRequests limits number of person search requests when splitting incomplete results, 0 means DefaultMaxRequests
+	MaxRequests int
+	// IncludeHistorical finds also former roles of persons, by default only currently valid records are searched
+	IncludeHistorical bool
+	// Role restricts subjects to those where the person has the role, persons without such subjects are omitted
+	Role rzp.SubjectRole
+	// Fuzzy searches RZP also for the female or male forms of the surname and its forms without diacritics,
+	// accepts names similar to the query, e.g. with typos, and ranks persons by their Score. Registries are
+	// not searched for the typos themselves, only persons found are matched. By default names must match the
+	// query apart from case and diacritics.
+	Fuzzy bool
 }
 
 type Person struct {
@@ -29,115 +51,334 @@ type Person struct {
 	FullName        string
 	Address         string
 	Subjects        []EconomicSubject
+	// Score is how well the name matches the query between 0 and 1, nil unless set by fuzzy search
+	Score *float64
+	// Sources are the records of each provider which reported the person
+	Sources []PersonSource
+	// Merges explain why records of other providers were merged into the first one, empty if there were none
+	Merges []MergeDecision
 }
 
 type EconomicSubject struct {
 	Name    string
 	Address string
 	Ico     types.Ico
+	// Role of the person in the subject, empty if it could not be determined
+	Role string
+	// Period of validity of the record, To is zero while it is valid
+	Period
+	// LegalForm, VatId, VatPayer and NaceCodes are known only for subjects looked up in ARES
+	LegalForm string
+	VatId     string
+	VatPayer  bool
+	NaceCodes []string
+	// Sources are names of providers which reported the subject
+	Sources []string
 }
 
-func Rzp(input PersonSearchInput, logger *slog.Logger) ([]Person, error) {
-	ctx, cancel := context.WithCancelCause(context.Background())
+// PersonError describes failure to find subjects or subject details of a single person
+type PersonError struct {
+	Person   string
+	PersonId rzp.PersonId
+	// Subject is empty when searching subjects of the person failed
+	Subject string
+	Err     error
+}
+
+func (e *PersonError) Error() string {
+	if e.Subject != "" {
+		return fmt.Sprintf("person %s (%s), subject %s: %v", e.Person, e.PersonId, e.Subject, e.Err)
+	}
+	return fmt.Sprintf("person %s (%s): %v", e.Person, e.PersonId, e.Err)
+}
+
+func (e *PersonError) Unwrap() error {
+	return e.Err
+}
+
+// Rzp searches persons in RZP together with their economic subjects. Unless input.FailFast is set,
+// persons whose subjects could not be fully searched are returned with the data that was found and
+// the errors are returned joined with errors.Join, each of them being *PersonError. Persons found before
+// the person search itself had to stop are returned the same way, with ErrRequestBudgetExhausted or ErrTooManyMatches.
+func Rzp(ctx context.Context, input PersonSearchInput, logger *slog.Logger, options ...rzp.Option) ([]Person, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
 	logger = logger.With("search", "rzp")
 	defer cancel(nil)
-	client, err := rzp.CreateClient(ctx, logger.With("client", "rzp"))
+	client, err := rzp.CreateClient(ctx, logger.With("client", "rzp"), options...)
 	if err != nil {
-		return nil, fmt.Errorf("unable to create RZP client: %v", err)
+		return nil, fmt.Errorf("unable to create RZP client: %w", err)
 	}
-	var searchQuery rzp.SearchSubjectQuery
-	if input.Query != "" {
-		searchQuery.Name = input.Query
+	rzpPersons, personsErr := rzpPersonSearch(input, client, cancel, logger)
+	if personsErr != nil && len(rzpPersons) == 0 {
+		return nil, personsErr
 	}
-	rzpPersons, err := rzpPersonSearch(searchQuery, client, cancel, logger)
-	if err != nil {
-		return nil, fmt.Errorf("unable to search persons in RZP: %v", err)
+
+	fail := func(err error) {
+		if input.FailFast {
+			cancel(err)
+		}
 	}
 
+	concurrency := input.Concurrency
+	if concurrency <= 0 {
+		concurrency = rzp.DefaultConcurrency
+	}
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range rzpPersons {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// results are kept by index, so that persons are returned in the order RZP found them
+	results := make([]types.Result[Person], len(rzpPersons))
+	searched := make([]bool, len(rzpPersons))
 	wg := sync.WaitGroup{}
-	resultChan := make(chan types.Result[Person])
-	for _, rzpPerson := range rzpPersons {
-		logger.Debug("Searching subjects for person", slog.String("person", rzpPerson.DisplayName))
+	for range min(concurrency, len(rzpPersons)) {
 		wg.Add(1)
 		go func() {
-			subjects, err := client.SearchSubject(rzp.SearchSubjectQuery{
-				PersonId: rzpPerson.PersonId,
-			})
-			if err != nil {
-				cancel(err)
-				resultChan <- types.Result[Person]{Err: err}
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = rzpPersonSubjects(rzpPersons[i], client, input, fail, logger)
+				searched[i] = true
 			}
-			economicSubjects := make([]EconomicSubject, 0, len(subjects.Subjects))
-			for _, subject := range subjects.Subjects {
-				economicSubjects = append(economicSubjects, EconomicSubject{
-					Name:    subject.Name,
-					Address: subject.Address,
-					Ico:     subject.Ico,
-				})
+		}()
+	}
+	logger.Debug("Waiting for subjects for all persons to be found")
+	wg.Wait()
+	logger.Debug("Subjects for all persons found")
+
+	persons := make([]Person, 0, len(rzpPersons))
+	var errs []error
+	if personsErr != nil {
+		errs = append(errs, personsErr)
+	}
+	for i, result := range results {
+		if !searched[i] || input.restrictsRole() && result.Err == nil && len(result.Result.Subjects) == 0 {
+			continue
+		}
+		persons = append(persons, result.Result)
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+
+	if input.FailFast && len(errs) > 0 {
+		return nil, context.Cause(ctx)
+	}
+	return persons, errors.Join(errs...)
+}
+
+// rzpPersonSubjects finds subjects of the person and their details to find role of the person in them
+func rzpPersonSubjects(rzpPerson rzp.Person, client *rzp.Rzp, input PersonSearchInput, fail func(error), logger *slog.Logger) types.Result[Person] {
+	logger.Debug("Searching subjects for person", slog.String("person", rzpPerson.DisplayName))
+	person := Person{
+		BirthDate:       time.Time(rzpPerson.DateOfBirth),
+		FirstName:       rzpPerson.FirstName,
+		LastName:        rzpPerson.LastName,
+		TitleBeforeName: rzpPerson.TitleBeforeName,
+		TitleAfterName:  rzpPerson.TitleAfterName,
+		FullName:        rzpPerson.DisplayName,
+	}
+
+	subjects, err := client.SearchSubject(rzp.SearchSubjectQuery{
+		PersonId:          rzpPerson.PersonId,
+		IncludeHistorical: input.IncludeHistorical,
+		Role:              input.Role,
+	})
+	if err != nil {
+		err := &PersonError{Person: rzpPerson.DisplayName, PersonId: rzpPerson.PersonId, Err: err}
+		fail(err)
+		return types.Result[Person]{Result: person, Err: err}
+	}
+	person.Subjects = make([]EconomicSubject, 0, len(subjects.Subjects))
+	var errs []error
+	for _, subject := range subjects.Subjects {
+		economicSubject := EconomicSubject{
+			Name:    subject.Name,
+			Address: subject.Address,
+			Ico:     subject.Ico,
+			Period:  Period{From: time.Time(subject.ValidFrom), To: time.Time(subject.ValidTo)},
+		}
+		if subject.Type == "F" || subject.Type == "P" {
+			subjectDetail, err := client.GetSubjectDetails(subject.Ssarzp)
+			if err != nil {
+				err := &PersonError{Person: rzpPerson.DisplayName, PersonId: rzpPerson.PersonId, Subject: subject.Name, Err: err}
+				fail(err)
+				errs = append(errs, err)
+			} else if subjectDetail.LegalEntity != nil {
+				economicSubject.Role = legalEntityRole(*subjectDetail.LegalEntity, rzpPerson)
+			} else {
+				economicSubject.Role = RoleEntrepreneur
+				person.Address = subject.Address
+				person.Citizenship = subjectDetail.Citizenship
 			}
+		}
+		person.Subjects = append(person.Subjects, economicSubject)
+	}
+	if subjects.MorePossibleMatches {
+		err := &PersonError{Person: rzpPerson.DisplayName, PersonId: rzpPerson.PersonId,
+			Err: fmt.Errorf("%w: only %d subjects returned", rzp.ErrTooManyMatches, len(subjects.Subjects))}
+		fail(err)
+		errs = append(errs, err)
+	}
+
+	logger.Debug("Done searching subjects for person", slog.String("person", person.FullName))
+	return types.Result[Person]{Result: person, Err: errors.Join(errs...)}
+}
 
-			person := Person{
-				BirthDate:       time.Time(rzpPerson.DateOfBirth),
-				FirstName:       rzpPerson.FirstName,
-				LastName:        rzpPerson.LastName,
-				TitleBeforeName: rzpPerson.TitleBeforeName,
-				TitleAfterName:  rzpPerson.TitleAfterName,
-				FullName:        rzpPerson.DisplayName,
-				Subjects:        economicSubjects,
+// legalEntityRole finds the person among statutory body members and responsible representatives of the entity
+func legalEntityRole(entity rzp.LegalEntityDetail, person rzp.Person) string {
+	for _, body := range entity.StatutoryBodies {
+		for _, member := range body.Members {
+			if samePerson(member.NaturalPerson, person) {
+				return RoleStatutoryBodyMember
 			}
-			for _, subject := range subjects.Subjects {
-				if subject.Type == "F" {
-					subjectDetail, err := client.GetSubjectDetails(subject.Ssarzp)
-					if err != nil {
-						cancel(err)
-						resultChan <- types.Result[Person]{Err: err}
-					}
-					person.Address = subject.Address
-					person.Citizenship = subjectDetail.Citizenship
-				}
+		}
+	}
+	for _, representative := range entity.ResponsibleRepresentatives {
+		if samePerson(representative.NaturalPerson, person) {
+			return RoleResponsibleRepresentative
+		}
+	}
+	return ""
+}
+
+// samePerson compares names and birth dates, birth date is ignored when either side does not state it
+func samePerson(natural rzp.NaturalPerson, person rzp.Person) bool {
+	if natural.FirstName != person.FirstName || natural.LastName != person.LastName {
+		return false
+	}
+	birthDate := time.Time(person.DateOfBirth)
+	return natural.BirthDate.IsZero() || birthDate.IsZero() || dateOnly(natural.BirthDate).Equal(dateOnly(birthDate))
+}
+
+type personSearcher interface {
+	SearchPerson(query rzp.SearchPersonQuery) (rzp.SearchPersonResponse, error)
+}
+
+// rzpPersonSearch finds persons matching the name and birth date window of the input, in fuzzy mode also under
+// the variants of the surname, see names.SurnameVariants. Unless input.FailFast is set,
+// persons found before the request budget was exhausted or the search could not be narrowed any further are
+// returned together with the error.
+func rzpPersonSearch(input PersonSearchInput, client personSearcher, cancel context.CancelCauseFunc, logger *slog.Logger) ([]rzp.Person, error) {
+	name := input.name()
+	personQuery := rzp.SearchPersonQuery{FirstName: name.FirstName, Surname: name.Surname, IncludeHistorical: input.IncludeHistorical}
+	if day, ok := input.singleDay(); ok {
+		personQuery.DateOfBirth = day
+	}
+
+	maxRequests := input.MaxRequests
+	if maxRequests <= 0 {
+		maxRequests = DefaultMaxRequests
+	}
+	p := &partitioner{
+		client:      client,
+		input:       input,
+		maxRequests: maxRequests,
+		today:       time.Now(),
+		logger:      logger,
+	}
+	surnames := []string{name.Surname}
+	if input.Fuzzy {
+		surnames = append(surnames, names.SurnameVariants(name.Surname)...)
+	}
+	var people []rzp.Person
+	var errs []error
+	for _, surname := range surnames {
+		query := personQuery
+		query.Surname = surname
+		found, err := p.search(query, query.FirstName == "")
+		people = append(people, found...)
+		if err != nil {
+			errs = append(errs, err)
+			// variants share the request budget, too many matches of one of them does not stop the others
+			if !errors.Is(err, ErrTooManyMatches) {
+				break
 			}
+		}
+	}
+	people = deduplicatePersons(people)
+	err := errors.Join(errs...)
+	if err != nil {
+		err = fmt.Errorf("unable to search persons in RZP: %w", err)
+		if input.FailFast || !errors.Is(err, ErrRequestBudgetExhausted) && !errors.Is(err, ErrTooManyMatches) {
+			cancel(err)
+			return nil, err
+		}
+	}
 
-			resultChan <- types.Result[Person]{Result: person}
-			wg.Done()
-			logger.Debug("Done searching subjects for person", slog.String("person", person.FullName))
-		}()
+	filtered := make([]rzp.Person, 0, len(people))
+	for _, person := range people {
+		if input.bornInWindow(time.Time(person.DateOfBirth)) && hasTitles(name, person.TitleBeforeName, person.TitleAfterName) {
+			filtered = append(filtered, person)
+		}
 	}
 
-	go func() {
-		logger.Debug("Waiting for subjects for all persons to be found")
-		wg.Wait()
-		logger.Debug("Subjects for all persons found")
-		close(resultChan)
-	}()
+	logger.Debug("Found persons", slog.Int("count", len(people)), slog.Int("matching", len(filtered)), slog.Int("requests", p.requests))
+	return filtered, err
+}
 
-	persons := make([]Person, 0, len(rzpPersons))
-	for result := range resultChan {
-		persons = append(persons, result.Result)
+func (input PersonSearchInput) restrictsRole() bool {
+	return input.Role != "" && input.Role != rzp.SubjectRoleAny
+}
+
+// name is the searched name, explicitly given parts or the parsed Query
+func (input PersonSearchInput) name() names.Name {
+	if input.Surname != "" {
+		return names.Name{FirstName: strings.Join(strings.Fields(input.FirstName), " "), Surname: strings.Join(strings.Fields(input.Surname), " ")}
 	}
+	return names.Parse(input.Query)
+}
 
-	return persons, nil
+// hasTitles checks that the person has all titles stated in the searched name
+func hasTitles(name names.Name, titleBeforeName string, titleAfterName string) bool {
+	return names.HasTitles(name.TitleBeforeName, titleBeforeName) && names.HasTitles(name.TitleAfterName, titleAfterName)
 }
 
-func rzpPersonSearch(searchQuery rzp.SearchSubjectQuery, client *rzp.Rzp, cancel context.CancelCauseFunc, logger *slog.Logger) ([]rzp.Person, error) {
-	personQuery := rzp.SearchPersonQuery{}
-	if searchQuery.Name != "" {
-		parts := strings.Split(searchQuery.Name, " ")
-		firstName := strings.Join(parts[0:(len(parts)-1)], " ")
-		surname := parts[len(parts)-1]
-		personQuery.FirstName = firstName
-		personQuery.Surname = surname
+// rankPersons scores names of persons against the searched name and sorts them from the best match
+func rankPersons(persons []Person, name names.Name) {
+	for i := range persons {
+		score := names.Score(name.FirstName, name.Surname, persons[i].FirstName, persons[i].LastName)
+		persons[i].Score = &score
 	}
+	sort.SliceStable(persons, func(i, j int) bool { return *persons[i].Score > *persons[j].Score })
+}
 
-	persons, err := client.SearchPerson(personQuery)
-	if err != nil {
-		err := fmt.Errorf("unable to search persons in RZP: %v", err)
-		cancel(err)
-		return nil, err
+// singleDay returns the birth date if the window covers exactly one day.
+func (input PersonSearchInput) singleDay() (time.Time, bool) {
+	if input.BornAfter.IsZero() || input.BornBefore.IsZero() {
+		return time.Time{}, false
+	}
+	after := dateOnly(input.BornAfter)
+	if !after.Equal(dateOnly(input.BornBefore)) {
+		return time.Time{}, false
+	}
+	return after, true
+}
+
+// bornInWindow checks birth date against BornAfter and BornBefore, both are inclusive and zero means unbounded.
+// Persons whose birth date is not known are kept, they may be born in the window.
+func (input PersonSearchInput) bornInWindow(birthDate time.Time) bool {
+	if birthDate.IsZero() {
+		return true
 	}
-	if persons.MorePossibleMatches {
-		return nil, fmt.Errorf("too many possible matches, please provide more details")
+	day := dateOnly(birthDate)
+	if !input.BornAfter.IsZero() && day.Before(dateOnly(input.BornAfter)) {
+		return false
 	}
+	if !input.BornBefore.IsZero() && day.After(dateOnly(input.BornBefore)) {
+		return false
+	}
+	return true
+}
 
-	logger.Debug("Found persons", slog.Int("count", len(persons.People)))
-	return persons.People, nil
+func dateOnly(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
 }
@@ -0,0 +1,669 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/fstaffa/czsnoop/internal/names"
+	"github.com/fstaffa/czsnoop/internal/rzp"
+	"github.com/fstaffa/czsnoop/internal/rzp/rzptest"
+)
+
+// recordedSearcher answers person searches with responses recorded in testdata
+type recordedSearcher struct {
+	responses map[string]string
+	queries   []rzp.SearchPersonQuery
+}
+
+func queryKey(query rzp.SearchPersonQuery) string {
+	date := ""
+	if !query.DateOfBirth.IsZero() {
+		date = query.DateOfBirth.Format(time.DateOnly)
+	}
+	return fmt.Sprintf("%s|%s|%s", query.FirstName, query.Surname, date)
+}
+
+func (r *recordedSearcher) SearchPerson(query rzp.SearchPersonQuery) (rzp.SearchPersonResponse, error) {
+	r.queries = append(r.queries, query)
+	file, ok := r.responses[queryKey(query)]
+	if !ok {
+		file = "osoby_empty.json"
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		return rzp.SearchPersonResponse{}, err
+	}
+	var response rzp.SearchPersonResponse
+	err = json.Unmarshal(data, &response)
+	return response, err
+}
+
+func mustParseDate(t *testing.T, date string) time.Time {
+	t.Helper()
+	if date == "" {
+		return time.Time{}
+	}
+	parsed, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		t.Fatalf("Unable to parse date %v", err)
+	}
+	return parsed
+}
+
+func Test_rzpPersonSearch_BirthDateWindow(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		bornAfter   string
+		bornBefore  string
+		expectedIds []rzp.PersonId
+		pushedDown  string
+	}{
+		"no window":            {expectedIds: []rzp.PersonId{"1001", "1002", "1003", "1004"}},
+		"born after":           {bornAfter: "1980-06-01", expectedIds: []rzp.PersonId{"1002", "1003", "1004"}},
+		"born before":          {bornBefore: "1980-06-01", expectedIds: []rzp.PersonId{"1001", "1002"}},
+		"window is inclusive":  {bornAfter: "1980-06-01", bornBefore: "1980-06-30", expectedIds: []rzp.PersonId{"1002", "1003"}},
+		"single day pushdown":  {bornAfter: "1980-06-01", bornBefore: "1980-06-01", expectedIds: []rzp.PersonId{"1002"}, pushedDown: "1980-06-01"},
+		"nobody in the window": {bornAfter: "2000-01-01", bornBefore: "2001-01-01", expectedIds: []rzp.PersonId{}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			searcher := &recordedSearcher{responses: map[string]string{
+				"Jan|Novák|":           "osoby_jan_novak.json",
+				"Jan|Novák|1980-06-01": "osoby_novak_1980-06-01.json",
+			}}
+			input := PersonSearchInput{
+				Query:      "Jan Novák",
+				BornAfter:  mustParseDate(t, test.bornAfter),
+				BornBefore: mustParseDate(t, test.bornBefore),
+			}
+			_, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+
+			persons, err := rzpPersonSearch(input, searcher, cancel, slog.Default())
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if len(persons) != len(test.expectedIds) {
+				t.Fatalf("Expected %d persons, got %d", len(test.expectedIds), len(persons))
+			}
+			for i, person := range persons {
+				if person.PersonId != test.expectedIds[i] {
+					t.Errorf("Expected person %d to have id %s, got %s", i, test.expectedIds[i], person.PersonId)
+				}
+			}
+			pushedDown := searcher.queries[0].DateOfBirth
+			if !pushedDown.Equal(mustParseDate(t, test.pushedDown)) {
+				t.Errorf("Expected date of birth in query to be '%s', got %s", test.pushedDown, pushedDown)
+			}
+		})
+	}
+}
+
+func Test_PersonSearchInput_bornInWindow(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		birthDate string
+		expected  bool
+	}{
+		"in the window":      {birthDate: "1980-06-15", expected: true},
+		"before the window":  {birthDate: "1980-05-31", expected: false},
+		"after the window":   {birthDate: "1980-07-01", expected: false},
+		"unknown birth date": {birthDate: "", expected: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			input := PersonSearchInput{BornAfter: mustParseDate(t, "1980-06-01"), BornBefore: mustParseDate(t, "1980-06-30")}
+			if actual := input.bornInWindow(mustParseDate(t, test.birthDate)); actual != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_rzpPersonSearch_Name(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		input       PersonSearchInput
+		expectedIds []rzp.PersonId
+	}{
+		"title before name": {input: PersonSearchInput{Query: "Ing. Jan Novák"}, expectedIds: []rzp.PersonId{"1002"}},
+		"title after name":  {input: PersonSearchInput{Query: "Jan Novák, Ph.D."}, expectedIds: []rzp.PersonId{"1003"}},
+		"surname first":     {input: PersonSearchInput{Query: "Novák, Jan"}, expectedIds: []rzp.PersonId{"1001", "1002", "1003", "1004"}},
+		"explicit parts":    {input: PersonSearchInput{FirstName: "Jan", Surname: "Novák"}, expectedIds: []rzp.PersonId{"1001", "1002", "1003", "1004"}},
+		"titles nobody has": {input: PersonSearchInput{Query: "Ing. Jan Novák Ph.D."}, expectedIds: []rzp.PersonId{}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			searcher := &recordedSearcher{responses: map[string]string{"Jan|Novák|": "osoby_jan_novak.json"}}
+			_, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+
+			persons, err := rzpPersonSearch(test.input, searcher, cancel, slog.Default())
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			ids := make([]rzp.PersonId, 0, len(persons))
+			for _, person := range persons {
+				ids = append(ids, person.PersonId)
+			}
+			if !slices.Equal(ids, test.expectedIds) {
+				t.Errorf("Expected persons %v, got %v", test.expectedIds, ids)
+			}
+		})
+	}
+}
+
+func Test_rzpPersonSearch_FuzzySurnameVariants(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		fuzzy            bool
+		expectedIds      []rzp.PersonId
+		expectedSurnames []string
+	}{
+		"exact": {expectedIds: []rzp.PersonId{"1001", "1002", "1003", "1004"}, expectedSurnames: []string{"Novák"}},
+		"fuzzy": {
+			fuzzy:            true,
+			expectedIds:      []rzp.PersonId{"1001", "1002", "1003", "1004", "3001"},
+			expectedSurnames: []string{"Novák", "Novak", "Nováková", "Novakova"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			searcher := &recordedSearcher{responses: map[string]string{
+				"|Novák|":    "osoby_jan_novak.json",
+				"|Nováková|": "osoby_novak_1980-06-02.json",
+				"|Novak|":    "osoby_jan_novak.json",
+			}}
+			_, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+
+			persons, err := rzpPersonSearch(PersonSearchInput{Query: "Novák", Fuzzy: test.fuzzy}, searcher, cancel, slog.Default())
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			ids := make([]rzp.PersonId, 0, len(persons))
+			for _, person := range persons {
+				ids = append(ids, person.PersonId)
+			}
+			if !slices.Equal(ids, test.expectedIds) {
+				t.Errorf("Expected persons %v, got %v", test.expectedIds, ids)
+			}
+			surnames := make([]string, 0, len(searcher.queries))
+			for _, query := range searcher.queries {
+				surnames = append(surnames, query.Surname)
+			}
+			if !slices.Equal(surnames, test.expectedSurnames) {
+				t.Errorf("Expected searched surnames %v, got %v", test.expectedSurnames, surnames)
+			}
+		})
+	}
+}
+
+func Test_rzpPersonSearch_SplitsByDayWhenIncomplete(t *testing.T) {
+	t.Parallel()
+	searcher := &recordedSearcher{responses: map[string]string{
+		"|Novák|":           "osoby_novak_incomplete.json",
+		"|Novák|1980-06-01": "osoby_novak_1980-06-01.json",
+		"|Novák|1980-06-02": "osoby_novak_1980-06-02.json",
+	}}
+	input := PersonSearchInput{
+		Query:      "Novák",
+		BornAfter:  mustParseDate(t, "1980-06-01"),
+		BornBefore: mustParseDate(t, "1980-06-03"),
+	}
+	_, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	persons, err := rzpPersonSearch(input, searcher, cancel, slog.Default())
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if len(searcher.queries) != 4 {
+		t.Errorf("Expected initial query and one query per day, got %d queries", len(searcher.queries))
+	}
+	if len(persons) != 2 {
+		t.Fatalf("Expected 2 persons, got %d", len(persons))
+	}
+	if persons[0].PersonId != "1002" || persons[1].PersonId != "3001" {
+		t.Errorf("Expected persons 1002 and 3001, got %s and %s", persons[0].PersonId, persons[1].PersonId)
+	}
+}
+
+func Test_rzpPersonSearch_SplitsByFirstNamePrefix(t *testing.T) {
+	t.Parallel()
+	searcher := &recordedSearcher{responses: map[string]string{
+		"|Novák|":   "osoby_novak_incomplete.json",
+		"j|Novák|":  "osoby_jan_novak.json",
+		"ja|Novák|": "osoby_jan_novak.json",
+		"p|Novák|":  "osoby_novak_incomplete.json",
+	}}
+	input := PersonSearchInput{Query: "Novák"}
+	_, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	persons, err := rzpPersonSearch(input, searcher, cancel, slog.Default())
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	expectedRequests := 1 + 2*len(firstNameAlphabet)
+	if len(searcher.queries) != expectedRequests {
+		t.Errorf("Expected %d requests, got %d", expectedRequests, len(searcher.queries))
+	}
+	expectedIds := []rzp.PersonId{"1001", "1002", "1003", "1004"}
+	if len(persons) != len(expectedIds) {
+		t.Fatalf("Expected %d deduplicated persons, got %d", len(expectedIds), len(persons))
+	}
+	for i, person := range persons {
+		if person.PersonId != expectedIds[i] {
+			t.Errorf("Expected person %d to have id %s, got %s", i, expectedIds[i], person.PersonId)
+		}
+	}
+}
+
+func Test_rzpPersonSearch_SplitsOneSidedWindowByDay(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		bornAfter     string
+		bornBefore    string
+		expectedDates []string
+	}{
+		"born after":  {bornAfter: "1980-06-01", expectedDates: []string{"1980-06-01", "1980-06-02", "1980-06-03"}},
+		"born before": {bornBefore: "1860-06-04", expectedDates: []string{"1860-06-03", "1860-06-04"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			searcher := &recordedSearcher{responses: map[string]string{
+				"|Novák|":           "osoby_novak_incomplete.json",
+				"|Novák|1980-06-01": "osoby_novak_1980-06-01.json",
+			}}
+			p := &partitioner{
+				client:      searcher,
+				input:       PersonSearchInput{BornAfter: mustParseDate(t, test.bornAfter), BornBefore: mustParseDate(t, test.bornBefore)},
+				maxRequests: DefaultMaxRequests,
+				today:       mustParseDate(t, "1980-06-03"),
+				logger:      slog.Default(),
+			}
+
+			_, err := p.search(rzp.SearchPersonQuery{Surname: "Novák"}, true)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			var dates []string
+			for _, query := range searcher.queries[1:] {
+				dates = append(dates, query.DateOfBirth.Format(time.DateOnly))
+			}
+			if !slices.Equal(dates, test.expectedDates) {
+				t.Errorf("Expected queries for days %v, got %v", test.expectedDates, dates)
+			}
+		})
+	}
+}
+
+func Test_rzpPersonSearch_AddsFirstNames(t *testing.T) {
+	t.Parallel()
+	searcher := &recordedSearcher{responses: map[string]string{
+		"Jan|Novák|":      "osoby_jan_novak_incomplete.json",
+		"Jan Petr|Novák|": "osoby_jan_petr_novak.json",
+	}}
+	_, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	persons, err := rzpPersonSearch(PersonSearchInput{Query: "Jan Novák"}, searcher, cancel, slog.Default())
+	if !errors.Is(err, ErrTooManyMatches) {
+		t.Fatalf("Expected error %v, got %v", ErrTooManyMatches, err)
+	}
+	if len(searcher.queries) != 2 || searcher.queries[1].FirstName != "Jan Petr" {
+		t.Errorf("Expected search for added first name Jan Petr, got %v", searcher.queries)
+	}
+	var ids []rzp.PersonId
+	for _, person := range persons {
+		ids = append(ids, person.PersonId)
+	}
+	expectedIds := []rzp.PersonId{"5001", "5002", "1001"}
+	if !slices.Equal(ids, expectedIds) {
+		t.Errorf("Expected persons %v, got %v", expectedIds, ids)
+	}
+}
+
+func Test_rzpPersonSearch_FirstNamePrefixOnServer(t *testing.T) {
+	t.Parallel()
+	fixtures := slices.Clone(rzptest.Fixtures)
+	fixtures = append(fixtures, rzptest.PersonFixture(url.Values{"o-prijmeni": {"Dvořák"}, "pouzeplatne": {"true"}}, "osoby_dvorak.json"))
+	for _, letter := range firstNameAlphabet {
+		file := "osoby_empty.json"
+		if letter == 'j' {
+			file = "osoby_dvorak_j.json"
+		}
+		query := url.Values{"o-jmeno": {string(letter)}, "o-prijmeni": {"Dvořák"}, "pouzeplatne": {"true"}}
+		fixtures = append(fixtures, rzptest.PersonFixture(query, file))
+	}
+	server := rzptest.NewServerWithFixtures(t, fixtures)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	client, err := rzp.CreateClient(ctx, slog.Default(), rzp.WithBaseUrl(server.URL))
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+
+	persons, err := rzpPersonSearch(PersonSearchInput{Query: "Dvořák"}, client, cancel, slog.Default())
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	var firstNames []string
+	for _, person := range persons {
+		firstNames = append(firstNames, person.FirstName)
+	}
+	expected := []string{"Jan", "Jiří", "Josef"}
+	if !slices.Equal(firstNames, expected) {
+		t.Errorf("Expected prefix j to find %v, got %v", expected, firstNames)
+	}
+}
+
+func Test_rzpPersonSearch_TooManyMatches(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		query       string
+		bornAfter   string
+		bornBefore  string
+		maxRequests int
+		failFast    bool
+		expectedErr error
+		expectedIds []rzp.PersonId
+	}{
+		"first name given":         {query: "Petr Novák", expectedErr: ErrTooManyMatches, expectedIds: []rzp.PersonId{"2001"}},
+		"budget exhausted":         {query: "Novák", maxRequests: 5, expectedErr: ErrRequestBudgetExhausted, expectedIds: []rzp.PersonId{"2001"}},
+		"window exceeds budget":    {query: "Petr Novák", bornAfter: "1980-01-01", bornBefore: "1980-12-31", expectedErr: ErrTooManyMatches},
+		"single day is incomplete": {query: "Petr Novák", bornAfter: "1960-01-01", bornBefore: "1960-01-01", expectedErr: ErrTooManyMatches, expectedIds: []rzp.PersonId{"2001"}},
+		"fail fast":                {query: "Novák", maxRequests: 5, failFast: true, expectedErr: ErrRequestBudgetExhausted},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			searcher := &recordedSearcher{responses: map[string]string{
+				"|Novák|":               "osoby_novak_incomplete.json",
+				"Petr|Novák|":           "osoby_novak_incomplete.json",
+				"Petr|Novák|1960-01-01": "osoby_novak_incomplete.json",
+			}}
+			input := PersonSearchInput{
+				Query:       test.query,
+				BornAfter:   mustParseDate(t, test.bornAfter),
+				BornBefore:  mustParseDate(t, test.bornBefore),
+				MaxRequests: test.maxRequests,
+				FailFast:    test.failFast,
+			}
+			_, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+
+			persons, err := rzpPersonSearch(input, searcher, cancel, slog.Default())
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("Expected error %v, got %v", test.expectedErr, err)
+			}
+			var ids []rzp.PersonId
+			for _, person := range persons {
+				ids = append(ids, person.PersonId)
+			}
+			if !slices.Equal(ids, test.expectedIds) {
+				t.Errorf("Expected partial results %v, got %v", test.expectedIds, ids)
+			}
+		})
+	}
+}
+
+func Test_Rzp(t *testing.T) {
+	t.Parallel()
+	server := rzptest.NewServer(t)
+
+	persons, err := Rzp(context.Background(), PersonSearchInput{Query: "Jan Novák"}, slog.Default(), rzp.WithBaseUrl(server.URL))
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	if len(persons) != 2 {
+		t.Fatalf("Expected 2 persons, got %d", len(persons))
+	}
+	sort.Slice(persons, func(i, j int) bool { return persons[i].BirthDate.Before(persons[j].BirthDate) })
+
+	entrepreneur := persons[0]
+	if entrepreneur.FullName != "Jan Novák" {
+		t.Errorf("Expected full name Jan Novák, got %s", entrepreneur.FullName)
+	}
+	if entrepreneur.Citizenship != "Česká republika" {
+		t.Errorf("Expected citizenship from subject details, got '%s'", entrepreneur.Citizenship)
+	}
+	if entrepreneur.Address != "Husova 5, 370 01, České Budějovice" {
+		t.Errorf("Expected address from subject, got '%s'", entrepreneur.Address)
+	}
+	if len(entrepreneur.Subjects) != 1 || entrepreneur.Subjects[0].Ico != "87654326" {
+		t.Errorf("Expected single subject with ICO 87654326, got %v", entrepreneur.Subjects)
+	} else if entrepreneur.Subjects[0].Role != RoleEntrepreneur {
+		t.Errorf("Expected role %s, got '%s'", RoleEntrepreneur, entrepreneur.Subjects[0].Role)
+	}
+
+	boardMember := persons[1]
+	if boardMember.TitleBeforeName != "Ing." {
+		t.Errorf("Expected title before name Ing., got '%s'", boardMember.TitleBeforeName)
+	}
+	if len(boardMember.Subjects) != 1 || boardMember.Subjects[0].Name != "NOVÁK & PARTNEŘI a.s." {
+		t.Errorf("Expected single subject NOVÁK & PARTNEŘI a.s., got %v", boardMember.Subjects)
+	} else if boardMember.Subjects[0].Role != RoleStatutoryBodyMember {
+		t.Errorf("Expected role %s, got '%s'", RoleStatutoryBodyMember, boardMember.Subjects[0].Role)
+	}
+	if boardMember.Citizenship != "" {
+		t.Errorf("Expected no citizenship without entrepreneur subject, got '%s'", boardMember.Citizenship)
+	}
+}
+
+func Test_Rzp_IncludeHistorical(t *testing.T) {
+	t.Parallel()
+	server := rzptest.NewServer(t)
+
+	persons, err := Rzp(context.Background(), PersonSearchInput{Query: "Jan Novák", IncludeHistorical: true}, slog.Default(), rzp.WithBaseUrl(server.URL))
+	if err != nil {
+		t.Fatalf("Received unexpected error %v", err)
+	}
+	sort.Slice(persons, func(i, j int) bool { return persons[i].BirthDate.Before(persons[j].BirthDate) })
+	if len(persons) != 2 || len(persons[0].Subjects) != 2 {
+		t.Fatalf("Expected entrepreneur with current and former subject, got %v", persons)
+	}
+	former := persons[0].Subjects[1]
+	if former.Ico != "24681351" || former.Role != RoleStatutoryBodyMember {
+		t.Errorf("Expected former statutory body member of 24681351, got %+v", former)
+	}
+	if !former.Ended(time.Now()) {
+		t.Errorf("Expected former role to have ended, got %+v", former.Period)
+	}
+	if persons[0].Citizenship != "Česká republika" {
+		t.Errorf("Expected citizenship from entrepreneur subject, got '%s'", persons[0].Citizenship)
+	}
+}
+
+func Test_Company_current(t *testing.T) {
+	t.Parallel()
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ended := Period{From: time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC)}
+	ongoing := Period{From: time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)}
+	company := Company{
+		Trades: []Trade{
+			{Number: "1", DateOfTermination: ended.To},
+			{
+				Number:         "2",
+				Suspensions:    []Period{ended, ongoing},
+				Establishments: []Establishment{{Number: "1", Period: ended}, {Number: "2", Period: ongoing}},
+				ResponsibleRepresentatives: []AssociatedPerson{
+					{FullName: "Former", Period: ended},
+					{FullName: "Current", Period: ongoing},
+				},
+			},
+		},
+		Persons: []AssociatedPerson{{FullName: "Former", Period: ended}, {FullName: "Current"}},
+	}
+
+	current := company.current(at)
+	if len(current.Trades) != 1 || current.Trades[0].Number != "2" {
+		t.Fatalf("Expected only trade 2, got %+v", current.Trades)
+	}
+	trade := current.Trades[0]
+	if len(trade.Suspensions) != 1 || len(trade.Establishments) != 1 || trade.Establishments[0].Number != "2" {
+		t.Errorf("Expected only ongoing suspension and establishment, got %+v", trade)
+	}
+	if len(trade.ResponsibleRepresentatives) != 1 || trade.ResponsibleRepresentatives[0].FullName != "Current" {
+		t.Errorf("Expected only current representative, got %+v", trade.ResponsibleRepresentatives)
+	}
+	if len(current.Persons) != 1 || current.Persons[0].FullName != "Current" {
+		t.Errorf("Expected only current person, got %+v", current.Persons)
+	}
+	if len(company.Trades) != 2 || len(company.Trades[1].Suspensions) != 2 {
+		t.Errorf("Expected original company to be unchanged, got %+v", company.Trades)
+	}
+}
+
+func Test_currentSubject(t *testing.T) {
+	t.Parallel()
+	expired := rzp.Subject{Ssarzp: "P1", ValidTo: rzp.Iso8601Date(time.Date(2012, 6, 30, 0, 0, 0, 0, time.UTC))}
+	valid := rzp.Subject{Ssarzp: "P2"}
+	tests := map[string]struct {
+		subjects []rzp.Subject
+		expected rzp.Ssarzp
+	}{
+		"valid after expired": {subjects: []rzp.Subject{expired, valid}, expected: "P2"},
+		"valid first":         {subjects: []rzp.Subject{valid, expired}, expected: "P2"},
+		"only expired":        {subjects: []rzp.Subject{expired}, expected: "P1"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if subject := currentSubject(test.subjects); subject.Ssarzp != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, subject.Ssarzp)
+			}
+		})
+	}
+}
+
+func Test_Rzp_PartialResults(t *testing.T) {
+	t.Parallel()
+	server := rzptest.NewServerWithFixtures(t, rzptest.Without("listiny_F7701.xml"))
+
+	persons, err := Rzp(context.Background(), PersonSearchInput{Query: "Jan Novák"}, slog.Default(), rzp.WithBaseUrl(server.URL))
+	if err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+	var personErr *PersonError
+	if !errors.As(err, &personErr) {
+		t.Fatalf("Expected PersonError, got %v", err)
+	}
+	if personErr.PersonId != "5501001" || personErr.Subject != "Jan Novák" {
+		t.Errorf("Expected error for subject Jan Novák of person 5501001, got %v", personErr)
+	}
+	if len(persons) != 2 {
+		t.Fatalf("Expected both persons in partial results, got %d", len(persons))
+	}
+	for _, person := range persons {
+		if len(person.Subjects) != 1 {
+			t.Errorf("Expected subjects of %s to be found, got %v", person.FullName, person.Subjects)
+		}
+	}
+}
+
+func Test_Rzp_IncompleteSubjects(t *testing.T) {
+	t.Parallel()
+	fixtures := slices.Clone(rzptest.Fixtures)
+	for i, fixture := range fixtures {
+		if fixture.Query == "o-id=5501001&pouzeplatne=true&s-presvyber=true" {
+			fixtures[i].File = "subjekty_osoba_5501001_neuplne.json"
+		}
+	}
+	server := rzptest.NewServerWithFixtures(t, fixtures)
+
+	persons, err := Rzp(context.Background(), PersonSearchInput{Query: "Jan Novák"}, slog.Default(), rzp.WithBaseUrl(server.URL))
+	if !errors.Is(err, rzp.ErrTooManyMatches) {
+		t.Fatalf("Expected too many matches error, got %v", err)
+	}
+	var personErr *PersonError
+	if !errors.As(err, &personErr) || personErr.PersonId != "5501001" {
+		t.Errorf("Expected error for person 5501001, got %v", err)
+	}
+	if len(persons) != 2 {
+		t.Fatalf("Expected both persons in partial results, got %d", len(persons))
+	}
+	for _, person := range persons {
+		if len(person.Subjects) != 1 {
+			t.Errorf("Expected returned subjects of %s to be kept, got %v", person.FullName, person.Subjects)
+		}
+	}
+}
+
+func Test_Rzp_FailFast(t *testing.T) {
+	t.Parallel()
+	server := rzptest.NewServerWithFixtures(t, rzptest.Without("subjekty_osoba_5501002.json"))
+
+	persons, err := Rzp(context.Background(), PersonSearchInput{Query: "Jan Novák", FailFast: true}, slog.Default(), rzp.WithBaseUrl(server.URL))
+	var personErr *PersonError
+	if !errors.As(err, &personErr) {
+		t.Fatalf("Expected PersonError, got %v", err)
+	}
+	if personErr.PersonId != "5501002" {
+		t.Errorf("Expected error for person 5501002, got %v", personErr)
+	}
+	if persons != nil {
+		t.Errorf("Expected no results, got %v", persons)
+	}
+}
+
+func Test_rankPersons(t *testing.T) {
+	t.Parallel()
+	persons := []Person{
+		{FirstName: "Jan", LastName: "Novotný"},
+		{FirstName: "Jana", LastName: "Nováková"},
+		{FirstName: "JAN", LastName: "NOVÁK"},
+	}
+
+	rankPersons(persons, names.Name{FirstName: "Jan", Surname: "Novak"})
+	var ranked []string
+	for _, person := range persons {
+		ranked = append(ranked, person.LastName)
+	}
+	if expected := []string{"NOVÁK", "Nováková", "Novotný"}; !slices.Equal(ranked, expected) {
+		t.Errorf("Expected persons ranked %v, got %v", expected, ranked)
+	}
+	if *persons[0].Score != 1 || *persons[2].Score >= *persons[1].Score {
+		t.Errorf("Expected descending scores with exact match scoring 1, got %+v", persons)
+	}
+}
+
+func Test_PersonSearchInput_name(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		input    PersonSearchInput
+		expected names.Name
+	}{
+		"parsed query":     {input: PersonSearchInput{Query: "Ing. Jan Novák"}, expected: names.Name{TitleBeforeName: "Ing.", FirstName: "Jan", Surname: "Novák"}},
+		"explicit parts":   {input: PersonSearchInput{FirstName: "Jan  Petr", Surname: "Novák Svoboda"}, expected: names.Name{FirstName: "Jan Petr", Surname: "Novák Svoboda"}},
+		"surname only":     {input: PersonSearchInput{Surname: "Nováková"}, expected: names.Name{Surname: "Nováková"}},
+		"parts over query": {input: PersonSearchInput{Query: "Petr Dvořák", Surname: "Novák"}, expected: names.Name{Surname: "Novák"}},
+		"empty":            {input: PersonSearchInput{}, expected: names.Name{}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if parsed := test.input.name(); parsed != test.expected {
+				t.Errorf("Expected %+v, got %+v", test.expected, parsed)
+			}
+		})
+	}
+}
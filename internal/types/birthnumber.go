@@ -0,0 +1,102 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BirthNumber is Czech birth number (rodne cislo) without the slash
+type BirthNumber string
+
+type Sex string
+
+const (
+	Male   Sex = "male"
+	Female Sex = "female"
+)
+
+// CreateBirthNumber validates birth number in YYMMDDXXX or YYMMDDXXXX form, optionally with slash after the date.
+// 9 digit numbers were issued until 1953, 10 digit numbers must be divisible by 11
+// except numbers issued until 1985 where remainder 10 is represented by check digit 0.
+func CreateBirthNumber(birthNumber string) (BirthNumber, error) {
+	number := strings.Replace(strings.TrimSpace(birthNumber), "/", "", 1)
+	if len(number) != 9 && len(number) != 10 {
+		return "", fmt.Errorf("Birth number must have 9 or 10 digits")
+	}
+	if !isDigits(number) {
+		return "", fmt.Errorf("Birth number must be a number")
+	}
+
+	b := BirthNumber(number)
+	birthDate, _, err := b.parse()
+	if err != nil {
+		return "", err
+	}
+	if len(number) == 9 {
+		return b, nil
+	}
+
+	base, _ := strconv.Atoi(number[:9])
+	check := int(number[9] - '0')
+	remainder := base % 11
+	if remainder == 10 && check == 0 && birthDate.Year() < 1985 {
+		return b, nil
+	}
+	if remainder != check {
+		return "", fmt.Errorf("Birth number has invalid check digit")
+	}
+	return b, nil
+}
+
+// BirthDate returns date of birth encoded in the birth number
+func (b BirthNumber) BirthDate() time.Time {
+	birthDate, _, _ := b.parse()
+	return birthDate
+}
+
+// Sex returns sex encoded in the birth number, women have 50 added to the month
+func (b BirthNumber) Sex() Sex {
+	_, sex, _ := b.parse()
+	return sex
+}
+
+func (b BirthNumber) parse() (time.Time, Sex, error) {
+	number := string(b)
+	year, _ := strconv.Atoi(number[0:2])
+	month, _ := strconv.Atoi(number[2:4])
+	day, _ := strconv.Atoi(number[4:6])
+
+	if len(number) == 9 {
+		if year >= 54 {
+			return time.Time{}, "", fmt.Errorf("Birth number with 9 digits must be issued before 1954")
+		}
+		year += 1900
+	} else if year < 54 {
+		year += 2000
+	} else {
+		year += 1900
+	}
+
+	sex := Male
+	// 20 is added to the month since 2004 when numbers for the day run out
+	switch {
+	case month > 70 && year >= 2004:
+		month -= 70
+		sex = Female
+	case month > 50:
+		month -= 50
+		sex = Female
+	case month > 20 && year >= 2004:
+		month -= 20
+	}
+	if month < 1 || month > 12 {
+		return time.Time{}, "", fmt.Errorf("Birth number has invalid month")
+	}
+	birthDate := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if birthDate.Day() != day {
+		return time.Time{}, "", fmt.Errorf("Birth number has invalid day")
+	}
+	return birthDate, sex, nil
+}
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_CreateBirthNumber(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		birthNumber string
+		expected    BirthNumber
+		birthDate   string
+		sex         Sex
+	}{
+		"man":                          {birthNumber: "8001010006", expected: "8001010006", birthDate: "1980-01-01", sex: Male},
+		"woman":                        {birthNumber: "7857031237", expected: "7857031237", birthDate: "1978-07-03", sex: Female},
+		"with slash":                   {birthNumber: "800101/0006", expected: "8001010006", birthDate: "1980-01-01", sex: Male},
+		"remainder 10 before 1985":     {birthNumber: "8001010040", expected: "8001010040", birthDate: "1980-01-01", sex: Male},
+		"nine digits before 1954":      {birthNumber: "530101123", expected: "530101123", birthDate: "1953-01-01", sex: Male},
+		"nine digits woman":            {birthNumber: "535101/123", expected: "535101123", birthDate: "1953-01-01", sex: Female},
+		"born in 21st century":         {birthNumber: "0002291234", expected: "0002291234", birthDate: "2000-02-29", sex: Male},
+		"man with 20 added to month":   {birthNumber: "0421011239", expected: "0421011239", birthDate: "2004-01-01", sex: Male},
+		"woman with 70 added to month": {birthNumber: "0471011233", expected: "0471011233", birthDate: "2004-01-01", sex: Female},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			birthNumber, err := CreateBirthNumber(test.birthNumber)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if birthNumber != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, birthNumber)
+			}
+			if birthNumber.BirthDate().Format(time.DateOnly) != test.birthDate {
+				t.Errorf("Expected birth date %s, got %s", test.birthDate, birthNumber.BirthDate().Format(time.DateOnly))
+			}
+			if birthNumber.Sex() != test.sex {
+				t.Errorf("Expected sex %s, got %s", test.sex, birthNumber.Sex())
+			}
+		})
+	}
+}
+
+func Test_CreateBirthNumber_FailsWithInvalidBirthNumber(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		birthNumber string
+	}{
+		"empty":                         {birthNumber: ""},
+		"too short":                     {birthNumber: "80010100"},
+		"too long":                      {birthNumber: "80010100061"},
+		"not a number":                  {birthNumber: "80010100a6"},
+		"wrong check digit":             {birthNumber: "8001010007"},
+		"remainder 10 after 1985":       {birthNumber: "8601010100"},
+		"nine digits after 1953":        {birthNumber: "540101123"},
+		"invalid month":                 {birthNumber: "8013010008"},
+		"20 added to month before 2004": {birthNumber: "9921011232"},
+		"invalid day":                   {birthNumber: "8002301230"},
+		"not a leap year":               {birthNumber: "0102291233"},
+		"zero day":                      {birthNumber: "530100123"},
+		"two slashes":                   {birthNumber: "800101//0006"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := CreateBirthNumber(test.birthNumber)
+			if err == nil {
+				t.Fatalf("Expected error, got nil")
+			}
+		})
+	}
+}
@@ -2,21 +2,85 @@ package types
 
 import (
 	"fmt"
-	"strconv"
+	"strings"
 )
 
 type Ico string
 
+// CreateIco validates ICO including its check digit. ICOs with 6 or 7 digits are
+// normalized to 8 digits by adding leading zeros.
 func CreateIco(ico string) (Ico, error) {
-	if len(ico) != 8 {
-		return "", fmt.Errorf("Ico must be 8 characters long")
+	ico = strings.TrimSpace(ico)
+	if len(ico) < 6 || len(ico) > 8 {
+		return "", fmt.Errorf("Ico must have 6 to 8 digits")
 	}
-	if _, err := strconv.Atoi(ico); err != nil {
+	if !isDigits(ico) {
 		return "", fmt.Errorf("Ico must be a number")
 	}
+	ico = strings.Repeat("0", 8-len(ico)) + ico
+	if ico[7] != icoCheckDigit(ico) {
+		return "", fmt.Errorf("Ico %s has invalid check digit", ico)
+	}
 	return Ico(ico), nil
 }
 
+// icoCheckDigit computes the mod 11 check digit from the first 7 digits of ICO
+func icoCheckDigit(ico string) byte {
+	sum := 0
+	for i := 0; i < 7; i++ {
+		sum += int(ico[i]-'0') * (8 - i)
+	}
+	return byte('0' + (11-sum%11)%10)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Dic is a Czech VAT number, CZ followed by ICO for legal entities or by birth number for natural persons
+type Dic string
+
+// CreateDic validates DIC, the CZ prefix is added if missing
+func CreateDic(dic string) (Dic, error) {
+	dic = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(dic), " ", ""))
+	number := strings.TrimPrefix(dic, "CZ")
+	if !isDigits(number) || number == "" {
+		return "", fmt.Errorf("Dic must be CZ followed by a number")
+	}
+
+	switch len(number) {
+	case 8:
+		if _, err := CreateIco(number); err != nil {
+			return "", fmt.Errorf("Dic based on ICO is invalid: %v", err)
+		}
+	case 9, 10:
+		// persons without birth number have special numbers starting with 699
+		if len(number) == 9 && strings.HasPrefix(number, "699") {
+			break
+		}
+		if _, err := CreateBirthNumber(number); err != nil {
+			return "", fmt.Errorf("Dic based on birth number is invalid: %v", err)
+		}
+	default:
+		return "", fmt.Errorf("Dic must have 8 to 10 digits after CZ prefix")
+	}
+	return Dic("CZ" + number), nil
+}
+
+// Ico returns ICO the DIC is based on, false if it is based on birth number
+func (d Dic) Ico() (Ico, bool) {
+	number := strings.TrimPrefix(string(d), "CZ")
+	if len(number) != 8 {
+		return "", false
+	}
+	return Ico(number), true
+}
+
 type Result[T any] struct {
 	Result T
 	Err    error
@@ -10,9 +10,16 @@ func Test_CreateIci_FailsWithInvalidIco(t *testing.T) {
 		name string
 		ico  string
 	}{
-		"too short":    {ico: "1234567"},
-		"too long":     {ico: "123456789"},
-		"not a number": {ico: "1234567a"},
+		"too short":          {ico: "12345"},
+		"single digit":       {ico: "1"},
+		"padded check digit": {ico: "1234567"},
+		"too long":           {ico: "123456789"},
+		"not a number":       {ico: "1234567a"},
+		"empty":              {ico: ""},
+		"wrong check digit":  {ico: "01895542"},
+		"wrong check digit7": {ico: "2565219"},
+		"wrong check digit6": {ico: "123456"},
+		"negative":           {ico: "-1895541"},
 	}
 
 	for name, test := range tests {
@@ -24,3 +31,91 @@ func Test_CreateIci_FailsWithInvalidIco(t *testing.T) {
 		})
 	}
 }
+
+func Test_CreateIco(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		ico      string
+		expected Ico
+	}{
+		"valid":                           {ico: "27074358", expected: "27074358"},
+		"remainder 0 gives check digit 1": {ico: "01895541", expected: "01895541"},
+		"remainder 1 gives check digit 0": {ico: "25652150", expected: "25652150"},
+		"seven digits":                    {ico: "2565218", expected: "02565218"},
+		"six digits":                      {ico: "690163", expected: "00690163"},
+		"surrounding whitespace":          {ico: " 45274649 ", expected: "45274649"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ico, err := CreateIco(test.ico)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if ico != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, ico)
+			}
+		})
+	}
+}
+
+func Test_CreateDic(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		dic         string
+		expected    Dic
+		expectedIco Ico
+	}{
+		"based on ico":                 {dic: "CZ01895541", expected: "CZ01895541", expectedIco: "01895541"},
+		"without prefix":               {dic: "01895541", expected: "CZ01895541", expectedIco: "01895541"},
+		"lowercase prefix with space":  {dic: "cz 45274649", expected: "CZ45274649", expectedIco: "45274649"},
+		"ten digit birth number":       {dic: "CZ8001010006", expected: "CZ8001010006"},
+		"woman birth number":           {dic: "CZ7857031237", expected: "CZ7857031237"},
+		"birth number before 1985 fix": {dic: "CZ8001010040", expected: "CZ8001010040"},
+		"nine digit birth number":      {dic: "CZ530101123", expected: "CZ530101123"},
+		"person without birth number":  {dic: "CZ699001234", expected: "CZ699001234"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dic, err := CreateDic(test.dic)
+			if err != nil {
+				t.Fatalf("Received unexpected error %v", err)
+			}
+			if dic != test.expected {
+				t.Errorf("Expected %s, got %s", test.expected, dic)
+			}
+			ico, ok := dic.Ico()
+			if ok != (test.expectedIco != "") || ico != test.expectedIco {
+				t.Errorf("Expected ICO '%s', got '%s'", test.expectedIco, ico)
+			}
+		})
+	}
+}
+
+func Test_CreateDic_FailsWithInvalidDic(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		dic string
+	}{
+		"empty":                          {dic: ""},
+		"prefix only":                    {dic: "CZ"},
+		"other country":                  {dic: "SK2020123456"},
+		"too short":                      {dic: "CZ1234567"},
+		"too long":                       {dic: "CZ12345678901"},
+		"not a number":                   {dic: "CZ0189554a"},
+		"ico with wrong check digit":     {dic: "CZ01895542"},
+		"birth number with wrong check":  {dic: "CZ8001010007"},
+		"birth number with invalid date": {dic: "CZ8013010008"},
+		"birth number with zero day":     {dic: "CZ530100123"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := CreateDic(test.dic)
+			if err == nil {
+				t.Fatalf("Expected error, got nil")
+			}
+		})
+	}
+}